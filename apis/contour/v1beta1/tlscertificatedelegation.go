@@ -14,6 +14,7 @@
 package v1beta1
 
 import (
+	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -46,7 +47,8 @@ type TLSCertificateDelegation struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec TLSCertificateDelegationSpec `json:"spec"`
+	Spec               TLSCertificateDelegationSpec `json:"spec"`
+	projcontour.Status `json:"status"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
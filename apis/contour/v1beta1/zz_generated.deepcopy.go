@@ -254,6 +254,7 @@ func (in *TLSCertificateDelegation) DeepCopyInto(out *TLSCertificateDelegation)
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
 	return
 }
 
@@ -22,12 +22,17 @@ type HTTPProxySpec struct {
 	// Virtualhost appears at most once. If it is present, the object is considered
 	// to be a "root".
 	VirtualHost *VirtualHost `json:"virtualhost,omitempty"`
-	// Routes are the ingress routes. If TCPProxy is present, Routes is ignored.
+	// Routes are the ingress routes. If TCPProxy is present, Routes are only
+	// served over insecure HTTP.
 	Routes []Route `json:"routes"`
 	// TCPProxy holds TCP proxy information.
 	TCPProxy *TCPProxy `json:"tcpproxy,omitempty"`
 	// Includes allow for specific routing configuration to be appended to another HTTPProxy in another namespace.
 	Includes []Include `json:"includes,omitempty"`
+	// TracingPolicy overrides the sampling rate of the requests served by
+	// the routes of this HTTPProxy. Tracing must be configured in Contour's
+	// configuration file.
+	TracingPolicy *TracingPolicy `json:"tracingPolicy,omitempty"`
 }
 
 // Include describes a set of policies that can be applied to an HTTPProxy in a namespace.
@@ -44,6 +49,14 @@ type Include struct {
 type Condition struct {
 	// Prefix defines a prefix match for a request.
 	Prefix string `json:"prefix,omitempty"`
+	// Exact defines an exact match for the request path.
+	// Only valid on route conditions, at most one of
+	// prefix, exact, or regex may be specified.
+	Exact string `json:"exact,omitempty"`
+	// Regex defines a regular expression, in RE2 syntax, which
+	// must match the whole request path. Only valid on route
+	// conditions, at most one of prefix, exact, or regex may be specified.
+	Regex string `json:"regex,omitempty"`
 	// HeadersMatch represent a set of HTTP headers that match the key/value exactly as specified.
 	HeadersMatch map[string][]string `json:"headersMatch,omitempty"`
 	// HeadersContain represent a set of HTTP headers that match the key exactly and the value as a contains.
@@ -54,12 +67,67 @@ type Condition struct {
 // to be a "root".
 type VirtualHost struct {
 	// The fully qualified domain name of the root of the ingress tree
-	// all leaves of the DAG rooted at this object relate to the fqdn
+	// all leaves of the DAG rooted at this object relate to the fqdn.
+	// HTTPProxy permits a wildcard as the leftmost label, eg. *.example.com,
+	// a root with an exact fqdn takes precedence over a matching wildcard.
+	// A wildcard fqdn does not match a Host header which includes a port.
 	Fqdn string `json:"fqdn"`
 	// If present describes tls properties. The CNI names that will be matched on
 	// are described in fqdn, the tls.secretName secret must contain a
 	// matching certificate
 	TLS *TLS `json:"tls,omitempty"`
+	// The policy for rate limiting on the virtual host.
+	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// Authorization configures an external authorization service
+	// which must allow each request before it is forwarded.
+	// Authorization requires tls.secretName to be set.
+	Authorization *AuthorizationServer `json:"authorization,omitempty"`
+	// CORSPolicy allows cross-origin requests to the virtual host.
+	// The cors HTTP filter must be enabled on the listener.
+	CORSPolicy *CORSPolicy `json:"corsPolicy,omitempty"`
+}
+
+// CORSPolicy describes the cross-origin requests permitted by a virtual host.
+type CORSPolicy struct {
+	// AllowOrigin is the list of origins which may make cross-origin
+	// requests. The origin "*" allows requests from any origin.
+	AllowOrigin []string `json:"allowOrigin"`
+	// AllowMethods is the list of methods permitted in cross-origin requests.
+	AllowMethods []string `json:"allowMethods,omitempty"`
+	// AllowHeaders is the list of headers permitted in cross-origin requests.
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+	// ExposeHeaders is the list of response headers browsers may expose.
+	ExposeHeaders []string `json:"exposeHeaders,omitempty"`
+	// MaxAge is how long the result of a preflight request
+	// may be cached, for example "10m".
+	MaxAge string `json:"maxAge,omitempty"`
+	// AllowCredentials permits cross-origin requests with credentials.
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
+}
+
+// AuthorizationServer references a Kubernetes Service which implements
+// the Envoy external authorization gRPC protocol. The Service must be in
+// the namespace of the HTTPProxy, and its port must use the h2 or h2c
+// upstream protocol, set with the contour.heptio.com/upstream-protocol
+// annotation.
+type AuthorizationServer struct {
+	// Name is the name of the Kubernetes service.
+	Name string `json:"name"`
+	// Port (defined as Integer) on the service.
+	Port int `json:"port"`
+	// FailOpen allows requests when the authorization service
+	// cannot be reached or returns an error.
+	FailOpen bool `json:"failOpen,omitempty"`
+	// ResponseTimeout is the time to wait for a response from
+	// the authorization service, for example "500ms".
+	ResponseTimeout string `json:"responseTimeout,omitempty"`
+}
+
+// AuthorizationPolicy modifies how requests on a route are authorized.
+type AuthorizationPolicy struct {
+	// Disabled disables external authorization for the route.
+	// Only the root HTTPProxy may disable authorization.
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // TLS describes tls properties. The CNI names that will be matched on
@@ -70,10 +138,59 @@ type TLS struct {
 	SecretName string `json:"secretName,omitempty"`
 	// Minimum TLS version this vhost should negotiate
 	MinimumProtocolVersion string `json:"minimumProtocolVersion,omitempty"`
+	// Maximum TLS version this vhost should negotiate, which
+	// cannot exceed the maximum version Contour is configured with.
+	MaximumProtocolVersion string `json:"maximumProtocolVersion,omitempty"`
+	// CipherSuites restricts the cipher suites negotiated for TLS 1.2
+	// and earlier to a subset of those Contour is configured with.
+	CipherSuites []string `json:"cipherSuites,omitempty"`
+	// ECDHCurves restricts the ECDH curves offered to a
+	// subset of those Contour is configured with.
+	ECDHCurves []string `json:"ecdhCurves,omitempty"`
 	// If Passthrough is set to true, the SecretName will be ignored
 	// and the encrypted handshake will be passed through to the
 	// backing cluster.
 	Passthrough bool `json:"passthrough,omitempty"`
+	// ClientValidation requires clients to present a certificate
+	// signed by a trusted CA when they establish a TLS connection.
+	// ClientValidation requires tls.secretName to be set.
+	ClientValidation *DownstreamValidation `json:"clientValidation,omitempty"`
+	// EnableFallbackCertificate serves this vhost to clients which do
+	// not send SNI, using the fallback certificate Contour is configured
+	// with and routing on the Host header. It requires tls.secretName
+	// and cannot be used with clientValidation, authorization, or the
+	// TLS parameters above, as the fallback certificate is served with
+	// the TLS parameters of the listener.
+	EnableFallbackCertificate bool `json:"enableFallbackCertificate,omitempty"`
+}
+
+// DownstreamValidation defines how to verify the certificates
+// presented by clients.
+type DownstreamValidation struct {
+	// Name of a Kubernetes secret, in the namespace of the HTTPProxy,
+	// whose ca.crt key holds the CA certificates which must have
+	// signed the client certificate.
+	CACertificate string `json:"caSecret"`
+	// ForwardClientCertificate adds the selected details of the client
+	// certificate to requests in the x-forwarded-client-cert header.
+	// If not set, the x-forwarded-client-cert header is removed.
+	ForwardClientCertificate *ClientCertificateDetails `json:"forwardClientCertificate,omitempty"`
+}
+
+// ClientCertificateDetails selects the details of the client certificate
+// which are forwarded in the x-forwarded-client-cert header. The hash of
+// the certificate is always forwarded.
+type ClientCertificateDetails struct {
+	// Subject forwards the subject of the certificate.
+	Subject bool `json:"subject,omitempty"`
+	// Cert forwards the entire certificate, URL encoded PEM.
+	Cert bool `json:"cert,omitempty"`
+	// Chain forwards the entire certificate chain, URL encoded PEM.
+	Chain bool `json:"chain,omitempty"`
+	// DNS forwards the DNS type subject alternative names.
+	DNS bool `json:"dns,omitempty"`
+	// URI forwards the URI type subject alternative name.
+	URI bool `json:"uri,omitempty"`
 }
 
 // Route contains the set of routes for a virtual host
@@ -86,6 +203,7 @@ type Route struct {
 	EnableWebsockets bool `json:"enableWebsockets,omitempty"`
 	// Allow this path to respond to insecure requests over HTTP which are normally
 	// not permitted when a `virtualhost.tls` block is present.
+	// Ignored if the virtual host has an authorization service.
 	PermitInsecure bool `json:"permitInsecure,omitempty"`
 	// Indicates that during forwarding, the matched prefix (or path) should be swapped with this value
 	PrefixRewrite string `json:"prefixRewrite,omitempty"`
@@ -93,6 +211,30 @@ type Route struct {
 	TimeoutPolicy *TimeoutPolicy `json:"timeoutPolicy,omitempty"`
 	// The retry policy for this route
 	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// The policy for rate limiting on the route.
+	// If set, the virtual host's rate limit policy is not applied to this route.
+	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// The authorization policy for the route.
+	AuthPolicy *AuthorizationPolicy `json:"authPolicy,omitempty"`
+	// The policy for managing request headers during proxying.
+	RequestHeadersPolicy *HeadersPolicy `json:"requestHeadersPolicy,omitempty"`
+	// The policy for managing response headers during proxying.
+	ResponseHeadersPolicy *HeadersPolicy `json:"responseHeadersPolicy,omitempty"`
+	// The policy for rewriting the Host header during proxying.
+	// If not specified, routes whose services include an ExternalName
+	// service rewrite the Host header to the upstream DNS name.
+	// An empty policy disables this default.
+	HostRewritePolicy *HostRewritePolicy `json:"hostRewritePolicy,omitempty"`
+}
+
+// HostRewritePolicy defines how the Host header is rewritten during forwarding.
+// At most one of Hostname or Auto may be specified.
+type HostRewritePolicy struct {
+	// Hostname is the literal value to which the Host header is rewritten.
+	Hostname string `json:"hostname,omitempty"`
+	// Auto rewrites the Host header to the DNS name of the upstream
+	// host selected. Only applies to ExternalName services.
+	Auto bool `json:"auto,omitempty"`
 }
 
 // TCPProxy contains the set of services to proxy TCP connections.
@@ -118,6 +260,32 @@ type Service struct {
 	Strategy string `json:"strategy,omitempty"`
 	// UpstreamValidation defines how to verify the backend service's certificate
 	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
+	// The policy for managing request headers during proxying to this service.
+	RequestHeadersPolicy *HeadersPolicy `json:"requestHeadersPolicy,omitempty"`
+	// The policy for managing response headers during proxying from this service.
+	ResponseHeadersPolicy *HeadersPolicy `json:"responseHeadersPolicy,omitempty"`
+	// ConnectTimeout is the timeout for new connections to the service, for
+	// example "2s". If not supplied, Contour's configured default is used.
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+}
+
+// HeadersPolicy defines how headers are managed during forwarding.
+// The Host header and HTTP/2 pseudo headers, such as :path,
+// cannot be managed.
+type HeadersPolicy struct {
+	// Set specifies a list of HTTP header values that will be set in the HTTP header.
+	// If the header does not exist it will be added, otherwise it will be overwritten.
+	Set []HeaderValue `json:"set,omitempty"`
+	// Remove specifies a list of HTTP header names to remove.
+	Remove []string `json:"remove,omitempty"`
+}
+
+// HeaderValue represents a header name/value pair
+type HeaderValue struct {
+	// Name represents a key of a header
+	Name string `json:"name"`
+	// Value represents the value of a header specified by a key
+	Value string `json:"value"`
 }
 
 // HealthCheck defines optional healthchecks on the upstream service
@@ -145,6 +313,12 @@ type TimeoutPolicy struct {
 	Request string `json:"request"`
 }
 
+// TracingPolicy defines the tracing settings of the routes of an HTTPProxy.
+type TracingPolicy struct {
+	// Sampling is the percentage, from 0 to 100, of requests which are traced.
+	Sampling float64 `json:"sampling"`
+}
+
 // RetryPolicy define the attributes associated with retrying policy
 type RetryPolicy struct {
 	// NumRetries is maximum allowed number of retries.
@@ -155,6 +329,56 @@ type RetryPolicy struct {
 	PerTryTimeout string `json:"perTryTimeout,omitempty"`
 }
 
+// RateLimitPolicy defines rate limiting for a virtual host or route.
+// Requests are counted against the rate limit service configured
+// in Contour's configuration file.
+type RateLimitPolicy struct {
+	// Descriptors defines the descriptors sent to the rate limit
+	// service for each request. A request is limited if any
+	// descriptor exceeds its limit.
+	Descriptors []RateLimitDescriptor `json:"descriptors,omitempty"`
+}
+
+// RateLimitDescriptor defines a list of entries which together
+// form a single descriptor sent to the rate limit service.
+type RateLimitDescriptor struct {
+	// Entries is the list of key-value pair generators.
+	Entries []RateLimitDescriptorEntry `json:"entries,omitempty"`
+}
+
+// RateLimitDescriptorEntry is a key-value pair generator. Exactly one
+// field on this struct must be non-nil.
+type RateLimitDescriptorEntry struct {
+	// GenericKey defines a descriptor entry with a static key and value.
+	GenericKey *GenericKeyDescriptor `json:"genericKey,omitempty"`
+	// RequestHeader defines a descriptor entry that's populated only if
+	// a given header is present on the request. The descriptor key is static,
+	// and the descriptor value is equal to the value of the header.
+	RequestHeader *RequestHeaderDescriptor `json:"requestHeader,omitempty"`
+	// RemoteAddress defines a descriptor entry with a key of "remote_address"
+	// and a value equal to the client's IP address (from x-forwarded-for).
+	RemoteAddress *RemoteAddressDescriptor `json:"remoteAddress,omitempty"`
+}
+
+// GenericKeyDescriptor defines a descriptor entry with a static value.
+type GenericKeyDescriptor struct {
+	// Value defines the value of the descriptor entry.
+	Value string `json:"value"`
+}
+
+// RequestHeaderDescriptor defines a descriptor entry that's populated only if
+// a given header is present on the request.
+type RequestHeaderDescriptor struct {
+	// HeaderName defines the name of the header to look for on the request.
+	HeaderName string `json:"headerName"`
+	// DescriptorKey defines the key to use on the descriptor entry.
+	DescriptorKey string `json:"descriptorKey"`
+}
+
+// RemoteAddressDescriptor defines a descriptor entry with a key of
+// "remote_address" and a value equal to the client's IP address.
+type RemoteAddressDescriptor struct{}
+
 // UpstreamValidation defines how to verify the backend service's certificate
 type UpstreamValidation struct {
 	// Name of the Kubernetes secret be used to validate the certificate presented by the backend
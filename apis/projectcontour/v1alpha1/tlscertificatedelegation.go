@@ -46,7 +46,8 @@ type TLSCertificateDelegation struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec TLSCertificateDelegationSpec `json:"spec"`
+	Spec   TLSCertificateDelegationSpec `json:"spec"`
+	Status `json:"status"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
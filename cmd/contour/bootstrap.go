@@ -19,6 +19,7 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/heptio/contour/internal/envoy"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
 )
 
 // registerBootstrap registers the bootstrap subcommand and flags
@@ -28,6 +29,7 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *bootstrap
 
 	bootstrap := app.Command("bootstrap", "Generate bootstrap configuration.")
 	bootstrap.Arg("path", "Configuration file.").Required().StringVar(&ctx.path)
+	bootstrap.Flag("config-path", "path to the contour serve configuration file").Short('c').ExistingFileVar(&ctx.configFile)
 	bootstrap.Flag("admin-address", "Envoy admin interface address").StringVar(&ctx.config.AdminAddress)
 	bootstrap.Flag("admin-port", "Envoy admin interface port").IntVar(&ctx.config.AdminPort)
 	bootstrap.Flag("xds-address", "xDS gRPC API address").StringVar(&ctx.config.XDSAddress)
@@ -42,10 +44,27 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *bootstrap
 type bootstrapContext struct {
 	config envoy.BootstrapConfig
 	path   string
+
+	// configFile is the contour serve configuration file from
+	// which the location of the trace collector, rate limit
+	// service, and access log service are read.
+	configFile string
 }
 
 // doBootstrap writes an Envoy bootstrap configuration file to the supplied path.
 func doBootstrap(ctx *bootstrapContext) {
+	if ctx.configFile != "" {
+		serveCtx, err := loadServeContext(ctx.configFile)
+		check(err)
+		check(serveCtx.TracingConfig.validate())
+		check(serveCtx.RateLimitServiceConfig.validate())
+		check(serveCtx.AccessLogConfig.validate())
+		check(serveCtx.validateBootstrapServices())
+		ctx.config.Tracing = serveCtx.bootstrapTracing()
+		ctx.config.RateLimitService = serveCtx.bootstrapRateLimitService()
+		ctx.config.AccessLogService = serveCtx.bootstrapAccessLogService()
+	}
+
 	f, err := os.Create(ctx.path)
 	check(err)
 	bs := envoy.Bootstrap(&ctx.config)
@@ -54,3 +73,15 @@ func doBootstrap(ctx *bootstrapContext) {
 	check(err)
 	check(f.Close())
 }
+
+// loadServeContext returns a serveContext initialized to
+// defaults and overridden by the configuration file at path.
+func loadServeContext(path string) (*serveContext, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ctx := newServeContext()
+	return ctx, yaml.NewDecoder(f).Decode(ctx)
+}
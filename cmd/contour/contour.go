@@ -91,6 +91,14 @@ func main() {
 		// on top of any values sourced from -c's config file.
 		_, err := app.Parse(args)
 		check(err)
+		check(serveCtx.TLSConfig.validate())
+		check(serveCtx.AccessLogConfig.validate())
+		check(serveCtx.TracingConfig.validate())
+		check(serveCtx.RateLimitServiceConfig.validate())
+		check(serveCtx.validateBootstrapServices())
+		check(serveCtx.TimeoutConfig.validate())
+		check(serveCtx.HTTPFilterConfig.validate())
+		check(serveCtx.LeaderElectionConfig.validate())
 		log.Infof("args: %v", args)
 		doServe(log, serveCtx)
 	default:
@@ -19,23 +19,41 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
 	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
+// Lock types accepted by LeaderElectionConfig.validate.
+const (
+	lockTypeConfigMaps = resourcelock.ConfigMapsResourceLock
+	lockTypeLeases     = resourcelock.LeasesResourceLock
+
+	// lockTypeConfigMapsLeases acquires both a ConfigMap and a Lease so
+	// that Contours using either lock type agree on the leader while a
+	// deployment is migrated from ConfigMap to Lease locks.
+	lockTypeConfigMapsLeases = "configmapsleases"
+)
+
+// unknownLeader is the identity reported by a multiLock
+// whose primary and secondary locks have different holders.
+const unknownLeader = "leaderelection.k8s.io/unknown"
+
 // newLeaderElector creates a new leaderelection.LeaderElector and associated
 // channels by which to observe elections and depositions.
-func newLeaderElector(log logrus.FieldLogger, ctx *serveContext, client *kubernetes.Clientset, coordinationClient *coordinationv1.CoordinationV1Client) (*leaderelection.LeaderElector, chan struct{}, chan struct{}) {
+func newLeaderElector(log logrus.FieldLogger, ctx *serveContext, client kubernetes.Interface, coordinationClient coordinationv1.CoordinationV1Interface) (*leaderelection.LeaderElector, chan struct{}, chan struct{}) {
 
-	// leaderOK will block gRPC startup until it's closed.
+	// leaderOK is closed when we are elected leader, after
+	// which the status of objects may be written.
 	leaderOK := make(chan struct{})
 	// deposed is closed by the leader election callback when
 	// we are deposed as leader so that we can clean up.
 	deposed := make(chan struct{})
 
-	rl := newResourceLock(ctx, client, coordinationClient)
+	rl, err := newResourceLock(ctx, client, coordinationClient)
+	check(err)
 
 	// Make the leader elector, ready to be used in the Workgroup.
 	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
@@ -62,28 +80,115 @@ func newLeaderElector(log logrus.FieldLogger, ctx *serveContext, client *kuberne
 	return le, leaderOK, deposed
 }
 
-// newResourceLock creates a new resourcelock.Interface based on the Pod's name,
-// or a uuid if the name cannot be determined.
-func newResourceLock(ctx *serveContext, client *kubernetes.Clientset, coordinationClient *coordinationv1.CoordinationV1Client) resourcelock.Interface {
+// newResourceLock creates a new resourcelock.Interface of the configured
+// lock type based on the Pod's name, or a uuid if the name cannot be determined.
+func newResourceLock(ctx *serveContext, client kubernetes.Interface, coordinationClient coordinationv1.CoordinationV1Interface) (resourcelock.Interface, error) {
 	resourceLockID, found := os.LookupEnv("POD_NAME")
 	if !found {
 		resourceLockID = uuid.New().String()
 	}
 
-	rl, err := resourcelock.New(
-		// TODO(youngnick) change this to a Lease object instead
-		// of the configmap once the Lease API has been GA for a full support
-		// cycle (ie nine months).
-		// Figure out the resource lock ID
-		resourcelock.ConfigMapsResourceLock,
-		ctx.LeaderElectionConfig.Namespace,
-		ctx.LeaderElectionConfig.Name,
-		client.CoreV1(),
-		coordinationClient,
-		resourcelock.ResourceLockConfig{
-			Identity: resourceLockID,
-		},
-	)
-	check(err)
-	return rl
+	lock := func(lockType string) (resourcelock.Interface, error) {
+		return resourcelock.New(
+			lockType,
+			ctx.LeaderElectionConfig.Namespace,
+			ctx.LeaderElectionConfig.Name,
+			client.CoreV1(),
+			coordinationClient,
+			resourcelock.ResourceLockConfig{
+				Identity: resourceLockID,
+			},
+		)
+	}
+
+	// The lock type has been checked by LeaderElectionConfig.validate
+	// at startup.
+	switch lockType := ctx.LeaderElectionConfig.LockType; lockType {
+	case "":
+		return lock(lockTypeConfigMaps)
+	case lockTypeConfigMapsLeases:
+		primary, err := lock(lockTypeConfigMaps)
+		if err != nil {
+			return nil, err
+		}
+		secondary, err := lock(lockTypeLeases)
+		if err != nil {
+			return nil, err
+		}
+		return &multiLock{
+			primary:   primary,
+			secondary: secondary,
+		}, nil
+	default:
+		return lock(lockType)
+	}
+}
+
+// multiLock is a resourcelock.Interface which holds a primary and
+// a secondary lock. Leadership requires holding both, so Contours
+// locking only the primary, or only the secondary, will agree on
+// the leader with Contours using a multiLock.
+type multiLock struct {
+	primary, secondary resourcelock.Interface
+}
+
+// Get returns the primary lock's record. If the secondary lock is held by
+// someone else the holder is reported as unknown so neither lock is taken
+// until both have expired.
+func (ml *multiLock) Get() (*resourcelock.LeaderElectionRecord, error) {
+	primary, err := ml.primary.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	secondary, err := ml.secondary.Get()
+	if err != nil {
+		// the secondary lock does not exist yet, the primary
+		// lock is held by a Contour that predates the multiLock.
+		if errors.IsNotFound(err) && primary.HolderIdentity != ml.Identity() {
+			return primary, nil
+		}
+		return nil, err
+	}
+
+	if primary.HolderIdentity != secondary.HolderIdentity {
+		primary.HolderIdentity = unknownLeader
+	}
+	return primary, nil
+}
+
+// Create creates both locks, tolerating a primary lock
+// which was created by a Contour that predates the multiLock.
+func (ml *multiLock) Create(ler resourcelock.LeaderElectionRecord) error {
+	if err := ml.primary.Create(ler); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return ml.secondary.Create(ler)
+}
+
+// Update updates both locks, creating the secondary lock if it does not exist.
+func (ml *multiLock) Update(ler resourcelock.LeaderElectionRecord) error {
+	if err := ml.primary.Update(ler); err != nil {
+		return err
+	}
+	if _, err := ml.secondary.Get(); err != nil {
+		if errors.IsNotFound(err) {
+			return ml.secondary.Create(ler)
+		}
+		return err
+	}
+	return ml.secondary.Update(ler)
+}
+
+func (ml *multiLock) RecordEvent(s string) {
+	ml.primary.RecordEvent(s)
+	ml.secondary.RecordEvent(s)
+}
+
+func (ml *multiLock) Identity() string {
+	return ml.primary.Identity()
+}
+
+func (ml *multiLock) Describe() string {
+	return ml.primary.Describe() + "," + ml.secondary.Describe()
 }
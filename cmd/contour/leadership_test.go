@@ -0,0 +1,181 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestNewResourceLock(t *testing.T) {
+	tests := map[string]struct {
+		lockType string
+		want     string
+	}{
+		"default": {
+			lockType: "",
+			want:     "heptio-contour/leader-elect",
+		},
+		"configmaps": {
+			lockType: "configmaps",
+			want:     "heptio-contour/leader-elect",
+		},
+		"leases": {
+			lockType: "leases",
+			want:     "heptio-contour/leader-elect",
+		},
+		"configmapsleases": {
+			lockType: "configmapsleases",
+			want:     "heptio-contour/leader-elect,heptio-contour/leader-elect",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newServeContext()
+			ctx.LeaderElectionConfig.LockType = tc.lockType
+			client := fake.NewSimpleClientset()
+			rl, err := newResourceLock(ctx, client, client.CoordinationV1())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, rl.Describe()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestNewResourceLockLeases(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := newServeContext()
+	ler := resourcelock.LeaderElectionRecord{
+		HolderIdentity:       "contour-0",
+		LeaseDurationSeconds: 15,
+	}
+
+	ctx.LeaderElectionConfig.LockType = "leases"
+	rl, err := newResourceLock(ctx, client, client.CoordinationV1())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Create(ler); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoordinationV1().Leases("heptio-contour").Get("leader-elect", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected lease to be created: %v", err)
+	}
+	if _, err := client.CoreV1().ConfigMaps("heptio-contour").Get("leader-elect", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected configmap not to be created")
+	}
+}
+
+func TestMultiLock(t *testing.T) {
+	lock := func(client *fake.Clientset, lockType, identity string) resourcelock.Interface {
+		t.Helper()
+		rl, err := resourcelock.New(lockType, "heptio-contour", "leader-elect",
+			client.CoreV1(), client.CoordinationV1(),
+			resourcelock.ResourceLockConfig{Identity: identity})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rl
+	}
+	record := func(identity string) resourcelock.LeaderElectionRecord {
+		return resourcelock.LeaderElectionRecord{
+			HolderIdentity:       identity,
+			LeaseDurationSeconds: 15,
+		}
+	}
+	multi := func(client *fake.Clientset, identity string) *multiLock {
+		return &multiLock{
+			primary:   lock(client, resourcelock.ConfigMapsResourceLock, identity),
+			secondary: lock(client, resourcelock.LeasesResourceLock, identity),
+		}
+	}
+
+	t.Run("create both locks", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		ml := multi(client, "contour-0")
+		if err := ml.Create(record("contour-0")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ml.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff("contour-0", got.HolderIdentity); diff != "" {
+			t.Fatal(diff)
+		}
+		if _, err := client.CoordinationV1().Leases("heptio-contour").Get("leader-elect", metav1.GetOptions{}); err != nil {
+			t.Fatalf("expected lease to be created: %v", err)
+		}
+	})
+
+	t.Run("primary held by configmap lock", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		if err := lock(client, resourcelock.ConfigMapsResourceLock, "contour-old").Create(record("contour-old")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := multi(client, "contour-0").Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff("contour-old", got.HolderIdentity); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("locks held by different leaders", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		if err := lock(client, resourcelock.ConfigMapsResourceLock, "contour-old").Create(record("contour-old")); err != nil {
+			t.Fatal(err)
+		}
+		if err := lock(client, resourcelock.LeasesResourceLock, "contour-new").Create(record("contour-new")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := multi(client, "contour-0").Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(unknownLeader, got.HolderIdentity); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("update creates missing secondary", func(t *testing.T) {
+		client := fake.NewSimpleClientset()
+		if err := lock(client, resourcelock.ConfigMapsResourceLock, "contour-old").Create(record("contour-old")); err != nil {
+			t.Fatal(err)
+		}
+		ml := multi(client, "contour-0")
+		if _, err := ml.Get(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ml.Update(record("contour-0")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ml.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff("contour-0", got.HolderIdentity); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -37,6 +37,7 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 	"gopkg.in/yaml.v2"
 	coreinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 )
 
 // registerServe registers the serve subcommand and flags
@@ -125,12 +126,13 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 
 	// Create a set of SharedInformerFactories for each root-ingressroute namespace (if defined)
 	var namespacedInformers []coreinformers.SharedInformerFactory
-	for _, namespace := range ctx.ingressRouteRootNamespaces() {
+	for _, namespace := range ctx.secretNamespaces() {
 		inf := coreinformers.NewSharedInformerFactoryWithOptions(client, 0, coreinformers.WithNamespace(namespace))
 		namespacedInformers = append(namespacedInformers, inf)
 	}
 
 	// step 3. build our mammoth Kubernetes event handler.
+	tlsPolicy := ctx.tlsPolicy()
 	eh := &contour.EventHandler{
 		CacheHandler: &contour.CacheHandler{
 			ListenerVisitorConfig: contour.ListenerVisitorConfig{
@@ -141,7 +143,25 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 				HTTPSAddress:           ctx.httpsAddr,
 				HTTPSPort:              ctx.httpsPort,
 				HTTPSAccessLog:         ctx.httpsAccessLog,
-				MinimumProtocolVersion: dag.MinProtoVersion(ctx.TLSConfig.MinimumProtocolVersion),
+				HTTPFilters:            httpFilters(ctx.HTTPFilterConfig.HTTP),
+				HTTPSFilters:           httpFilters(ctx.HTTPFilterConfig.HTTPS),
+				AccessLogTextFormat:    ctx.accessLogTextFormat(),
+				AccessLogJSONFields:    ctx.accessLogJSONFields(),
+				AccessLogService:       ctx.accessLogService(),
+				MinimumProtocolVersion: tlsPolicy.MinimumProtocolVersion,
+				MaximumProtocolVersion: tlsPolicy.MaximumProtocolVersion,
+				CipherSuites:           tlsPolicy.CipherSuites,
+				ECDHCurves:             tlsPolicy.ECDHCurves,
+				RateLimitService:       ctx.rateLimitService(),
+				Tracing:                ctx.tracing(),
+				ConnectionIdleTimeout:  ctx.TimeoutConfig.ConnectionIdleTimeout,
+				TCPIdleTimeout:         ctx.TimeoutConfig.TCPIdleTimeout,
+				StreamIdleTimeout:      ctx.TimeoutConfig.StreamIdleTimeout,
+				RequestTimeout:         ctx.TimeoutConfig.RequestTimeout,
+				DrainTimeout:           ctx.TimeoutConfig.DrainTimeout,
+			},
+			ClusterVisitorConfig: contour.ClusterVisitorConfig{
+				ConnectTimeout: ctx.TimeoutConfig.ConnectTimeout,
 			},
 			ListenerCache: contour.NewListenerCache(ctx.statsAddr, ctx.statsPort),
 			FieldLogger:   log.WithField("context", "CacheHandler"),
@@ -158,25 +178,35 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 				FieldLogger:    log.WithField("context", "KubernetesCache"),
 			},
 			DisablePermitInsecure: ctx.DisablePermitInsecure,
+			TLSPolicy:             tlsPolicy,
+			FallbackCertificate:   ctx.fallbackCertificate(),
+
+			RateLimitServiceConfigured: ctx.rateLimitService() != nil,
 		},
 		FieldLogger: log.WithField("context", "contourEventHandler"),
 	}
 
 	// step 4. register our resource event handler with the k8s informers.
-	coreInformers.Core().V1().Services().Informer().AddEventHandler(eh)
-	coreInformers.Extensions().V1beta1().Ingresses().Informer().AddEventHandler(eh)
-	contourInformers.Contour().V1beta1().IngressRoutes().Informer().AddEventHandler(eh)
-	contourInformers.Contour().V1beta1().TLSCertificateDelegations().Informer().AddEventHandler(eh)
-	contourInformers.Projectcontour().V1alpha1().HTTPProxies().Informer().AddEventHandler(eh)
-	contourInformers.Projectcontour().V1alpha1().TLSCertificateDelegations().Informer().AddEventHandler(eh)
+	// Contour is not ready until each of these informers has synced.
+	var informersSynced []cache.InformerSynced
+	addEventHandler := func(inf cache.SharedIndexInformer, handler cache.ResourceEventHandler) {
+		inf.AddEventHandler(handler)
+		informersSynced = append(informersSynced, inf.HasSynced)
+	}
+	addEventHandler(coreInformers.Core().V1().Services().Informer(), eh)
+	addEventHandler(coreInformers.Extensions().V1beta1().Ingresses().Informer(), eh)
+	addEventHandler(contourInformers.Contour().V1beta1().IngressRoutes().Informer(), eh)
+	addEventHandler(contourInformers.Contour().V1beta1().TLSCertificateDelegations().Informer(), eh)
+	addEventHandler(contourInformers.Projectcontour().V1alpha1().HTTPProxies().Informer(), eh)
+	addEventHandler(contourInformers.Projectcontour().V1alpha1().TLSCertificateDelegations().Informer(), eh)
 
 	// Add informers for each root-ingressroute namespaces
 	for _, inf := range namespacedInformers {
-		inf.Core().V1().Secrets().Informer().AddEventHandler(eh)
+		addEventHandler(inf.Core().V1().Secrets().Informer(), eh)
 	}
 	// If root-ingressroutes are not defined, then add the informer for all namespaces
 	if len(namespacedInformers) == 0 {
-		coreInformers.Core().V1().Secrets().Informer().AddEventHandler(eh)
+		addEventHandler(coreInformers.Core().V1().Secrets().Informer(), eh)
 	}
 
 	// step 5. endpoints updates are handled directly by the EndpointsTranslator
@@ -184,7 +214,7 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	et := &contour.EndpointsTranslator{
 		FieldLogger: log.WithField("context", "endpointstranslator"),
 	}
-	coreInformers.Core().V1().Endpoints().Informer().AddEventHandler(et)
+	addEventHandler(coreInformers.Core().V1().Endpoints().Informer(), et)
 
 	// step 6. setup workgroup runner and register informers.
 	var g workgroup.Group
@@ -194,7 +224,24 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		g.Add(startInformer(inf, log.WithField("context", "corenamespacedinformers")))
 	}
 
-	// step 7. register our event handler with the workgroup
+	// step 7. register our event handler with the workgroup. Once the
+	// informers have synced the event handler rebuilds the DAG and
+	// closes ready, then Contour reports ready and serves xDS.
+	synced := make(chan struct{})
+	g.Add(func(stop <-chan struct{}) error {
+		log := log.WithField("context", "informersync")
+		log.Info("waiting for informers to sync")
+		if cache.WaitForCacheSync(stop, informersSynced...) {
+			log.Info("informers synced")
+			close(synced)
+		}
+		<-stop
+		return nil
+	})
+
+	ready := make(chan struct{})
+	eh.Synced = synced
+	eh.Ready = ready
 	g.Add(eh.Start())
 
 	// step 8. setup prometheus registry and register base metrics.
@@ -211,29 +258,38 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		},
 		Client:   client,
 		Registry: registry,
+		Ready:    ready,
 	}
 	g.Add(metricsvc.Start)
 
 	// step 10. create debug service and register with workgroup.
+	// xdsStatus is shared between the debug service and the grpc
+	// handler so the ACK/NACK status of each Envoy can be inspected.
+	xdsStatus := new(cgrpc.StatusCache)
 	debugsvc := debug.Service{
 		Service: httpsvc.Service{
 			Addr:        ctx.debugAddr,
 			Port:        ctx.debugPort,
 			FieldLogger: log.WithField("context", "debugsvc"),
 		},
-		Builder: &eh.Builder,
+		Builder:   &eh.Builder,
+		XDSStatus: xdsStatus,
 	}
 	g.Add(debugsvc.Start)
 
 	// step 11. if enabled, register leader election
 	if !ctx.DisableLeaderElection {
 		log := log.WithField("context", "leaderelection")
-		le, _, deposed := newLeaderElector(log, ctx, client, coordinationClient)
+		le, leaderOK, deposed := newLeaderElector(log, ctx, client, coordinationClient)
+
+		// only the leader writes the status of objects.
+		eh.IsLeader = leaderOK
 
 		g.AddContext(func(electionCtx context.Context) {
 			log.WithFields(logrus.Fields{
 				"configmapname":      ctx.LeaderElectionConfig.Name,
 				"configmapnamespace": ctx.LeaderElectionConfig.Namespace,
+				"locktype":           ctx.LeaderElectionConfig.LockType,
 			}).Info("started")
 
 			le.Run(electionCtx)
@@ -254,6 +310,10 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		})
 	} else {
 		log.Info("Leader election disabled")
+
+		// without leader election every replica writes status.
+		eh.IsLeader = make(chan struct{})
+		close(eh.IsLeader)
 	}
 
 	// step 12. register our custom metrics and plumb into cache handler
@@ -263,17 +323,42 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	eh.CacheHandler.Metrics = metrics
 
 	// step 13. create grpc handler and register with workgroup.
+	// If configured, the grpc server also receives access logs from Envoy.
+	var accessLogSink cgrpc.AccessLogSink
+	switch receiver := ctx.AccessLogConfig.Receiver; receiver {
+	case "":
+		// access log service disabled.
+	case "log":
+		accessLogSink = &cgrpc.LogAccessLogSink{
+			FieldLogger: log.WithField("context", "accesslog"),
+		}
+	default:
+		f, err := os.OpenFile(receiver, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		accessLogSink = &cgrpc.JSONAccessLogSink{W: f}
+	}
 	g.Add(func(stop <-chan struct{}) error {
 		log := log.WithField("context", "grpc")
-		resources := map[string]cgrpc.Resource{
-			eh.CacheHandler.ClusterCache.TypeURL():  &eh.CacheHandler.ClusterCache,
-			eh.CacheHandler.RouteCache.TypeURL():    &eh.CacheHandler.RouteCache,
-			eh.CacheHandler.ListenerCache.TypeURL(): &eh.CacheHandler.ListenerCache,
-			eh.CacheHandler.SecretCache.TypeURL():   &eh.CacheHandler.SecretCache,
-			et.TypeURL():                            et,
+		config := cgrpc.APIConfig{
+			Resources: map[string]cgrpc.Resource{
+				eh.CacheHandler.ClusterCache.TypeURL():  &eh.CacheHandler.ClusterCache,
+				eh.CacheHandler.RouteCache.TypeURL():    &eh.CacheHandler.RouteCache,
+				eh.CacheHandler.ListenerCache.TypeURL(): &eh.CacheHandler.ListenerCache,
+				eh.CacheHandler.SecretCache.TypeURL():   &eh.CacheHandler.SecretCache,
+				et.TypeURL():                            et,
+			},
+			Status:  xdsStatus,
+			Metrics: metrics,
+			Ready:   ready,
 		}
 		opts := ctx.grpcOptions()
-		s := cgrpc.NewAPI(log, resources, opts...)
+		s := cgrpc.NewAPI(log, config, opts...)
+		if accessLogSink != nil {
+			cgrpc.RegisterAccessLogService(s, log.WithField("context", "accesslogservice"), accessLogSink)
+		}
 		addr := net.JoinHostPort(ctx.xdsAddr, strconv.Itoa(ctx.xdsPort))
 		l, err := net.Listen("tcp", addr)
 		if err != nil {
@@ -18,6 +18,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -26,8 +27,11 @@ import (
 	"time"
 
 	"github.com/heptio/contour/internal/contour"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/envoy"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type serveContext struct {
@@ -85,6 +89,21 @@ type serveContext struct {
 
 	// LeaderElectionConfig can be set in the config file.
 	LeaderElectionConfig `yaml:"leaderelection,omitempty"`
+
+	// RateLimitServiceConfig can be set in the config file.
+	RateLimitServiceConfig `yaml:"ratelimitservice,omitempty"`
+
+	// AccessLogConfig can be set in the config file.
+	AccessLogConfig `yaml:"accesslog,omitempty"`
+
+	// TracingConfig can be set in the config file.
+	TracingConfig `yaml:"tracing,omitempty"`
+
+	// TimeoutConfig can be set in the config file.
+	TimeoutConfig `yaml:"timeouts,omitempty"`
+
+	// HTTPFilterConfig can be set in the config file.
+	HTTPFilterConfig `yaml:"httpfilters,omitempty"`
 }
 
 // newServeContext returns a serveContext initialized to defaults.
@@ -115,6 +134,14 @@ func newServeContext() *serveContext {
 			RetryPeriod:   time.Second * 2,
 			Namespace:     "heptio-contour",
 			Name:          "leader-elect",
+			LockType:      "configmaps",
+		},
+		AccessLogConfig: AccessLogConfig{
+			Format: accessLogFormatEnvoy,
+		},
+		TracingConfig: TracingConfig{
+			ServicePort: 9411,
+			Sampling:    100,
 		},
 	}
 }
@@ -122,6 +149,80 @@ func newServeContext() *serveContext {
 // TLSConfig holds configuration file TLS configuration details.
 type TLSConfig struct {
 	MinimumProtocolVersion string `yaml:"minimum-protocol-version"`
+
+	// MaximumProtocolVersion is one of 1.1, 1.2, or 1.3.
+	// If not set, TLS 1.3 is the maximum.
+	MaximumProtocolVersion string `yaml:"maximum-protocol-version,omitempty"`
+
+	// CipherSuites are the cipher suites, or equal preference groups
+	// of them such as "[A|B]", negotiated for TLS 1.2 and earlier.
+	// If not set, Contour's default cipher suites are used.
+	CipherSuites []string `yaml:"cipher-suites,omitempty"`
+
+	// ECDHCurves are the ECDH curves offered to clients.
+	// If not set, Envoy's default curves are used.
+	ECDHCurves []string `yaml:"ecdh-curves,omitempty"`
+
+	// FallbackCertificate is the Secret whose cert and key are
+	// presented to clients which do not send SNI. It is only used
+	// by HTTPProxies which set tls.enableFallbackCertificate.
+	FallbackCertificate FallbackCertificate `yaml:"fallback-certificate,omitempty"`
+}
+
+// FallbackCertificate names the Secret of the fallback certificate.
+type FallbackCertificate struct {
+	Name      string `yaml:"name,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// validate returns an error if the maximum protocol version is
+// invalid or less than the minimum, or if a cipher suite or curve
+// is unknown.
+func (c TLSConfig) validate() error {
+	max, err := dag.ProtoVersion(c.MaximumProtocolVersion)
+	if err != nil {
+		return fmt.Errorf("tls: maximum-protocol-version: %v", err)
+	}
+	if max != 0 && max < dag.MinProtoVersion(c.MinimumProtocolVersion) {
+		return fmt.Errorf("tls: maximum-protocol-version %q is less than minimum-protocol-version", c.MaximumProtocolVersion)
+	}
+	if err := dag.ValidateCipherSuites(c.CipherSuites); err != nil {
+		return fmt.Errorf("tls: cipher-suites: %v", err)
+	}
+	if err := dag.ValidateECDHCurves(c.ECDHCurves); err != nil {
+		return fmt.Errorf("tls: ecdh-curves: %v", err)
+	}
+	if fc := c.FallbackCertificate; (fc.Name == "") != (fc.Namespace == "") {
+		return errors.New("tls: fallback-certificate: name and namespace must both be set")
+	}
+	return nil
+}
+
+// fallbackCertificate returns the Secret of the fallback
+// certificate, or nil if none is configured.
+func (ctx *serveContext) fallbackCertificate() *types.NamespacedName {
+	fc := ctx.TLSConfig.FallbackCertificate
+	if fc.Name == "" {
+		return nil
+	}
+	return &types.NamespacedName{
+		Name:      fc.Name,
+		Namespace: fc.Namespace,
+	}
+}
+
+// tlsPolicy returns the TLS policy of the HTTPS listener,
+// which HTTPProxy virtual hosts may only tighten.
+func (ctx *serveContext) tlsPolicy() dag.TLSPolicy {
+	// the configuration has been validated, so
+	// the maximum protocol version is valid.
+	max, _ := dag.ProtoVersion(ctx.TLSConfig.MaximumProtocolVersion)
+	return dag.TLSPolicy{
+		MinimumProtocolVersion: dag.MinProtoVersion(ctx.TLSConfig.MinimumProtocolVersion),
+		MaximumProtocolVersion: max,
+		CipherSuites:           ctx.TLSConfig.CipherSuites,
+		ECDHCurves:             ctx.TLSConfig.ECDHCurves,
+	}
 }
 
 // LeaderElectionConfig holds the config bits for leader election inside the
@@ -132,6 +233,404 @@ type LeaderElectionConfig struct {
 	RetryPeriod   time.Duration `yaml:"retry-period,omitempty"`
 	Namespace     string        `yaml:"configmap-namespace,omitempty"`
 	Name          string        `yaml:"configmap-name,omitempty"`
+
+	// LockType is the kind of resource used as the leader election
+	// lock, one of "configmaps", "leases", or "configmapsleases".
+	// configmapsleases holds both a ConfigMap and a Lease, which
+	// allows replicas to move from ConfigMap to Lease locks one at
+	// a time. Namespace and Name apply to every lock type.
+	LockType string `yaml:"lock-type,omitempty"`
+}
+
+// validate returns an error if the lock type is unknown.
+func (c LeaderElectionConfig) validate() error {
+	switch c.LockType {
+	case "", lockTypeConfigMaps, lockTypeLeases, lockTypeConfigMapsLeases:
+		return nil
+	default:
+		return fmt.Errorf("leaderelection: lock-type %q must be one of %q, %q, or %q",
+			c.LockType, lockTypeConfigMaps, lockTypeLeases, lockTypeConfigMapsLeases)
+	}
+}
+
+// RateLimitServiceConfig holds the configuration of the external
+// rate limit service inside the configuration file.
+type RateLimitServiceConfig struct {
+	// ClusterName is the name of the Envoy cluster serving the
+	// rate limit service. If not set, rate limiting is disabled.
+	ClusterName string        `yaml:"cluster-name,omitempty"`
+	Domain      string        `yaml:"domain,omitempty"`
+	FailOpen    bool          `yaml:"fail-open,omitempty"`
+	Timeout     time.Duration `yaml:"timeout,omitempty"`
+
+	// GRPCService, if set, is the Service from which
+	// contour bootstrap defines the cluster.
+	GRPCService `yaml:",inline"`
+}
+
+// GRPCService holds the location of a gRPC service running as
+// a Kubernetes Service inside the configuration file.
+type GRPCService struct {
+	ServiceName      string `yaml:"service-name,omitempty"`
+	ServiceNamespace string `yaml:"service-namespace,omitempty"`
+	ServicePort      int    `yaml:"service-port,omitempty"`
+}
+
+// validate returns an error if the Service is incomplete, or its
+// cluster is not named or is named after a bootstrap cluster.
+func (s GRPCService) validate(clusterName string) error {
+	if s.ServiceName == "" {
+		return nil
+	}
+	switch clusterName {
+	case "":
+		return errors.New("cluster-name must be set when service-name is set")
+	case "contour", "service-stats", "tracing":
+		return fmt.Errorf("cluster-name %q is already defined by contour bootstrap", clusterName)
+	}
+	if s.ServiceNamespace == "" {
+		return errors.New("service-namespace must be set when service-name is set")
+	}
+	if s.ServicePort < 1 || s.ServicePort > 65535 {
+		return fmt.Errorf("service-port %d must be in the range 1-65535", s.ServicePort)
+	}
+	return nil
+}
+
+// bootstrap returns the cluster named clusterName of the Envoy
+// bootstrap configuration, or nil if no Service is set.
+func (s GRPCService) bootstrap(clusterName string) *envoy.GRPCServiceConfig {
+	if s.ServiceName == "" {
+		return nil
+	}
+	return &envoy.GRPCServiceConfig{
+		ClusterName:      clusterName,
+		ServiceName:      s.ServiceName,
+		ServiceNamespace: s.ServiceNamespace,
+		ServicePort:      s.ServicePort,
+	}
+}
+
+// validate returns an error if the Service of the
+// rate limit service is set but incomplete.
+func (c RateLimitServiceConfig) validate() error {
+	if err := c.GRPCService.validate(c.ClusterName); err != nil {
+		return fmt.Errorf("ratelimitservice: %v", err)
+	}
+	return nil
+}
+
+// rateLimitService returns the rate limit service configuration for
+// the Envoy listeners, or nil if no rate limit service is configured.
+func (ctx *serveContext) rateLimitService() *contour.RateLimitServiceConfig {
+	rls := ctx.RateLimitServiceConfig
+	if rls.ClusterName == "" {
+		return nil
+	}
+	return &contour.RateLimitServiceConfig{
+		ClusterName: rls.ClusterName,
+		Domain:      rls.Domain,
+		FailOpen:    rls.FailOpen,
+		Timeout:     rls.Timeout,
+	}
+}
+
+// bootstrapRateLimitService returns the rate limit service cluster of
+// the Envoy bootstrap configuration, or nil if its Service is not set.
+func (ctx *serveContext) bootstrapRateLimitService() *envoy.GRPCServiceConfig {
+	rls := ctx.RateLimitServiceConfig
+	return rls.GRPCService.bootstrap(rls.ClusterName)
+}
+
+const (
+	accessLogFormatEnvoy = "envoy"
+	accessLogFormatText  = "text"
+	accessLogFormatJSON  = "json"
+)
+
+// AccessLogConfig holds the format of Envoy's HTTP and HTTPS
+// access logs inside the configuration file.
+type AccessLogConfig struct {
+	// Format is one of "envoy", Envoy's default format, "text",
+	// which uses TextFormat, or "json", which uses JSONFields.
+	Format string `yaml:"format,omitempty"`
+
+	// TextFormat is the format string of each access log entry.
+	TextFormat string `yaml:"text-format,omitempty"`
+
+	// JSONFields maps each field of a JSON access log
+	// entry to the format string of its value.
+	JSONFields map[string]string `yaml:"json-fields,omitempty"`
+
+	// GRPC additionally sends the HTTP and HTTPS access
+	// logs over the gRPC Access Log Service.
+	GRPC AccessLogServiceConfig `yaml:"grpc,omitempty"`
+
+	// Receiver is where Contour's own gRPC Access Log Service writes
+	// the entries it receives, either "log", for Contour's log, or the
+	// path of a file which is written as JSON. If not set, Contour
+	// does not serve the access log service.
+	Receiver string `yaml:"receiver,omitempty"`
+}
+
+// AccessLogServiceConfig holds the configuration of the gRPC
+// Access Log Service inside the configuration file.
+type AccessLogServiceConfig struct {
+	// ClusterName is the name of the Envoy cluster serving the
+	// access log service. If not set, access logs are not sent.
+	ClusterName string `yaml:"cluster-name,omitempty"`
+
+	// LogName identifies the access log to the service.
+	// If not set, defaults to "contour".
+	LogName string `yaml:"log-name,omitempty"`
+
+	// GRPCService, if set, is the Service from which
+	// contour bootstrap defines the cluster.
+	GRPCService `yaml:",inline"`
+}
+
+// validate returns an error if the access log format is unknown,
+// is missing its settings, or uses an unsupported command operator,
+// or if the Service of the access log service is incomplete.
+func (c AccessLogConfig) validate() error {
+	if err := c.GRPC.GRPCService.validate(c.GRPC.ClusterName); err != nil {
+		return fmt.Errorf("accesslog: grpc: %v", err)
+	}
+	switch c.Format {
+	case accessLogFormatEnvoy:
+		return nil
+	case accessLogFormatText:
+		if c.TextFormat == "" {
+			return errors.New("accesslog: text-format must be set when format is text")
+		}
+		if err := envoy.ValidateAccessLogFormat(c.TextFormat); err != nil {
+			return fmt.Errorf("accesslog: text-format: %v", err)
+		}
+		return nil
+	case accessLogFormatJSON:
+		if len(c.JSONFields) == 0 {
+			return errors.New("accesslog: json-fields must be set when format is json")
+		}
+		for k, v := range c.JSONFields {
+			if err := envoy.ValidateAccessLogFormat(v); err != nil {
+				return fmt.Errorf("accesslog: json-fields: %s: %v", k, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("accesslog: unknown format %q, must be one of %s, %s, or %s",
+			c.Format, accessLogFormatEnvoy, accessLogFormatText, accessLogFormatJSON)
+	}
+}
+
+// accessLogTextFormat returns the text format of the access logs,
+// or an empty string if the format is not text.
+func (ctx *serveContext) accessLogTextFormat() string {
+	if ctx.AccessLogConfig.Format != accessLogFormatText {
+		return ""
+	}
+	return ctx.AccessLogConfig.TextFormat
+}
+
+// accessLogJSONFields returns the JSON fields of the access logs,
+// or nil if the format is not json.
+func (ctx *serveContext) accessLogJSONFields() map[string]string {
+	if ctx.AccessLogConfig.Format != accessLogFormatJSON {
+		return nil
+	}
+	return ctx.AccessLogConfig.JSONFields
+}
+
+// accessLogService returns the gRPC access log service configuration
+// for the Envoy listeners, or nil if no access log service is configured.
+func (ctx *serveContext) accessLogService() *contour.AccessLogServiceConfig {
+	als := ctx.AccessLogConfig.GRPC
+	if als.ClusterName == "" {
+		return nil
+	}
+	logName := als.LogName
+	if logName == "" {
+		logName = "contour"
+	}
+	return &contour.AccessLogServiceConfig{
+		ClusterName: als.ClusterName,
+		LogName:     logName,
+	}
+}
+
+// bootstrapAccessLogService returns the access log service cluster of
+// the Envoy bootstrap configuration, or nil if its Service is not set.
+func (ctx *serveContext) bootstrapAccessLogService() *envoy.GRPCServiceConfig {
+	als := ctx.AccessLogConfig.GRPC
+	return als.GRPCService.bootstrap(als.ClusterName)
+}
+
+// validateBootstrapServices returns an error if the rate limit service
+// and the access log service both define a bootstrap cluster with the
+// same name, as Envoy rejects a bootstrap with duplicate clusters.
+func (ctx *serveContext) validateBootstrapServices() error {
+	rls, als := ctx.bootstrapRateLimitService(), ctx.bootstrapAccessLogService()
+	if rls != nil && als != nil && rls.ClusterName == als.ClusterName {
+		return fmt.Errorf("ratelimitservice and accesslog: grpc: cluster-name %q must be different", rls.ClusterName)
+	}
+	return nil
+}
+
+// TracingConfig holds the location of the trace collector and
+// the tracing settings of Envoy's HTTP and HTTPS listeners inside
+// the configuration file.
+type TracingConfig struct {
+	// ServiceName is the name of the Service of a Zipkin compatible
+	// trace collector. If not set, requests are not traced.
+	ServiceName      string `yaml:"service-name,omitempty"`
+	ServiceNamespace string `yaml:"service-namespace,omitempty"`
+	ServicePort      int    `yaml:"service-port,omitempty"`
+
+	// CollectorEndpoint is the API endpoint of the collector.
+	// If not set, defaults to /api/v2/spans.
+	CollectorEndpoint string `yaml:"collector-endpoint,omitempty"`
+
+	// Sampling is the percentage, from 0 to 100, of requests
+	// which are traced. HTTPProxy tracingPolicy overrides it.
+	Sampling float64 `yaml:"sampling,omitempty"`
+
+	// RequestHeadersForTags are the names of request headers
+	// whose values are added to each span as tags.
+	RequestHeadersForTags []string `yaml:"request-headers-for-tags,omitempty"`
+}
+
+// validate returns an error if the collector's Service is
+// incomplete or the sampling rate is not a percentage.
+func (c TracingConfig) validate() error {
+	if c.ServiceName == "" {
+		return nil
+	}
+	if c.ServiceNamespace == "" {
+		return errors.New("tracing: service-namespace must be set when service-name is set")
+	}
+	if c.ServicePort < 1 || c.ServicePort > 65535 {
+		return fmt.Errorf("tracing: service-port %d must be in the range 1-65535", c.ServicePort)
+	}
+	if c.Sampling < 0 || c.Sampling > 100 {
+		return fmt.Errorf("tracing: sampling %v must be in the range 0-100", c.Sampling)
+	}
+	return nil
+}
+
+// tracing returns the tracing settings of the Envoy
+// listeners, or nil if no trace collector is configured.
+func (ctx *serveContext) tracing() *contour.TracingConfig {
+	t := ctx.TracingConfig
+	if t.ServiceName == "" {
+		return nil
+	}
+	return &contour.TracingConfig{
+		Sampling:              t.Sampling,
+		RequestHeadersForTags: t.RequestHeadersForTags,
+	}
+}
+
+// bootstrapTracing returns the trace collector of the Envoy
+// bootstrap configuration, or nil if none is configured.
+func (ctx *serveContext) bootstrapTracing() *envoy.TracingConfig {
+	t := ctx.TracingConfig
+	if t.ServiceName == "" {
+		return nil
+	}
+	return &envoy.TracingConfig{
+		ServiceName:       t.ServiceName,
+		ServiceNamespace:  t.ServiceNamespace,
+		ServicePort:       t.ServicePort,
+		CollectorEndpoint: t.CollectorEndpoint,
+	}
+}
+
+// TimeoutConfig holds the timeouts of Envoy's listeners and
+// upstream connections inside the configuration file. A timeout
+// which is not set uses Contour's or Envoy's default.
+type TimeoutConfig struct {
+	// ConnectionIdleTimeout applies to HTTP connections,
+	// TCPIdleTimeout to TLS passthrough TCP proxy connections.
+	ConnectionIdleTimeout time.Duration `yaml:"connection-idle-timeout,omitempty"`
+	TCPIdleTimeout        time.Duration `yaml:"tcp-idle-timeout,omitempty"`
+	StreamIdleTimeout     time.Duration `yaml:"stream-idle-timeout,omitempty"`
+	RequestTimeout        time.Duration `yaml:"request-timeout,omitempty"`
+	DrainTimeout          time.Duration `yaml:"drain-timeout,omitempty"`
+
+	// ConnectTimeout applies to connections to upstream services,
+	// HTTPProxy services may override it with connectTimeout.
+	ConnectTimeout time.Duration `yaml:"connect-timeout,omitempty"`
+}
+
+// validate returns an error if any timeout is negative.
+func (c TimeoutConfig) validate() error {
+	for _, t := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"connection-idle-timeout", c.ConnectionIdleTimeout},
+		{"tcp-idle-timeout", c.TCPIdleTimeout},
+		{"stream-idle-timeout", c.StreamIdleTimeout},
+		{"request-timeout", c.RequestTimeout},
+		{"drain-timeout", c.DrainTimeout},
+		{"connect-timeout", c.ConnectTimeout},
+	} {
+		if t.value < 0 {
+			return fmt.Errorf("timeouts: %s %v must not be negative", t.name, t.value)
+		}
+	}
+	return nil
+}
+
+// HTTPFilterConfig holds the configurable HTTP filters of Envoy's
+// HTTP and HTTPS listeners inside the configuration file. If a
+// listener's filters are not set, it has the gzip and grpc-web
+// filters, an empty list removes them.
+type HTTPFilterConfig struct {
+	HTTP  []HTTPFilter `yaml:"http,omitempty"`
+	HTTPS []HTTPFilter `yaml:"https,omitempty"`
+}
+
+// HTTPFilter holds the configuration of an HTTP filter.
+type HTTPFilter struct {
+	// Name is one of health-check, cors, buffer, gzip, or grpc-web.
+	Name string `yaml:"name"`
+
+	// Path is the request path answered by the health-check filter.
+	Path string `yaml:"path,omitempty"`
+
+	// MaxRequestBytes is the size of the largest request
+	// body accepted by the buffer filter.
+	MaxRequestBytes uint32 `yaml:"max-request-bytes,omitempty"`
+}
+
+// validate returns an error if either listener's filters are invalid.
+func (c HTTPFilterConfig) validate() error {
+	if err := contour.ValidateHTTPFilters(httpFilters(c.HTTP)); err != nil {
+		return fmt.Errorf("httpfilters: http: %v", err)
+	}
+	if err := contour.ValidateHTTPFilters(httpFilters(c.HTTPS)); err != nil {
+		return fmt.Errorf("httpfilters: https: %v", err)
+	}
+	return nil
+}
+
+// httpFilters converts the configuration file's HTTP filters
+// to those of the Envoy listeners. If filters is nil, nil is
+// returned so the listener's default filters apply.
+func httpFilters(filters []HTTPFilter) []contour.HTTPFilter {
+	if filters == nil {
+		return nil
+	}
+	converted := make([]contour.HTTPFilter, 0, len(filters))
+	for _, f := range filters {
+		converted = append(converted, contour.HTTPFilter{
+			Name:            f.Name,
+			Path:            f.Path,
+			MaxRequestBytes: f.MaxRequestBytes,
+		})
+	}
+	return converted
 }
 
 // grpcOptions returns a slice of grpc.ServerOptions.
@@ -207,3 +706,22 @@ func (ctx *serveContext) ingressRouteRootNamespaces() []string {
 	}
 	return ns
 }
+
+// secretNamespaces returns the namespaces whose Secrets are watched,
+// the root namespaces and the namespace of the fallback certificate,
+// or nil if Secrets are watched in every namespace.
+func (ctx *serveContext) secretNamespaces() []string {
+	ns := ctx.ingressRouteRootNamespaces()
+	if ns == nil {
+		return nil
+	}
+	if fc := ctx.fallbackCertificate(); fc != nil {
+		for _, n := range ns {
+			if n == fc.Namespace {
+				return ns
+			}
+		}
+		ns = append(ns, fc.Namespace)
+	}
+	return ns
+}
@@ -19,6 +19,8 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/heptio/contour/internal/envoy"
 	"gopkg.in/yaml.v2"
 )
 
@@ -69,6 +71,55 @@ func TestServeContextIngressRouteRootNamespaces(t *testing.T) {
 	}
 }
 
+func TestServeContextSecretNamespaces(t *testing.T) {
+	tests := map[string]struct {
+		ctx  serveContext
+		want []string
+	}{
+		"all namespaces": {
+			ctx: serveContext{
+				TLSConfig: TLSConfig{
+					FallbackCertificate: FallbackCertificate{Name: "fallback", Namespace: "heptio-contour"},
+				},
+			},
+			want: nil,
+		},
+		"root namespaces": {
+			ctx: serveContext{
+				rootNamespaces: "prod1,prod2",
+			},
+			want: []string{"prod1", "prod2"},
+		},
+		"fallback certificate in root namespace": {
+			ctx: serveContext{
+				rootNamespaces: "prod1,prod2",
+				TLSConfig: TLSConfig{
+					FallbackCertificate: FallbackCertificate{Name: "fallback", Namespace: "prod2"},
+				},
+			},
+			want: []string{"prod1", "prod2"},
+		},
+		"fallback certificate outside root namespaces": {
+			ctx: serveContext{
+				rootNamespaces: "prod1,prod2",
+				TLSConfig: TLSConfig{
+					FallbackCertificate: FallbackCertificate{Name: "fallback", Namespace: "heptio-contour"},
+				},
+			},
+			want: []string{"prod1", "prod2", "heptio-contour"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.ctx.secretNamespaces()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
 func TestServeContextTLSParams(t *testing.T) {
 	tests := map[string]struct {
 		ctx         serveContext
@@ -144,6 +195,43 @@ tls:
 				return ctx
 			},
 		},
+		"tls cipher suites and curves": {
+			yamlIn: `
+tls:
+  maximum-protocol-version: 1.2
+  cipher-suites:
+  - "[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305]"
+  - ECDHE-RSA-AES128-GCM-SHA256
+  ecdh-curves:
+  - P-256
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.TLSConfig.MaximumProtocolVersion = "1.2"
+				ctx.TLSConfig.CipherSuites = []string{
+					"[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305]",
+					"ECDHE-RSA-AES128-GCM-SHA256",
+				}
+				ctx.TLSConfig.ECDHCurves = []string{"P-256"}
+				return ctx
+			},
+		},
+		"tls fallback certificate": {
+			yamlIn: `
+tls:
+  fallback-certificate:
+    name: fallback
+    namespace: heptio-contour
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.TLSConfig.FallbackCertificate = FallbackCertificate{
+					Name:      "fallback",
+					Namespace: "heptio-contour",
+				}
+				return ctx
+			},
+		},
 		"leader election namespace and configmap only": {
 			yamlIn: `
 leaderelection:
@@ -165,6 +253,7 @@ leaderelection:
   lease-duration: 600s
   renew-deadline: 500s
   retry-period: 60s
+  lock-type: leases
 `,
 			want: func() *serveContext {
 				ctx := newServeContext()
@@ -173,6 +262,155 @@ leaderelection:
 				ctx.LeaderElectionConfig.LeaseDuration = 600 * time.Second
 				ctx.LeaderElectionConfig.RenewDeadline = 500 * time.Second
 				ctx.LeaderElectionConfig.RetryPeriod = 60 * time.Second
+				ctx.LeaderElectionConfig.LockType = "leases"
+				return ctx
+			},
+		},
+		"rate limit service": {
+			yamlIn: `
+ratelimitservice:
+  cluster-name: ratelimit
+  domain: contour
+  fail-open: true
+  timeout: 100ms
+  service-name: ratelimit
+  service-namespace: projectcontour
+  service-port: 8081
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.RateLimitServiceConfig.ClusterName = "ratelimit"
+				ctx.RateLimitServiceConfig.Domain = "contour"
+				ctx.RateLimitServiceConfig.FailOpen = true
+				ctx.RateLimitServiceConfig.Timeout = 100 * time.Millisecond
+				ctx.RateLimitServiceConfig.ServiceName = "ratelimit"
+				ctx.RateLimitServiceConfig.ServiceNamespace = "projectcontour"
+				ctx.RateLimitServiceConfig.ServicePort = 8081
+				return ctx
+			},
+		},
+		"json access log": {
+			yamlIn: `
+accesslog:
+  format: json
+  json-fields:
+    "@timestamp": "%START_TIME%"
+    status: "%RESPONSE_CODE%"
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.AccessLogConfig.Format = "json"
+				ctx.AccessLogConfig.JSONFields = map[string]string{
+					"@timestamp": "%START_TIME%",
+					"status":     "%RESPONSE_CODE%",
+				}
+				return ctx
+			},
+		},
+		"grpc access log service": {
+			yamlIn: `
+accesslog:
+  grpc:
+    cluster-name: contour
+    log-name: ingress
+  receiver: log
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.AccessLogConfig.GRPC.ClusterName = "contour"
+				ctx.AccessLogConfig.GRPC.LogName = "ingress"
+				ctx.AccessLogConfig.Receiver = "log"
+				return ctx
+			},
+		},
+		"grpc access log service from a service": {
+			yamlIn: `
+accesslog:
+  grpc:
+    cluster-name: als
+    service-name: als-receiver
+    service-namespace: logging
+    service-port: 9001
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.AccessLogConfig.GRPC.ClusterName = "als"
+				ctx.AccessLogConfig.GRPC.ServiceName = "als-receiver"
+				ctx.AccessLogConfig.GRPC.ServiceNamespace = "logging"
+				ctx.AccessLogConfig.GRPC.ServicePort = 9001
+				return ctx
+			},
+		},
+		"tracing": {
+			yamlIn: `
+tracing:
+  service-name: jaeger-collector
+  service-namespace: tracing
+  sampling: 2.5
+  request-headers-for-tags:
+  - x-request-id
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.TracingConfig.ServiceName = "jaeger-collector"
+				ctx.TracingConfig.ServiceNamespace = "tracing"
+				ctx.TracingConfig.Sampling = 2.5
+				ctx.TracingConfig.RequestHeadersForTags = []string{"x-request-id"}
+				return ctx
+			},
+		},
+		"timeouts": {
+			yamlIn: `
+timeouts:
+  connection-idle-timeout: 1h
+  tcp-idle-timeout: 2h
+  stream-idle-timeout: 10m
+  request-timeout: 30s
+  drain-timeout: 1m
+  connect-timeout: 2s
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.TimeoutConfig.ConnectionIdleTimeout = time.Hour
+				ctx.TimeoutConfig.TCPIdleTimeout = 2 * time.Hour
+				ctx.TimeoutConfig.StreamIdleTimeout = 10 * time.Minute
+				ctx.TimeoutConfig.RequestTimeout = 30 * time.Second
+				ctx.TimeoutConfig.DrainTimeout = time.Minute
+				ctx.TimeoutConfig.ConnectTimeout = 2 * time.Second
+				return ctx
+			},
+		},
+		"http filters": {
+			yamlIn: `
+httpfilters:
+  http:
+  - name: health-check
+    path: /healthz
+  - name: gzip
+  https:
+  - name: buffer
+    max-request-bytes: 1048576
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.HTTPFilterConfig.HTTP = []HTTPFilter{
+					{Name: "health-check", Path: "/healthz"},
+					{Name: "gzip"},
+				}
+				ctx.HTTPFilterConfig.HTTPS = []HTTPFilter{
+					{Name: "buffer", MaxRequestBytes: 1048576},
+				}
+				return ctx
+			},
+		},
+		"no https filters": {
+			yamlIn: `
+httpfilters:
+  https: []
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.HTTPFilterConfig.HTTPS = []HTTPFilter{}
 				return ctx
 			},
 		},
@@ -191,6 +429,592 @@ leaderelection:
 	}
 }
 
+func TestAccessLogConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config AccessLogConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().AccessLogConfig,
+		},
+		"text": {
+			config: AccessLogConfig{
+				Format:     "text",
+				TextFormat: "%START_TIME% %RESPONSE_CODE%",
+			},
+		},
+		"text without format": {
+			config: AccessLogConfig{
+				Format: "text",
+			},
+			want: "accesslog: text-format must be set when format is text",
+		},
+		"text with unknown operator": {
+			config: AccessLogConfig{
+				Format:     "text",
+				TextFormat: "%STATUS%",
+			},
+			want: `accesslog: text-format: unknown command operator "%STATUS%"`,
+		},
+		"json": {
+			config: AccessLogConfig{
+				Format: "json",
+				JSONFields: map[string]string{
+					"method": "%REQ(:METHOD)%",
+				},
+			},
+		},
+		"json without fields": {
+			config: AccessLogConfig{
+				Format: "json",
+			},
+			want: "accesslog: json-fields must be set when format is json",
+		},
+		"json with missing argument": {
+			config: AccessLogConfig{
+				Format: "json",
+				JSONFields: map[string]string{
+					"method": "%REQ%",
+				},
+			},
+			want: `accesslog: json-fields: method: command operator "%REQ%" requires an argument`,
+		},
+		"unknown format": {
+			config: AccessLogConfig{
+				Format: "xml",
+			},
+			want: `accesslog: unknown format "xml", must be one of envoy, text, or json`,
+		},
+		"grpc service": {
+			config: AccessLogConfig{
+				Format: "envoy",
+				GRPC: AccessLogServiceConfig{
+					ClusterName: "als",
+					GRPCService: GRPCService{
+						ServiceName:      "als-receiver",
+						ServiceNamespace: "logging",
+						ServicePort:      9001,
+					},
+				},
+			},
+		},
+		"grpc service of the contour cluster": {
+			config: AccessLogConfig{
+				Format: "envoy",
+				GRPC: AccessLogServiceConfig{
+					ClusterName: "contour",
+					GRPCService: GRPCService{
+						ServiceName:      "als-receiver",
+						ServiceNamespace: "logging",
+						ServicePort:      9001,
+					},
+				},
+			},
+			want: `accesslog: grpc: cluster-name "contour" is already defined by contour bootstrap`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitServiceConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config RateLimitServiceConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().RateLimitServiceConfig,
+		},
+		"cluster only": {
+			config: RateLimitServiceConfig{
+				ClusterName: "ratelimit",
+			},
+		},
+		"service": {
+			config: RateLimitServiceConfig{
+				ClusterName: "ratelimit",
+				GRPCService: GRPCService{
+					ServiceName:      "ratelimit",
+					ServiceNamespace: "projectcontour",
+					ServicePort:      8081,
+				},
+			},
+		},
+		"service without cluster name": {
+			config: RateLimitServiceConfig{
+				GRPCService: GRPCService{
+					ServiceName:      "ratelimit",
+					ServiceNamespace: "projectcontour",
+					ServicePort:      8081,
+				},
+			},
+			want: "ratelimitservice: cluster-name must be set when service-name is set",
+		},
+		"service without namespace": {
+			config: RateLimitServiceConfig{
+				ClusterName: "ratelimit",
+				GRPCService: GRPCService{
+					ServiceName: "ratelimit",
+					ServicePort: 8081,
+				},
+			},
+			want: "ratelimitservice: service-namespace must be set when service-name is set",
+		},
+		"service without port": {
+			config: RateLimitServiceConfig{
+				ClusterName: "ratelimit",
+				GRPCService: GRPCService{
+					ServiceName:      "ratelimit",
+					ServiceNamespace: "projectcontour",
+				},
+			},
+			want: "ratelimitservice: service-port 0 must be in the range 1-65535",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServeContextBootstrapGRPCServices(t *testing.T) {
+	ctx := newServeContext()
+	ctx.RateLimitServiceConfig.ClusterName = "ratelimit"
+	ctx.AccessLogConfig.GRPC.ClusterName = "contour"
+	if got := ctx.bootstrapRateLimitService(); got != nil {
+		t.Fatalf("expected no bootstrap rate limit service, got %v", got)
+	}
+	if got := ctx.bootstrapAccessLogService(); got != nil {
+		t.Fatalf("expected no bootstrap access log service, got %v", got)
+	}
+
+	ctx.RateLimitServiceConfig.GRPCService = GRPCService{
+		ServiceName:      "ratelimit",
+		ServiceNamespace: "projectcontour",
+		ServicePort:      8081,
+	}
+	ctx.AccessLogConfig.GRPC = AccessLogServiceConfig{
+		ClusterName: "als",
+		GRPCService: GRPCService{
+			ServiceName:      "als-receiver",
+			ServiceNamespace: "logging",
+			ServicePort:      9001,
+		},
+	}
+	want := &envoy.GRPCServiceConfig{
+		ClusterName:      "ratelimit",
+		ServiceName:      "ratelimit",
+		ServiceNamespace: "projectcontour",
+		ServicePort:      8081,
+	}
+	if diff := cmp.Diff(want, ctx.bootstrapRateLimitService()); diff != "" {
+		t.Fatal(diff)
+	}
+	want = &envoy.GRPCServiceConfig{
+		ClusterName:      "als",
+		ServiceName:      "als-receiver",
+		ServiceNamespace: "logging",
+		ServicePort:      9001,
+	}
+	if diff := cmp.Diff(want, ctx.bootstrapAccessLogService()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestServeContextValidateBootstrapServices(t *testing.T) {
+	rls := RateLimitServiceConfig{
+		ClusterName: "grpc",
+		GRPCService: GRPCService{
+			ServiceName:      "ratelimit",
+			ServiceNamespace: "projectcontour",
+			ServicePort:      8081,
+		},
+	}
+	als := AccessLogServiceConfig{
+		ClusterName: "grpc",
+		GRPCService: GRPCService{
+			ServiceName:      "als-receiver",
+			ServiceNamespace: "logging",
+			ServicePort:      9001,
+		},
+	}
+	tests := map[string]struct {
+		rls  RateLimitServiceConfig
+		als  AccessLogServiceConfig
+		want string
+	}{
+		"default": {},
+		"rate limit service only": {
+			rls: rls,
+		},
+		"access log service only": {
+			als: als,
+		},
+		"different cluster names": {
+			rls: rls,
+			als: AccessLogServiceConfig{
+				ClusterName: "als",
+				GRPCService: als.GRPCService,
+			},
+		},
+		"same cluster name without access log service": {
+			rls: rls,
+			als: AccessLogServiceConfig{
+				ClusterName: "grpc",
+			},
+		},
+		"same cluster name": {
+			rls:  rls,
+			als:  als,
+			want: `ratelimitservice and accesslog: grpc: cluster-name "grpc" must be different`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newServeContext()
+			ctx.RateLimitServiceConfig = tc.rls
+			ctx.AccessLogConfig.GRPC = tc.als
+			var got string
+			if err := ctx.validateBootstrapServices(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServeContextAccessLogService(t *testing.T) {
+	tests := map[string]struct {
+		config AccessLogServiceConfig
+		want   *contour.AccessLogServiceConfig
+	}{
+		"not configured": {
+			config: AccessLogServiceConfig{},
+			want:   nil,
+		},
+		"default log name": {
+			config: AccessLogServiceConfig{
+				ClusterName: "contour",
+			},
+			want: &contour.AccessLogServiceConfig{
+				ClusterName: "contour",
+				LogName:     "contour",
+			},
+		},
+		"log name": {
+			config: AccessLogServiceConfig{
+				ClusterName: "als",
+				LogName:     "ingress",
+			},
+			want: &contour.AccessLogServiceConfig{
+				ClusterName: "als",
+				LogName:     "ingress",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newServeContext()
+			ctx.AccessLogConfig.GRPC = tc.config
+			if diff := cmp.Diff(tc.want, ctx.accessLogService()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestTracingConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config TracingConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().TracingConfig,
+		},
+		"collector service": {
+			config: TracingConfig{
+				ServiceName:      "jaeger-collector",
+				ServiceNamespace: "tracing",
+				ServicePort:      9411,
+				Sampling:         100,
+			},
+		},
+		"collector service without namespace": {
+			config: TracingConfig{
+				ServiceName: "jaeger-collector",
+				ServicePort: 9411,
+			},
+			want: "tracing: service-namespace must be set when service-name is set",
+		},
+		"collector service port out of range": {
+			config: TracingConfig{
+				ServiceName:      "jaeger-collector",
+				ServiceNamespace: "tracing",
+				ServicePort:      70000,
+			},
+			want: "tracing: service-port 70000 must be in the range 1-65535",
+		},
+		"sampling out of range": {
+			config: TracingConfig{
+				ServiceName:      "jaeger-collector",
+				ServiceNamespace: "tracing",
+				ServicePort:      9411,
+				Sampling:         150,
+			},
+			want: "tracing: sampling 150 must be in the range 0-100",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config TLSConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().TLSConfig,
+		},
+		"all set": {
+			config: TLSConfig{
+				MinimumProtocolVersion: "1.2",
+				MaximumProtocolVersion: "1.3",
+				CipherSuites:           []string{"[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305]"},
+				ECDHCurves:             []string{"X25519", "P-384"},
+			},
+		},
+		"invalid maximum protocol version": {
+			config: TLSConfig{
+				MaximumProtocolVersion: "1.4",
+			},
+			want: `tls: maximum-protocol-version: invalid TLS protocol version "1.4"`,
+		},
+		"maximum less than minimum protocol version": {
+			config: TLSConfig{
+				MinimumProtocolVersion: "1.3",
+				MaximumProtocolVersion: "1.2",
+			},
+			want: `tls: maximum-protocol-version "1.2" is less than minimum-protocol-version`,
+		},
+		"unknown cipher suite": {
+			config: TLSConfig{
+				CipherSuites: []string{"[ECDHE-ECDSA-AES128-GCM-SHA256|RC4-SHA]"},
+			},
+			want: `tls: cipher-suites: unknown cipher suite "RC4-SHA"`,
+		},
+		"unknown curve": {
+			config: TLSConfig{
+				ECDHCurves: []string{"P-224"},
+			},
+			want: `tls: ecdh-curves: unknown ECDH curve "P-224"`,
+		},
+		"fallback certificate without namespace": {
+			config: TLSConfig{
+				FallbackCertificate: FallbackCertificate{Name: "fallback"},
+			},
+			want: "tls: fallback-certificate: name and namespace must both be set",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeoutConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config TimeoutConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().TimeoutConfig,
+		},
+		"all set": {
+			config: TimeoutConfig{
+				ConnectionIdleTimeout: time.Hour,
+				TCPIdleTimeout:        time.Hour,
+				StreamIdleTimeout:     time.Minute,
+				RequestTimeout:        time.Minute,
+				DrainTimeout:          time.Second,
+				ConnectTimeout:        time.Second,
+			},
+		},
+		"negative connect timeout": {
+			config: TimeoutConfig{
+				ConnectTimeout: -time.Second,
+			},
+			want: "timeouts: connect-timeout -1s must not be negative",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLeaderElectionConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config LeaderElectionConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().LeaderElectionConfig,
+		},
+		"leases": {
+			config: LeaderElectionConfig{
+				LockType: "leases",
+			},
+		},
+		"configmapsleases": {
+			config: LeaderElectionConfig{
+				LockType: "configmapsleases",
+			},
+		},
+		"unknown lock type": {
+			config: LeaderElectionConfig{
+				LockType: "endpoints",
+			},
+			want: `leaderelection: lock-type "endpoints" must be one of "configmaps", "leases", or "configmapsleases"`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHTTPFilterConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config HTTPFilterConfig
+		want   string
+	}{
+		"default": {
+			config: newServeContext().HTTPFilterConfig,
+		},
+		"valid": {
+			config: HTTPFilterConfig{
+				HTTP:  []HTTPFilter{{Name: "cors"}},
+				HTTPS: []HTTPFilter{},
+			},
+		},
+		"unknown http filter": {
+			config: HTTPFilterConfig{
+				HTTP: []HTTPFilter{{Name: "lua"}},
+			},
+			want: `httpfilters: http: unknown filter "lua", must be one of health-check, cors, buffer, gzip, grpc-web`,
+		},
+		"https health check without path": {
+			config: HTTPFilterConfig{
+				HTTPS: []HTTPFilter{{Name: "health-check"}},
+			},
+			want: "httpfilters: https: health-check: path must begin with /",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := tc.config.validate(); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHTTPFilters(t *testing.T) {
+	if got := httpFilters(nil); got != nil {
+		t.Fatalf("expected nil filters, got %v", got)
+	}
+	if got := httpFilters([]HTTPFilter{}); got == nil || len(got) != 0 {
+		t.Fatalf("expected empty filters, got %v", got)
+	}
+	got := httpFilters([]HTTPFilter{{Name: "buffer", MaxRequestBytes: 1024}})
+	want := []contour.HTTPFilter{{Name: "buffer", MaxRequestBytes: 1024}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestServeContextTracing(t *testing.T) {
+	ctx := newServeContext()
+	if got := ctx.tracing(); got != nil {
+		t.Fatalf("expected no tracing, got %v", got)
+	}
+	if got := ctx.bootstrapTracing(); got != nil {
+		t.Fatalf("expected no bootstrap tracing, got %v", got)
+	}
+
+	ctx.TracingConfig.ServiceName = "jaeger-collector"
+	ctx.TracingConfig.ServiceNamespace = "tracing"
+	ctx.TracingConfig.RequestHeadersForTags = []string{"x-request-id"}
+	want := &contour.TracingConfig{
+		Sampling:              100,
+		RequestHeadersForTags: []string{"x-request-id"},
+	}
+	if diff := cmp.Diff(want, ctx.tracing()); diff != "" {
+		t.Fatal(diff)
+	}
+	wantBootstrap := &envoy.TracingConfig{
+		ServiceName:      "jaeger-collector",
+		ServiceNamespace: "tracing",
+		ServicePort:      9411,
+	}
+	if diff := cmp.Diff(wantBootstrap, ctx.bootstrapTracing()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
 func checkErr(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
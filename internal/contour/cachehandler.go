@@ -28,6 +28,7 @@ import (
 // CacheHandler manages the state of xDS caches.
 type CacheHandler struct {
 	ListenerVisitorConfig
+	ClusterVisitorConfig
 	ListenerCache
 	RouteCache
 	ClusterCache
@@ -42,12 +43,14 @@ func (ch *CacheHandler) OnChange(dag *dag.DAG) {
 	timer := prometheus.NewTimer(ch.CacheHandlerOnUpdateSummary)
 	defer timer.ObserveDuration()
 
+	// record the rebuild before updating the caches, which
+	// notify the xDS streams that measure the time since.
+	ch.SetDAGLastRebuilt(time.Now())
+
 	ch.updateSecrets(dag)
 	ch.updateListeners(dag)
 	ch.updateRoutes(dag)
 	ch.updateClusters(dag)
-
-	ch.SetDAGLastRebuilt(time.Now())
 }
 
 func (ch *CacheHandler) updateSecrets(root dag.Visitable) {
@@ -66,6 +69,6 @@ func (ch *CacheHandler) updateRoutes(root dag.Visitable) {
 }
 
 func (ch *CacheHandler) updateClusters(root dag.Visitable) {
-	clusters := visitClusters(root)
+	clusters := visitClusters(root, &ch.ClusterVisitorConfig)
 	ch.ClusterCache.Update(clusters)
 }
@@ -16,12 +16,14 @@ package contour
 import (
 	"sort"
 	"sync"
+	"time"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/golang/protobuf/proto"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/envoy"
+	"github.com/heptio/contour/internal/protobuf"
 )
 
 // ClusterCache manages the contents of the gRPC CDS cache.
@@ -80,14 +82,25 @@ func (c clusterByName) Less(i, j int) bool {
 
 func (*ClusterCache) TypeURL() string { return cache.ClusterType }
 
+// ClusterVisitorConfig holds configuration parameters for visitClusters.
+type ClusterVisitorConfig struct {
+	// ConnectTimeout is the timeout for new connections to the
+	// upstream hosts of clusters which do not set their own.
+	// If not set, the envoy package default of 250ms is used.
+	ConnectTimeout time.Duration
+}
+
 type clusterVisitor struct {
+	*ClusterVisitorConfig
+
 	clusters map[string]*envoy_api_v2.Cluster
 }
 
 // visitCluster produces a map of *envoy_api_v2.Clusters.
-func visitClusters(root dag.Vertex) map[string]*envoy_api_v2.Cluster {
+func visitClusters(root dag.Vertex, cvc *ClusterVisitorConfig) map[string]*envoy_api_v2.Cluster {
 	cv := clusterVisitor{
-		clusters: make(map[string]*envoy_api_v2.Cluster),
+		ClusterVisitorConfig: cvc,
+		clusters:             make(map[string]*envoy_api_v2.Cluster),
 	}
 	cv.visit(root)
 	return cv.clusters
@@ -98,6 +111,9 @@ func (v *clusterVisitor) visit(vertex dag.Vertex) {
 		name := envoy.Clustername(cluster)
 		if _, ok := v.clusters[name]; !ok {
 			c := envoy.Cluster(cluster)
+			if cluster.ConnectTimeout == 0 && v.ConnectTimeout > 0 {
+				c.ConnectTimeout = protobuf.Duration(v.ConnectTimeout)
+			}
 			v.clusters[c.Name] = c
 		}
 	}
@@ -184,6 +184,7 @@ func TestClusterCacheQuery(t *testing.T) {
 
 func TestClusterVisit(t *testing.T) {
 	tests := map[string]struct {
+		ClusterVisitorConfig
 		objs []interface{}
 		want map[string]*v2.Cluster
 	}{
@@ -304,6 +305,154 @@ func TestClusterVisit(t *testing.T) {
 				},
 			),
 		},
+		"httpproxy service connect timeout": {
+			ClusterVisitorConfig: ClusterVisitorConfig{
+				ConnectTimeout: time.Second,
+			},
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "kuard",
+								Port: 80,
+							}},
+						}, {
+							Condition: &projcontour.Condition{
+								Prefix: "/slow",
+							},
+							Services: []projcontour.Service{{
+								Name:           "kuard",
+								Port:           80,
+								ConnectTimeout: "2s",
+							}},
+						}},
+					},
+				},
+				service("default", "kuard",
+					v1.ServicePort{
+						Protocol: "TCP",
+						Name:     "http",
+						Port:     80,
+					},
+				),
+			},
+			want: clustermap(
+				&v2.Cluster{
+					Name:                 "default/kuard/80/da39a3ee5e",
+					AltStatName:          "default_kuard_80",
+					ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_EDS),
+					EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+						EdsConfig:   envoy.ConfigSource("contour"),
+						ServiceName: "default/kuard/http",
+					},
+					ConnectTimeout: protobuf.Duration(time.Second),
+					LbPolicy:       v2.Cluster_ROUND_ROBIN,
+					CommonLbConfig: envoy.ClusterCommonLBConfig(),
+				},
+				&v2.Cluster{
+					Name:                 "default/kuard/80/aca0096f62",
+					AltStatName:          "default_kuard_80",
+					ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_EDS),
+					EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+						EdsConfig:   envoy.ConfigSource("contour"),
+						ServiceName: "default/kuard/http",
+					},
+					ConnectTimeout: protobuf.Duration(2 * time.Second),
+					LbPolicy:       v2.Cluster_ROUND_ROBIN,
+					CommonLbConfig: envoy.ClusterCommonLBConfig(),
+				},
+			),
+		},
+		"httpproxy authorization service": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "secret",
+							},
+							Authorization: &projcontour.AuthorizationServer{
+								Name: "auth",
+								Port: 9000,
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "kuard",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata("certificate", "key"),
+				},
+				service("default", "kuard",
+					v1.ServicePort{
+						Protocol: "TCP",
+						Name:     "http",
+						Port:     80,
+					},
+				),
+				serviceWithAnnotations(
+					"default",
+					"auth",
+					map[string]string{
+						"contour.heptio.com/upstream-protocol.h2c": "9000",
+					},
+					v1.ServicePort{
+						Protocol: "TCP",
+						Name:     "grpc",
+						Port:     9000,
+					},
+				),
+			},
+			want: clustermap(
+				&v2.Cluster{
+					Name:                 "default/auth/9000/da39a3ee5e",
+					AltStatName:          "default_auth_9000",
+					ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_EDS),
+					EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+						EdsConfig:   envoy.ConfigSource("contour"),
+						ServiceName: "default/auth/grpc",
+					},
+					ConnectTimeout:       protobuf.Duration(250 * time.Millisecond),
+					LbPolicy:             v2.Cluster_ROUND_ROBIN,
+					Http2ProtocolOptions: &envoy_api_v2_core.Http2ProtocolOptions{},
+					CommonLbConfig:       envoy.ClusterCommonLBConfig(),
+				},
+				&v2.Cluster{
+					Name:                 "default/kuard/80/da39a3ee5e",
+					AltStatName:          "default_kuard_80",
+					ClusterDiscoveryType: envoy.ClusterDiscoveryType(v2.Cluster_EDS),
+					EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+						EdsConfig:   envoy.ConfigSource("contour"),
+						ServiceName: "default/kuard/http",
+					},
+					ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+					LbPolicy:       v2.Cluster_ROUND_ROBIN,
+					CommonLbConfig: envoy.ClusterCommonLBConfig(),
+				},
+			),
+		},
 		"long namespace and service name": {
 			objs: []interface{}{
 				&v1beta1.Ingress{
@@ -854,7 +1003,7 @@ func TestClusterVisit(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			root := buildDAG(tc.objs...)
-			got := visitClusters(root)
+			got := visitClusters(root, &tc.ClusterVisitorConfig)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
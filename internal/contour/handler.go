@@ -42,6 +42,19 @@ type EventHandler struct {
 
 	CRDStatus *k8s.CRDStatus
 
+	// IsLeader is closed when this EventHandler becomes the leader.
+	// Until then, or if IsLeader is nil, the status of objects is
+	// not written back to the API server.
+	IsLeader chan struct{}
+
+	// Synced is closed once the informers feeding this EventHandler
+	// have synced. The DAG is then rebuilt and Ready is closed.
+	Synced <-chan struct{}
+
+	// Ready, if not nil, is closed once a DAG built after Synced
+	// was closed has been sent to the CacheHandler.
+	Ready chan struct{}
+
 	*metrics.Metrics
 
 	logrus.FieldLogger
@@ -51,6 +64,10 @@ type EventHandler struct {
 	// last holds the last time CacheHandler.OnUpdate was called.
 	last time.Time
 
+	// recheck holds the time at which the validity of a
+	// certificate in the last DAG built changes.
+	recheck time.Time
+
 	// Sequence is a channel that receives a incrementing sequence number
 	// for each update processed. The updates may be processed immediately, or
 	// delayed by a holdoff timer. In each case a non blocking send to Sequence
@@ -111,6 +128,26 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 		pending <-chan time.Time
 	)
 
+	// isLeader is set to nil once leadership has been acquired
+	// so the select below does not fire again.
+	isLeader := e.IsLeader
+
+	// synced is set to nil once the informers have synced.
+	synced := e.Synced
+
+	var (
+		// scheduled is the time the recheck timer was set for.
+		scheduled time.Time
+
+		// recheck fires when the validity of a certificate
+		// changes, so the status of the objects which use
+		// it can be written afresh.
+		recheck *time.Timer
+
+		// rechecked is a reference to the recheck timer's channel.
+		rechecked <-chan time.Time
+	)
+
 	inc := func() { outstanding++ }
 	reset := func() (v int) {
 		v, outstanding = outstanding, 0
@@ -146,14 +183,65 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 		pending = timer.C
 	}
 
+	// drain processes the events delivered to the EventHandler until
+	// none has arrived for HoldoffDelay, or HoldoffMaxDelay has passed.
+	// The informers report they have synced once their own caches are
+	// full, which may be before they have delivered every event to us.
+	drain := func() {
+		idle := time.NewTimer(e.HoldoffDelay)
+		defer idle.Stop()
+		deadline := time.NewTimer(e.HoldoffMaxDelay)
+		defer deadline.Stop()
+		for {
+			select {
+			case op := <-e.update:
+				if e.onUpdate(op) {
+					inc()
+				}
+				if !idle.Stop() {
+					<-idle.C
+				}
+				idle.Reset(e.HoldoffDelay)
+			case <-idle.C:
+				return
+			case <-deadline.C:
+				return
+			case <-stop:
+				return
+			}
+		}
+	}
+
 	for {
-		// In the main loop one of four things can happen.
-		// 1. We're waiting for an event on op, stop, or pending, noting that
-		//    C may be nil if there are no pending events.
+		if !e.recheck.Equal(scheduled) {
+			// the last DAG built changed the time at which
+			// the validity of a certificate changes.
+			scheduled = e.recheck
+			if recheck != nil {
+				recheck.Stop()
+			}
+			recheck, rechecked = nil, nil
+			if !scheduled.IsZero() {
+				recheck = time.NewTimer(time.Until(scheduled))
+				rechecked = recheck.C
+			}
+		}
+
+		// In the main loop one of seven things can happen.
+		// 1. We're waiting for an event on op, stop, pending, isLeader,
+		//    synced, or rechecked, noting that C may be nil if there
+		//    are no pending events.
 		// 2. We're processing an event.
 		// 3. The holdoff timer from a previous event has fired and we're
 		//    building a new DAG and sending to the CacheHandler.
-		// 4. We're stopping.
+		// 4. We've been elected leader and are rebuilding the DAG so
+		//    the status of each object can be written.
+		// 5. The informers have synced and we're building the first
+		//    complete DAG, after which we're ready.
+		// 6. A certificate has expired, or become valid, and we're
+		//    rebuilding the DAG so the status of each object which
+		//    uses it is written.
+		// 7. We're stopping.
 		//
 		// Only one of these things can happen at a time.
 		select {
@@ -169,6 +257,30 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 			e.WithField("last_update", time.Since(e.last)).WithField("outstanding", reset()).Info("performing delayed update")
 			e.updateDAG()
 			e.incSequence()
+		case <-isLeader:
+			// we have just been elected leader, rebuild the dag
+			// so the status of every object is written afresh.
+			isLeader = nil
+			e.WithField("outstanding", reset()).Info("elected leader, performing update")
+			e.updateDAG()
+			e.incSequence()
+		case <-synced:
+			// the informers have synced, process the events they
+			// have yet to deliver then rebuild the dag so the
+			// CacheHandler holds every object.
+			synced = nil
+			drain()
+			e.WithField("outstanding", reset()).Info("informers synced, performing update")
+			e.updateDAG()
+			e.incSequence()
+			if e.Ready != nil {
+				close(e.Ready)
+			}
+		case <-rechecked:
+			scheduled, recheck, rechecked = time.Time{}, nil, nil
+			e.WithField("outstanding", reset()).Info("certificate validity changed, performing update")
+			e.updateDAG()
+			e.incSequence()
 		case <-stop:
 			// shutdown
 			return nil
@@ -213,16 +325,35 @@ func (e *EventHandler) incSequence() {
 
 // updateDAG builds a new DAG and sends it to the CacheHandler
 // the updates the status on objects and updates the metrics.
+// The status of objects is only updated by the leader.
 func (e *EventHandler) updateDAG() {
 	dag := e.Builder.Build()
 	e.CacheHandler.OnChange(dag)
 	statuses := dag.Statuses()
-	e.setStatus(statuses)
+	if e.isLeader() {
+		e.setStatus(statuses)
+	} else {
+		e.Debug("not the leader, skipping status update")
+	}
 
-	metrics := calculateIngressRouteMetric(statuses)
-	e.Metrics.SetIngressRouteMetric(metrics)
+	ir, proxy := calculateRouteMetric(statuses)
+	e.Metrics.SetIngressRouteMetric(ir)
+	e.Metrics.SetHTTPProxyMetric(proxy)
+	e.Metrics.SetObjectStatusMetric(calculateObjectStatusMetric(statuses))
 
 	e.last = time.Now()
+	e.recheck = dag.Recheck()
+}
+
+// isLeader returns true if this EventHandler has been elected leader.
+func (e *EventHandler) isLeader() bool {
+	select {
+	case <-e.IsLeader:
+		return true
+	default:
+		// IsLeader is open, or nil.
+		return false
+	}
 }
 
 // setStatus updates the status of objects.
@@ -249,6 +380,16 @@ func (e *EventHandler) setStatus(statuses map[dag.Meta]dag.Status) {
 					WithField("namespace", obj.Namespace).
 					Error("failed to set status")
 			}
+		case *ingressroutev1.TLSCertificateDelegation, *projcontour.TLSCertificateDelegation:
+			err := e.CRDStatus.SetStatus(st.Status, st.Description, obj)
+			if err != nil {
+				e.WithError(err).
+					WithField("status", st.Status).
+					WithField("desc", st.Description).
+					WithField("name", obj.GetObjectMeta().GetName()).
+					WithField("namespace", obj.GetObjectMeta().GetNamespace()).
+					Error("failed to set status")
+			}
 		default:
 			e.WithField("namespace", obj.GetObjectMeta().GetNamespace()).
 				WithField("name", obj.GetObjectMeta().GetName()).
@@ -0,0 +1,338 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/apis/generated/clientset/versioned/fake"
+	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
+	"github.com/heptio/contour/internal/certgen"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/k8s"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventHandlerSetStatusOnlyWhenLeader(t *testing.T) {
+	closed := make(chan struct{})
+	close(closed)
+
+	tests := map[string]struct {
+		isLeader chan struct{}
+		want     []string
+	}{
+		"nil leader channel": {
+			isLeader: nil,
+			want:     nil,
+		},
+		"not yet elected": {
+			isLeader: make(chan struct{}),
+			want:     nil,
+		},
+		"elected leader": {
+			isLeader: closed,
+			want:     []string{"patch"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			proxy := &projcontour.HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: projcontour.HTTPProxySpec{
+					VirtualHost: &projcontour.VirtualHost{
+						Fqdn: "example.com",
+					},
+				},
+			}
+			client := fake.NewSimpleClientset(proxy)
+			m := metrics.NewMetrics(prometheus.NewRegistry())
+			eh := &EventHandler{
+				CacheHandler: &CacheHandler{
+					Metrics: m,
+				},
+				CRDStatus: &k8s.CRDStatus{
+					Client: client,
+				},
+				IsLeader:    tc.isLeader,
+				Metrics:     m,
+				FieldLogger: testLogger(t),
+			}
+			eh.Builder.Source = dag.KubernetesCache{
+				FieldLogger: testLogger(t),
+			}
+			eh.Builder.Source.Insert(proxy)
+			eh.updateDAG()
+
+			var got []string
+			for _, action := range client.Actions() {
+				got = append(got, action.GetVerb())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestEventHandlerReadyOnceSynced(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+	proxy := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	synced := make(chan struct{})
+	ready := make(chan struct{})
+	eh := &EventHandler{
+		CacheHandler: &CacheHandler{
+			Metrics: m,
+		},
+		Synced:      synced,
+		Ready:       ready,
+		Metrics:     m,
+		FieldLogger: testLogger(t),
+	}
+	eh.Builder.Source = dag.KubernetesCache{
+		FieldLogger: testLogger(t),
+	}
+	eh.Builder.Source.Insert(service)
+	eh.Builder.Source.Insert(proxy)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go eh.Start()(stop)
+
+	select {
+	case <-ready:
+		t.Fatal("ready before informers synced")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(synced)
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+		t.Fatal("not ready after informers synced")
+	}
+
+	// the DAG is sent to the CacheHandler before ready is closed.
+	if got := eh.CacheHandler.ClusterCache.Contents(); len(got) == 0 {
+		t.Fatal("expected clusters once ready")
+	}
+}
+
+func TestEventHandlerReadyAfterPendingEvents(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+	proxy := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	synced := make(chan struct{})
+	ready := make(chan struct{})
+	eh := &EventHandler{
+		CacheHandler: &CacheHandler{
+			Metrics: m,
+		},
+		HoldoffDelay:    100 * time.Millisecond,
+		HoldoffMaxDelay: time.Second,
+		Synced:          synced,
+		Ready:           ready,
+		Metrics:         m,
+		FieldLogger:     testLogger(t),
+	}
+	eh.Builder.Source = dag.KubernetesCache{
+		FieldLogger: testLogger(t),
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	run := eh.Start()
+	go func() {
+		defer close(done)
+		run(stop)
+	}()
+
+	// the informers have synced but not yet
+	// delivered their events to the handler.
+	close(synced)
+	time.Sleep(10 * time.Millisecond)
+	eh.OnAdd(service)
+	eh.OnAdd(proxy)
+
+	select {
+	case <-ready:
+	case <-time.After(2 * time.Second):
+		t.Fatal("not ready after informers synced")
+	}
+
+	if got := eh.CacheHandler.ClusterCache.Contents(); len(got) == 0 {
+		t.Fatal("expected clusters once ready")
+	}
+}
+
+func TestEventHandlerRebuildsWhenCertificateExpires(t *testing.T) {
+	expiry := time.Now().Add(2 * time.Second).Truncate(time.Second)
+	cert, key, err := certgen.NewCA("example.com", expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey:       cert,
+			v1.TLSPrivateKeyKey: key,
+		},
+	}
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+	proxy := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: secret.Name,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	synced := make(chan struct{})
+	close(synced)
+	eh := &EventHandler{
+		CacheHandler: &CacheHandler{
+			Metrics: m,
+		},
+		Synced:      synced,
+		Sequence:    make(chan int, 1),
+		Metrics:     m,
+		FieldLogger: testLogger(t),
+	}
+	eh.Builder.Source = dag.KubernetesCache{
+		FieldLogger: testLogger(t),
+	}
+	eh.Builder.Source.Insert(secret)
+	eh.Builder.Source.Insert(service)
+	eh.Builder.Source.Insert(proxy)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	run := eh.Start()
+	go func() {
+		defer close(done)
+		run(stop)
+	}()
+
+	// the DAG is built once the informers have synced
+	// and again once the certificate has expired.
+	for _, want := range []int{1, 2} {
+		select {
+		case got := <-eh.Sequence:
+			if got != want {
+				t.Fatalf("expected sequence %d, got %d", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for sequence %d", want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"fmt"
+	"strings"
+
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/heptio/contour/internal/envoy"
+)
+
+// The names of the HTTP filters which may be configured on a listener.
+const (
+	HTTPFilterHealthCheck = "health-check"
+	HTTPFilterCORS        = "cors"
+	HTTPFilterBuffer      = "buffer"
+	HTTPFilterGzip        = "gzip"
+	HTTPFilterGRPCWeb     = "grpc-web"
+)
+
+// httpFilterOrder is the order of the configurable HTTP filters in a
+// listener's filter chain, whatever order they are configured in.
+// Health checks are answered, and CORS preflight requests handled,
+// before requests are buffered. The configurable filters precede the
+// authorization and rate limit filters and the router.
+var httpFilterOrder = []string{
+	HTTPFilterHealthCheck,
+	HTTPFilterCORS,
+	HTTPFilterBuffer,
+	HTTPFilterGzip,
+	HTTPFilterGRPCWeb,
+}
+
+// HTTPFilter configures an HTTP filter of a listener.
+type HTTPFilter struct {
+	// Name is the name of the filter, one of HTTPFilterHealthCheck,
+	// HTTPFilterCORS, HTTPFilterBuffer, HTTPFilterGzip, or HTTPFilterGRPCWeb.
+	Name string
+
+	// Path is the request path answered by the health-check filter.
+	Path string
+
+	// MaxRequestBytes is the size of the largest request
+	// body accepted by the buffer filter.
+	MaxRequestBytes uint32
+}
+
+// ValidateHTTPFilters returns an error if any of filters is unknown,
+// is configured more than once, or is missing its settings.
+func ValidateHTTPFilters(filters []HTTPFilter) error {
+	seen := make(map[string]bool)
+	for _, f := range filters {
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate filter %q", f.Name)
+		}
+		seen[f.Name] = true
+
+		switch f.Name {
+		case HTTPFilterHealthCheck:
+			if !strings.HasPrefix(f.Path, "/") {
+				return fmt.Errorf("%s: path must begin with /", f.Name)
+			}
+		case HTTPFilterBuffer:
+			if f.MaxRequestBytes == 0 {
+				return fmt.Errorf("%s: max request bytes must be greater than zero", f.Name)
+			}
+		case HTTPFilterCORS, HTTPFilterGzip, HTTPFilterGRPCWeb:
+		default:
+			return fmt.Errorf("unknown filter %q, must be one of %s", f.Name, strings.Join(httpFilterOrder, ", "))
+		}
+	}
+	return nil
+}
+
+// httpFilterChain returns the Envoy HTTP filters of filters, ordered
+// by httpFilterOrder. Unknown filters are ignored. If filters is nil,
+// nil is returned so the HTTP connection manager's default applies.
+func httpFilterChain(filters []HTTPFilter) []*http.HttpFilter {
+	if filters == nil {
+		return nil
+	}
+	chain := []*http.HttpFilter{}
+	for _, name := range httpFilterOrder {
+		for _, f := range filters {
+			if f.Name != name {
+				continue
+			}
+			switch f.Name {
+			case HTTPFilterHealthCheck:
+				chain = append(chain, envoy.HealthCheckFilter(f.Path))
+			case HTTPFilterCORS:
+				chain = append(chain, envoy.CORSFilter())
+			case HTTPFilterBuffer:
+				chain = append(chain, envoy.BufferFilter(f.MaxRequestBytes))
+			case HTTPFilterGzip:
+				chain = append(chain, envoy.GzipFilter())
+			case HTTPFilterGRPCWeb:
+				chain = append(chain, envoy.GRPCWebFilter())
+			}
+		}
+	}
+	return chain
+}
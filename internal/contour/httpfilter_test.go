@@ -0,0 +1,109 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/envoy"
+)
+
+func TestValidateHTTPFilters(t *testing.T) {
+	tests := map[string]struct {
+		filters []HTTPFilter
+		want    string
+	}{
+		"nil": {
+			filters: nil,
+		},
+		"all filters": {
+			filters: []HTTPFilter{
+				{Name: "gzip"},
+				{Name: "grpc-web"},
+				{Name: "cors"},
+				{Name: "health-check", Path: "/healthz"},
+				{Name: "buffer", MaxRequestBytes: 1024},
+			},
+		},
+		"unknown filter": {
+			filters: []HTTPFilter{{Name: "lua"}},
+			want:    `unknown filter "lua", must be one of health-check, cors, buffer, gzip, grpc-web`,
+		},
+		"duplicate filter": {
+			filters: []HTTPFilter{{Name: "gzip"}, {Name: "gzip"}},
+			want:    `duplicate filter "gzip"`,
+		},
+		"health check without path": {
+			filters: []HTTPFilter{{Name: "health-check"}},
+			want:    "health-check: path must begin with /",
+		},
+		"buffer without size": {
+			filters: []HTTPFilter{{Name: "buffer"}},
+			want:    "buffer: max request bytes must be greater than zero",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := ValidateHTTPFilters(tc.filters); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHTTPFilterChain(t *testing.T) {
+	tests := map[string]struct {
+		filters []HTTPFilter
+		want    []*http.HttpFilter
+	}{
+		"nil": {
+			filters: nil,
+			want:    nil,
+		},
+		"empty": {
+			filters: []HTTPFilter{},
+			want:    []*http.HttpFilter{},
+		},
+		"ordered": {
+			filters: []HTTPFilter{
+				{Name: "gzip"},
+				{Name: "buffer", MaxRequestBytes: 1024},
+				{Name: "health-check", Path: "/healthz"},
+				{Name: "cors"},
+			},
+			want: []*http.HttpFilter{
+				envoy.HealthCheckFilter("/healthz"),
+				envoy.CORSFilter(),
+				envoy.BufferFilter(1024),
+				envoy.GzipFilter(),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := httpFilterChain(tc.filters)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -16,11 +16,14 @@ package contour
 import (
 	"sort"
 	"sync"
+	"time"
 
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/golang/protobuf/proto"
 	"github.com/heptio/contour/internal/dag"
@@ -30,6 +33,7 @@ import (
 const (
 	ENVOY_HTTP_LISTENER            = "ingress_http"
 	ENVOY_HTTPS_LISTENER           = "ingress_https"
+	ENVOY_FALLBACK_ROUTECONFIG     = "ingress_fallbackcert"
 	DEFAULT_HTTP_ACCESS_LOG        = "/dev/stdout"
 	DEFAULT_HTTP_LISTENER_ADDRESS  = "0.0.0.0"
 	DEFAULT_HTTP_LISTENER_PORT     = 8080
@@ -64,6 +68,32 @@ type ListenerVisitorConfig struct {
 	// If not set, defaults to DEFAULT_HTTPS_ACCESS_LOG.
 	HTTPSAccessLog string
 
+	// HTTPFilters are the configurable HTTP filters of the HTTP
+	// (non TLS) listener, see httpFilterOrder for their order.
+	// If nil, defaults to the gzip and gRPC-Web filters. If
+	// empty, the listener has no configurable filters.
+	HTTPFilters []HTTPFilter
+
+	// HTTPSFilters are the configurable HTTP filters of the
+	// HTTPS (TLS) listener, defaulting as HTTPFilters does.
+	HTTPSFilters []HTTPFilter
+
+	// AccessLogTextFormat is the format string of each entry
+	// in the HTTP and HTTPS access logs.
+	// If not set, Envoy's default format is used.
+	AccessLogTextFormat string
+
+	// AccessLogJSONFields, if set, writes each entry in the HTTP and
+	// HTTPS access logs as a JSON object. Each key is a field of the
+	// object and its value the format string of that field.
+	// AccessLogJSONFields takes precedence over AccessLogTextFormat.
+	AccessLogJSONFields map[string]string
+
+	// AccessLogService additionally sends the HTTP and HTTPS
+	// access logs to a gRPC Access Log Service.
+	// If not set, only file access logs are written.
+	AccessLogService *AccessLogServiceConfig
+
 	// UseProxyProto configures all listeners to expect a PROXY
 	// V1 or V2 preamble.
 	// If not set, defaults to false.
@@ -71,6 +101,95 @@ type ListenerVisitorConfig struct {
 
 	// MinimumProtocolVersion defines the min tls protocol version to be used
 	MinimumProtocolVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
+
+	// MaximumProtocolVersion defines the max tls protocol version to be used.
+	// If not set, TLS 1.3 is the maximum.
+	MaximumProtocolVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
+
+	// CipherSuites are the cipher suites negotiated for TLS 1.2 and
+	// earlier. If not set, dag.DefaultCipherSuites is used.
+	CipherSuites []string
+
+	// ECDHCurves are the ECDH curves offered.
+	// If not set, Envoy's default curves are used.
+	ECDHCurves []string
+
+	// RateLimitService configures the HTTP listeners to consult
+	// an external rate limit service.
+	// If not set, rate limiting is disabled.
+	RateLimitService *RateLimitServiceConfig
+
+	// Tracing configures the HTTP and HTTPS listeners to trace
+	// requests. The tracing provider itself is configured in
+	// Envoy's bootstrap configuration.
+	// If not set, requests are not traced.
+	Tracing *TracingConfig
+
+	// ConnectionIdleTimeout is the time after which an idle
+	// downstream HTTP connection is closed.
+	// If not set, the default of 60 seconds is used.
+	ConnectionIdleTimeout time.Duration
+
+	// TCPIdleTimeout is the time after which an idle TLS
+	// passthrough TCP proxy connection is closed.
+	// If not set, the default of 9001 seconds is used.
+	TCPIdleTimeout time.Duration
+
+	// StreamIdleTimeout is the time after which a request
+	// with no activity is reset.
+	// If not set, Envoy's default is used.
+	StreamIdleTimeout time.Duration
+
+	// RequestTimeout is the time allowed for Envoy to
+	// receive an entire request, including its headers.
+	// If not set, Envoy's default is used.
+	RequestTimeout time.Duration
+
+	// DrainTimeout is the time Envoy waits for HTTP/2 clients
+	// to stop sending requests on a draining connection.
+	// If not set, Envoy's default is used.
+	DrainTimeout time.Duration
+}
+
+// TracingConfig holds the tracing settings of the HTTP listeners.
+type TracingConfig struct {
+	// Sampling is the percentage, from 0 to 100, of
+	// requests which are traced.
+	Sampling float64
+
+	// RequestHeadersForTags are the names of request
+	// headers whose values are added to each span as tags.
+	RequestHeadersForTags []string
+}
+
+// RateLimitServiceConfig holds the configuration of the
+// external gRPC rate limit service.
+type RateLimitServiceConfig struct {
+	// ClusterName is the name of the Envoy cluster
+	// which serves the rate limit service.
+	ClusterName string
+
+	// Domain is the rate limit domain sent with each request.
+	Domain string
+
+	// FailOpen allows requests when the rate limit
+	// service cannot be reached or returns an error.
+	FailOpen bool
+
+	// Timeout is the time to wait for a response from the
+	// rate limit service. If not set, Envoy's default is used.
+	Timeout time.Duration
+}
+
+// AccessLogServiceConfig holds the configuration of
+// the gRPC Access Log Service.
+type AccessLogServiceConfig struct {
+	// ClusterName is the name of the Envoy cluster
+	// which serves the access log service.
+	ClusterName string
+
+	// LogName identifies the access log to the service.
+	LogName string
 }
 
 // httpAddress returns the port for the HTTP (non TLS)
@@ -127,6 +246,30 @@ func (lvc *ListenerVisitorConfig) httpsAccessLog() string {
 	return DEFAULT_HTTPS_ACCESS_LOG
 }
 
+// accessLogs returns the access log filters for an HTTP connection
+// manager, the file access log writing to path and, if configured,
+// the gRPC access log service.
+func (lvc *ListenerVisitorConfig) accessLogs(path string) []*accesslog.AccessLog {
+	logs := lvc.fileAccessLog(path)
+	if als := lvc.AccessLogService; als != nil && als.ClusterName != "" {
+		logs = append(logs, envoy.HTTPGRPCAccessLog(als.ClusterName, als.LogName)...)
+	}
+	return logs
+}
+
+// fileAccessLog returns the access log filter writing to path
+// in the configured format.
+func (lvc *ListenerVisitorConfig) fileAccessLog(path string) []*accesslog.AccessLog {
+	switch {
+	case len(lvc.AccessLogJSONFields) > 0:
+		return envoy.FileAccessLogJSON(path, lvc.AccessLogJSONFields)
+	case lvc.AccessLogTextFormat != "":
+		return envoy.FileAccessLogText(path, lvc.AccessLogTextFormat)
+	default:
+		return envoy.FileAccessLog(path)
+	}
+}
+
 // minProtocolVersion returns the requested minimum TLS protocol
 // version or envoy_api_v2_auth.TlsParameters_TLSv1_1 if not configured {
 func (lvc *ListenerVisitorConfig) minProtoVersion() envoy_api_v2_auth.TlsParameters_TlsProtocol {
@@ -136,6 +279,64 @@ func (lvc *ListenerVisitorConfig) minProtoVersion() envoy_api_v2_auth.TlsParamet
 	return envoy_api_v2_auth.TlsParameters_TLSv1_1
 }
 
+// tlsParameters returns the TLS parameters of the filter chain of vh,
+// those configured for the HTTPS listener tightened by those of vh.
+func (lvc *ListenerVisitorConfig) tlsParameters(vh *dag.SecureVirtualHost) *envoy_api_v2_auth.TlsParameters {
+	// choose the higher of the configured or requested minimum version,
+	// and the lower of the configured or requested maximum version.
+	min := lvc.minProtoVersion()
+	if vh.MinProtoVersion > min {
+		min = vh.MinProtoVersion
+	}
+	max := lvc.MaximumProtocolVersion
+	if vh.MaxProtoVersion != 0 && (max == 0 || vh.MaxProtoVersion < max) {
+		max = vh.MaxProtoVersion
+	}
+	if max != 0 && max < min {
+		// dag.tlsPolicy keeps an HTTPProxy's versions within the
+		// listener's, but the minimum version annotation of an Ingress
+		// or IngressRoute is not checked against the maximum. The
+		// requested minimum version takes precedence.
+		max = min
+	}
+
+	cipherSuites := lvc.CipherSuites
+	if len(vh.CipherSuites) > 0 {
+		cipherSuites = vh.CipherSuites
+	}
+	ecdhCurves := lvc.ECDHCurves
+	if len(vh.ECDHCurves) > 0 {
+		ecdhCurves = vh.ECDHCurves
+	}
+	return envoy.TLSParameters(min, max, cipherSuites, ecdhCurves)
+}
+
+// httpFilters returns the additional HTTP filters
+// configured for the HTTP and HTTPS listeners.
+func (lvc *ListenerVisitorConfig) httpFilters() []*http.HttpFilter {
+	var filters []*http.HttpFilter
+	if rls := lvc.RateLimitService; rls != nil && rls.ClusterName != "" {
+		filters = append(filters, envoy.RateLimitFilter(rls.ClusterName, rls.Domain, rls.Timeout, rls.FailOpen))
+	}
+	return filters
+}
+
+// httpConnectionManagerOptions returns the settings of an HTTP
+// connection manager with the supplied configurable HTTP filters.
+func (lvc *ListenerVisitorConfig) httpConnectionManagerOptions(filters []HTTPFilter) *envoy.HTTPConnectionManagerOptions {
+	opts := &envoy.HTTPConnectionManagerOptions{
+		Filters:           httpFilterChain(filters),
+		IdleTimeout:       lvc.ConnectionIdleTimeout,
+		StreamIdleTimeout: lvc.StreamIdleTimeout,
+		RequestTimeout:    lvc.RequestTimeout,
+		DrainTimeout:      lvc.DrainTimeout,
+	}
+	if t := lvc.Tracing; t != nil {
+		opts.Tracing = envoy.HTTPConnectionManagerTracing(t.Sampling, t.RequestHeadersForTags)
+	}
+	return opts
+}
+
 // ListenerCache manages the contents of the gRPC LDS cache.
 type ListenerCache struct {
 	mu           sync.Mutex
@@ -216,6 +417,10 @@ type listenerVisitor struct {
 
 	listeners map[string]*v2.Listener
 	http      bool // at least one dag.VirtualHost encountered
+
+	// fallback is the fallback certificate of the dag.SecureVirtualHosts
+	// which enable it, if any were encountered.
+	fallback *dag.Secret
 }
 
 func visitListeners(root dag.Vertex, lvc *ListenerVisitorConfig) map[string]*v2.Listener {
@@ -237,7 +442,7 @@ func visitListeners(root dag.Vertex, lvc *ListenerVisitorConfig) map[string]*v2.
 			ENVOY_HTTP_LISTENER,
 			lvc.httpAddress(), lvc.httpPort(),
 			proxyProtocol(lvc.UseProxyProto),
-			envoy.HTTPConnectionManager(ENVOY_HTTP_LISTENER, lvc.httpAccessLog()),
+			envoy.HTTPConnectionManagerWithOptions(ENVOY_HTTP_LISTENER, lvc.accessLogs(lvc.httpAccessLog()), lvc.httpConnectionManagerOptions(lvc.HTTPFilters), lvc.httpFilters()...),
 		)
 
 	}
@@ -255,6 +460,22 @@ func visitListeners(root dag.Vertex, lvc *ListenerVisitorConfig) map[string]*v2.
 				// on the first slice entry.
 				return lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains[i].FilterChainMatch.ServerNames[0] < lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains[j].FilterChainMatch.ServerNames[0]
 			})
+
+		// clients which do not send SNI match no server name, they
+		// are routed by their Host header to the vhosts which enable
+		// the fallback certificate.
+		if lv.fallback != nil {
+			lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains,
+				envoy.FilterChainTLSFallback(
+					lv.fallback,
+					envoy.Filters(
+						envoy.HTTPConnectionManagerWithOptions(ENVOY_FALLBACK_ROUTECONFIG, lvc.accessLogs(lvc.httpsAccessLog()), lvc.httpConnectionManagerOptions(lvc.HTTPSFilters), lvc.httpFilters()...),
+					),
+					envoy.TLSParameters(lvc.minProtoVersion(), lvc.MaximumProtocolVersion, lvc.CipherSuites, lvc.ECDHCurves),
+					"h2", "http/1.1",
+				),
+			)
+		}
 	}
 
 	return lv.listeners
@@ -274,13 +495,6 @@ func secureProxyProtocol(useProxy bool) []*envoy_api_v2_listener.ListenerFilter
 }
 
 func (v *listenerVisitor) visit(vertex dag.Vertex) {
-	max := func(a, b envoy_api_v2_auth.TlsParameters_TlsProtocol) envoy_api_v2_auth.TlsParameters_TlsProtocol {
-		if a > b {
-			return a
-		}
-		return b
-	}
-
 	switch vh := vertex.(type) {
 	case *dag.VirtualHost:
 		// we only create on http listener so record the fact
@@ -288,13 +502,26 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 		// the listener properly.
 		v.http = true
 	case *dag.SecureVirtualHost:
+		httpFilters := v.httpFilters()
+		if auth := vh.AuthorizationService; auth != nil {
+			// requests are authorized before they are rate limited.
+			httpFilters = append([]*http.HttpFilter{
+				envoy.ExtAuthzFilter(envoy.Clustername(auth), vh.AuthorizationFailOpen, vh.AuthorizationResponseTimeout),
+			}, httpFilters...)
+		}
+		opts := v.httpConnectionManagerOptions(v.HTTPSFilters)
+		if dv := vh.DownstreamValidation; dv != nil {
+			opts.ForwardClientCertificate = dv.ForwardClientCertificate
+		}
 		filters := envoy.Filters(
-			envoy.HTTPConnectionManager(ENVOY_HTTPS_LISTENER, v.httpsAccessLog()),
+			envoy.HTTPConnectionManagerWithOptions(secureRouteConfigName(vh), v.accessLogs(v.httpsAccessLog()), opts, httpFilters...),
 		)
 		alpnProtos := []string{"h2", "http/1.1"}
 		if vh.TCPProxy != nil {
+			// the gRPC access log service only receives HTTP
+			// access logs, TCP proxies log to file.
 			filters = envoy.Filters(
-				envoy.TCPProxy(ENVOY_HTTPS_LISTENER, vh.TCPProxy, v.httpsAccessLog()),
+				envoy.TCPProxy(ENVOY_HTTPS_LISTENER, vh.TCPProxy, v.fileAccessLog(v.httpsAccessLog()), v.TCPIdleTimeout),
 			)
 			alpnProtos = nil // do not offer ALPN
 		}
@@ -303,11 +530,16 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 			vh.VirtualHost.Name,
 			vh.Secret,
 			filters,
-			max(v.ListenerVisitorConfig.minProtoVersion(), vh.MinProtoVersion), // choose the higher of the configured or requested tls version
+			v.tlsParameters(vh),
+			vh.DownstreamValidation,
 			alpnProtos...,
 		)
 
 		v.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains, fc)
+
+		if vh.FallbackCertificate != nil {
+			v.fallback = vh.FallbackCertificate
+		}
 	default:
 		// recurse
 		vertex.Visit(v.visit)
@@ -17,42 +17,86 @@
 package contour
 
 import (
+	"reflect"
+
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
+	"github.com/heptio/contour/apis/generated/clientset/versioned/scheme"
+	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/metrics"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
-func calculateIngressRouteMetric(statuses map[dag.Meta]dag.Status) metrics.IngressRouteMetric {
-	metricTotal := make(map[metrics.Meta]int)
-	metricValid := make(map[metrics.Meta]int)
-	metricInvalid := make(map[metrics.Meta]int)
-	metricOrphaned := make(map[metrics.Meta]int)
-	metricRoots := make(map[metrics.Meta]int)
+// calculateRouteMetric returns the metrics for the IngressRoutes
+// and the HTTPProxies present in statuses.
+func calculateRouteMetric(statuses map[dag.Meta]dag.Status) (metrics.RouteMetric, metrics.RouteMetric) {
+	ir := newRouteMetric()
+	proxy := newRouteMetric()
 
 	for _, v := range statuses {
-		switch v.Status {
-		case dag.StatusValid:
-			metricValid[metrics.Meta{VHost: v.Vhost, Namespace: v.Object.GetObjectMeta().GetNamespace()}]++
-		case dag.StatusInvalid:
-			metricInvalid[metrics.Meta{VHost: v.Vhost, Namespace: v.Object.GetObjectMeta().GetNamespace()}]++
-		case dag.StatusOrphaned:
-			metricOrphaned[metrics.Meta{Namespace: v.Object.GetObjectMeta().GetNamespace()}]++
-		}
-
 		switch o := v.Object.(type) {
 		case *ingressroutev1.IngressRoute:
-			if o.Spec.VirtualHost != nil {
-				metricRoots[metrics.Meta{Namespace: v.Object.GetObjectMeta().GetNamespace()}]++
-			}
+			addRouteMetric(ir, v, o.Spec.VirtualHost != nil)
+		case *projcontour.HTTPProxy:
+			addRouteMetric(proxy, v, o.Spec.VirtualHost != nil)
 		}
-		metricTotal[metrics.Meta{Namespace: v.Object.GetObjectMeta().GetNamespace()}]++
 	}
 
-	return metrics.IngressRouteMetric{
-		Invalid:  metricInvalid,
-		Valid:    metricValid,
-		Orphaned: metricOrphaned,
-		Total:    metricTotal,
-		Root:     metricRoots,
+	return ir, proxy
+}
+
+func newRouteMetric() metrics.RouteMetric {
+	return metrics.RouteMetric{
+		Invalid:  make(map[metrics.Meta]int),
+		Valid:    make(map[metrics.Meta]int),
+		Orphaned: make(map[metrics.Meta]int),
+		Total:    make(map[metrics.Meta]int),
+		Root:     make(map[metrics.Meta]int),
+	}
+}
+
+// addRouteMetric counts the object described by v in m.
+func addRouteMetric(m metrics.RouteMetric, v dag.Status, root bool) {
+	namespace := v.Object.GetObjectMeta().GetNamespace()
+	switch v.Status {
+	case dag.StatusValid:
+		m.Valid[metrics.Meta{VHost: v.Vhost, Namespace: namespace}]++
+	case dag.StatusInvalid:
+		m.Invalid[metrics.Meta{VHost: v.Vhost, Namespace: namespace}]++
+	case dag.StatusOrphaned:
+		m.Orphaned[metrics.Meta{Namespace: namespace}]++
+	}
+	if root {
+		m.Root[metrics.Meta{Namespace: namespace}]++
+	}
+	m.Total[metrics.Meta{Namespace: namespace}]++
+}
+
+// calculateObjectStatusMetric returns the number of objects of each group
+// and kind, in each namespace, with each status.
+func calculateObjectStatusMetric(statuses map[dag.Meta]dag.Status) map[metrics.ObjectStatusMeta]int {
+	m := make(map[metrics.ObjectStatusMeta]int)
+	for _, v := range statuses {
+		group, kind := groupKind(v.Object)
+		m[metrics.ObjectStatusMeta{
+			Group:     group,
+			Kind:      kind,
+			Namespace: v.Object.GetObjectMeta().GetNamespace(),
+			Status:    v.Status,
+		}]++
+	}
+	return m
+}
+
+// groupKind returns the API group and kind of obj. Objects of a type
+// Contour's scheme does not know are counted by the name of their
+// type, without a group, so every kind of object with a status is
+// counted.
+func groupKind(obj dag.Object) (string, string) {
+	if o, ok := obj.(runtime.Object); ok {
+		if gvks, _, err := scheme.Scheme.ObjectKinds(o); err == nil {
+			return gvks[0].Group, gvks[0].Kind
+		}
 	}
+	return "", reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
 }
@@ -320,12 +320,12 @@ func TestIngressRouteMetrics(t *testing.T) {
 
 	tests := map[string]struct {
 		objs           []interface{}
-		want           metrics.IngressRouteMetric
+		want           metrics.RouteMetric
 		rootNamespaces []string
 	}{
 		"valid ingressroute": {
 			objs: []interface{}{ir1, s3},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{},
 				Valid: map[metrics.Meta]int{
 					{Namespace: "roots", VHost: "example.com"}: 1,
@@ -341,7 +341,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"invalid port in service": {
 			objs: []interface{}{ir2},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots", VHost: "example.com"}: 1,
 				},
@@ -357,7 +357,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"root ingressroute outside of roots namespace": {
 			objs: []interface{}{ir3},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "finance"}: 1,
 				},
@@ -374,7 +374,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"delegated route's match prefix does not match parent's prefix": {
 			objs: []interface{}{ir1, ir4, s3},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots"}: 1,
 				},
@@ -392,7 +392,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"root ingressroute does not specify FQDN": {
 			objs: []interface{}{ir13},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots"}: 1,
 				},
@@ -408,7 +408,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"self-edge produces a cycle": {
 			objs: []interface{}{ir6},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots", VHost: "example.com"}: 1,
 				},
@@ -424,7 +424,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"child delegates to parent, producing a cycle": {
 			objs: []interface{}{ir7, ir8},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots"}: 1,
 				},
@@ -442,7 +442,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"route has a list of services and also delegates": {
 			objs: []interface{}{ir9},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots", VHost: "example.com"}: 1,
 				},
@@ -458,7 +458,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"ingressroute is an orphaned route": {
 			objs: []interface{}{ir8},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{},
 				Valid:   map[metrics.Meta]int{},
 				Orphaned: map[metrics.Meta]int{
@@ -472,7 +472,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"ingressroute delegates to multiple ingressroutes, one is invalid": {
 			objs: []interface{}{ir10, ir11, ir12, s1, s2},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots"}: 1,
 				},
@@ -491,7 +491,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"invalid parent orphans children": {
 			objs: []interface{}{ir14, ir11},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots"}: 1,
 				},
@@ -509,7 +509,7 @@ func TestIngressRouteMetrics(t *testing.T) {
 		},
 		"multi-parent children is not orphaned when one of the parents is invalid": {
 			objs: []interface{}{ir14, ir11, ir10, s2},
-			want: metrics.IngressRouteMetric{
+			want: metrics.RouteMetric{
 				Invalid: map[metrics.Meta]int{
 					{Namespace: "roots"}: 1,
 				},
@@ -541,10 +541,150 @@ func TestIngressRouteMetrics(t *testing.T) {
 			}
 			dag := builder.Build()
 
-			got := calculateIngressRouteMetric(dag.Statuses())
+			got, _ := calculateRouteMetric(dag.Statuses())
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
 		})
 	}
 }
+
+func TestHTTPProxyMetrics(t *testing.T) {
+	// proxy1 is a valid root httpproxy
+	proxy1 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy2 is orphaned, no root includes it
+	proxy2 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "orphan",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// ir1 is a valid ingressroute
+	ir1 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "legacy",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.org",
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+				Services: []ingressroutev1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "home",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	// d1 and d2 are invalid, the secret they delegate does not exist.
+	d1 := &ingressroutev1.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "legacy-delegation",
+		},
+		Spec: ingressroutev1.TLSCertificateDelegationSpec{
+			Delegations: []ingressroutev1.CertificateDelegation{{
+				SecretName:       "missing",
+				TargetNamespaces: []string{"*"},
+			}},
+		},
+	}
+
+	d2 := &projcontour.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "delegation",
+		},
+		Spec: projcontour.TLSCertificateDelegationSpec{
+			Delegations: []projcontour.CertificateDelegation{{
+				SecretName:       "missing",
+				TargetNamespaces: []string{"*"},
+			}},
+		},
+	}
+
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: testLogger(t),
+		},
+	}
+	for _, o := range []interface{}{proxy1, proxy2, ir1, s1, d1, d2} {
+		builder.Source.Insert(o)
+	}
+	statuses := builder.Build().Statuses()
+
+	_, got := calculateRouteMetric(statuses)
+	want := metrics.RouteMetric{
+		Invalid: map[metrics.Meta]int{},
+		Valid: map[metrics.Meta]int{
+			{Namespace: "roots", VHost: "example.com"}: 1,
+		},
+		Orphaned: map[metrics.Meta]int{
+			{Namespace: "roots"}: 1,
+		},
+		Root: map[metrics.Meta]int{
+			{Namespace: "roots"}: 1,
+		},
+		Total: map[metrics.Meta]int{
+			{Namespace: "roots"}: 2,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+
+	gotStatus := calculateObjectStatusMetric(statuses)
+	wantStatus := map[metrics.ObjectStatusMeta]int{
+		{Group: "projectcontour.io", Kind: "HTTPProxy", Namespace: "roots", Status: "valid"}:                   1,
+		{Group: "projectcontour.io", Kind: "HTTPProxy", Namespace: "roots", Status: "orphaned"}:                1,
+		{Group: "contour.heptio.com", Kind: "IngressRoute", Namespace: "roots", Status: "valid"}:               1,
+		{Group: "projectcontour.io", Kind: "TLSCertificateDelegation", Namespace: "roots", Status: "invalid"}:  1,
+		{Group: "contour.heptio.com", Kind: "TLSCertificateDelegation", Namespace: "roots", Status: "invalid"}: 1,
+	}
+	if diff := cmp.Diff(wantStatus, gotStatus); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -117,19 +117,33 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 				vh.Visit(func(v dag.Vertex) {
 					switch r := v.(type) {
 					case *dag.PrefixRoute:
-						rr := envoy.Route(envoy.RoutePrefix(r.Prefix), envoy.RouteRoute(&r.Route))
+						rr := route(envoy.RoutePrefix(r.Prefix, r.HeaderConditions...), &r.Route)
 
 						if r.HTTPSUpgrade {
-							rr.Action = envoy.UpgradeHTTPS()
-							rr.RequestHeadersToAdd = nil
+							rr = &envoy_api_v2_route.Route{
+								Match:  rr.Match,
+								Action: envoy.UpgradeHTTPS(),
+							}
 						}
 						routes = append(routes, rr)
 					case *dag.RegexRoute:
-						rr := envoy.Route(envoy.RouteRegex(r.Regex), envoy.RouteRoute(&r.Route))
+						rr := route(regexMatch(r), &r.Route)
 
 						if r.HTTPSUpgrade {
-							rr.Action = envoy.UpgradeHTTPS()
-							rr.RequestHeadersToAdd = nil
+							rr = &envoy_api_v2_route.Route{
+								Match:  rr.Match,
+								Action: envoy.UpgradeHTTPS(),
+							}
+						}
+						routes = append(routes, rr)
+					case *dag.ExactRoute:
+						rr := route(envoy.RouteExact(r.Path, r.HeaderConditions...), &r.Route)
+
+						if r.HTTPSUpgrade {
+							rr = &envoy_api_v2_route.Route{
+								Match:  rr.Match,
+								Action: envoy.UpgradeHTTPS(),
+							}
 						}
 						routes = append(routes, rr)
 					}
@@ -139,21 +153,31 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 				}
 				sort.Stable(longestRouteFirst(routes))
 				vhost := envoy.VirtualHost(vh.Name, routes...)
+				vhost.RateLimits = envoy.RateLimits(vh.RateLimitPolicy)
+				vhost.Cors = envoy.CORSPolicy(vh.CORSPolicy)
 				v.routes["ingress_http"].VirtualHosts = append(v.routes["ingress_http"].VirtualHosts, vhost)
 			case *dag.SecureVirtualHost:
 				var routes []*envoy_api_v2_route.Route
 				vh.Visit(func(v dag.Vertex) {
 					switch r := v.(type) {
 					case *dag.PrefixRoute:
-						routes = append(
-							routes,
-							envoy.Route(envoy.RoutePrefix(r.Prefix), envoy.RouteRoute(&r.Route)),
-						)
+						rr := route(envoy.RoutePrefix(r.Prefix, r.HeaderConditions...), &r.Route)
+						if vh.AuthorizationService != nil && r.AuthDisabled {
+							rr.TypedPerFilterConfig = envoy.DisableExtAuthz()
+						}
+						routes = append(routes, rr)
 					case *dag.RegexRoute:
-						routes = append(
-							routes,
-							envoy.Route(envoy.RouteRegex(r.Regex), envoy.RouteRoute(&r.Route)),
-						)
+						rr := route(regexMatch(r), &r.Route)
+						if vh.AuthorizationService != nil && r.AuthDisabled {
+							rr.TypedPerFilterConfig = envoy.DisableExtAuthz()
+						}
+						routes = append(routes, rr)
+					case *dag.ExactRoute:
+						rr := route(envoy.RouteExact(r.Path, r.HeaderConditions...), &r.Route)
+						if vh.AuthorizationService != nil && r.AuthDisabled {
+							rr.TypedPerFilterConfig = envoy.DisableExtAuthz()
+						}
+						routes = append(routes, rr)
 					}
 				})
 				if len(routes) < 1 {
@@ -161,7 +185,27 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 				}
 				sort.Stable(longestRouteFirst(routes))
 				vhost := envoy.VirtualHost(vh.VirtualHost.Name, routes...)
-				v.routes["ingress_https"].VirtualHosts = append(v.routes["ingress_https"].VirtualHosts, vhost)
+				vhost.RateLimits = envoy.RateLimits(vh.RateLimitPolicy)
+				vhost.Cors = envoy.CORSPolicy(vh.CORSPolicy)
+				name := secureRouteConfigName(vh)
+				if _, ok := v.routes[name]; !ok {
+					v.routes[name] = &v2.RouteConfiguration{
+						Name: name,
+					}
+				}
+				v.routes[name].VirtualHosts = append(v.routes[name].VirtualHosts, vhost)
+
+				if vh.FallbackCertificate != nil {
+					// clients which do not send SNI are routed by
+					// their Host header to the vhosts which enable
+					// the fallback certificate.
+					if _, ok := v.routes[ENVOY_FALLBACK_ROUTECONFIG]; !ok {
+						v.routes[ENVOY_FALLBACK_ROUTECONFIG] = &v2.RouteConfiguration{
+							Name: ENVOY_FALLBACK_ROUTECONFIG,
+						}
+					}
+					v.routes[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts = append(v.routes[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts, vhost)
+				}
 			default:
 				// recurse
 				vertex.Visit(v.visit)
@@ -173,6 +217,42 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 	}
 }
 
+// secureRouteConfigName returns the name of the route configuration
+// served on the filter chain of vh. A virtual host which validates
+// client certificates, or is protected by an authorization service,
+// has a route configuration of its own, so a request whose SNI names
+// another virtual host cannot reach it by its Host header.
+func secureRouteConfigName(vh *dag.SecureVirtualHost) string {
+	if vh.DownstreamValidation == nil && vh.AuthorizationService == nil {
+		return ENVOY_HTTPS_LISTENER
+	}
+	return ENVOY_HTTPS_LISTENER + "/" + vh.VirtualHost.Name
+}
+
+// route returns a route which forwards requests matching match to
+// the clusters of r, applying r's request and response headers policies
+// and tracing policy.
+func route(match *envoy_api_v2_route.RouteMatch, r *dag.Route) *envoy_api_v2_route.Route {
+	rr := envoy.Route(match, envoy.RouteRoute(r))
+	rr.RequestHeadersToAdd = append(rr.RequestHeadersToAdd, envoy.HeadersToAdd(r.RequestHeadersPolicy)...)
+	rr.RequestHeadersToRemove = envoy.HeadersToRemove(r.RequestHeadersPolicy)
+	rr.ResponseHeadersToAdd = envoy.HeadersToAdd(r.ResponseHeadersPolicy)
+	rr.ResponseHeadersToRemove = envoy.HeadersToRemove(r.ResponseHeadersPolicy)
+	if tp := r.TracingPolicy; tp != nil {
+		rr.Tracing = envoy.RouteTracing(tp.Sampling)
+	}
+	return rr
+}
+
+// regexMatch returns the route match for r. HTTPProxy regexes are
+// matched with RE2, Ingress regexes keep Envoy's std::regex engine.
+func regexMatch(r *dag.RegexRoute) *envoy_api_v2_route.RouteMatch {
+	if r.RE2 {
+		return envoy.RouteSafeRegex(r.Regex, r.HeaderConditions...)
+	}
+	return envoy.RouteRegex(r.Regex, r.HeaderConditions...)
+}
+
 type virtualHostsByName []*envoy_api_v2_route.VirtualHost
 
 func (v virtualHostsByName) Len() int           { return len(v) }
@@ -183,20 +263,68 @@ type longestRouteFirst []*envoy_api_v2_route.Route
 
 func (l longestRouteFirst) Len() int      { return len(l) }
 func (l longestRouteFirst) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+// Less orders exact path matches before regex matches, and
+// regex matches before prefix matches. Within each kind the
+// longest path sorts first.
 func (l longestRouteFirst) Less(i, j int) bool {
 	switch a := l[i].Match.PathSpecifier.(type) {
+	case *envoy_api_v2_route.RouteMatch_Path:
+		switch b := l[j].Match.PathSpecifier.(type) {
+		case *envoy_api_v2_route.RouteMatch_Path:
+			if a.Path == b.Path {
+				return moreSpecificHeaders(l[i].Match.Headers, l[j].Match.Headers)
+			}
+			return a.Path > b.Path
+		case *envoy_api_v2_route.RouteMatch_Regex, *envoy_api_v2_route.RouteMatch_SafeRegex, *envoy_api_v2_route.RouteMatch_Prefix:
+			return true
+		}
 	case *envoy_api_v2_route.RouteMatch_Prefix:
 		switch b := l[j].Match.PathSpecifier.(type) {
 		case *envoy_api_v2_route.RouteMatch_Prefix:
+			if a.Prefix == b.Prefix {
+				return moreSpecificHeaders(l[i].Match.Headers, l[j].Match.Headers)
+			}
 			return a.Prefix > b.Prefix
 		}
-	case *envoy_api_v2_route.RouteMatch_Regex:
-		switch b := l[j].Match.PathSpecifier.(type) {
-		case *envoy_api_v2_route.RouteMatch_Regex:
-			return a.Regex > b.Regex
+	case *envoy_api_v2_route.RouteMatch_Regex, *envoy_api_v2_route.RouteMatch_SafeRegex:
+		switch l[j].Match.PathSpecifier.(type) {
+		case *envoy_api_v2_route.RouteMatch_Regex, *envoy_api_v2_route.RouteMatch_SafeRegex:
+			a, b := pathRegex(l[i].Match), pathRegex(l[j].Match)
+			if a == b {
+				return moreSpecificHeaders(l[i].Match.Headers, l[j].Match.Headers)
+			}
+			return a > b
 		case *envoy_api_v2_route.RouteMatch_Prefix:
 			return true
 		}
 	}
 	return false
 }
+
+// pathRegex returns the regex of a regex or safe regex path match.
+func pathRegex(m *envoy_api_v2_route.RouteMatch) string {
+	switch p := m.PathSpecifier.(type) {
+	case *envoy_api_v2_route.RouteMatch_Regex:
+		return p.Regex
+	case *envoy_api_v2_route.RouteMatch_SafeRegex:
+		return p.SafeRegex.GetRegex()
+	}
+	return ""
+}
+
+// moreSpecificHeaders returns true if the route matching headers a should
+// be considered before the route matching headers b. Routes with more header
+// conditions sort first, routes with the same number of conditions are
+// ordered by their conditions for stability.
+func moreSpecificHeaders(a, b []*envoy_api_v2_route.HeaderMatcher) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	for i := range a {
+		if as, bs := a[i].String(), b[i].String(); as != bs {
+			return as < bs
+		}
+	}
+	return false
+}
@@ -19,16 +19,19 @@ import (
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
 	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
+	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/envoy"
 	"github.com/heptio/contour/internal/protobuf"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -137,8 +140,9 @@ func TestRouteCacheQuery(t *testing.T) {
 
 func TestRouteVisit(t *testing.T) {
 	tests := map[string]struct {
-		objs []interface{}
-		want map[string]*v2.RouteConfiguration
+		fallbackCertificate *types.NamespacedName
+		objs                []interface{}
+		want                map[string]*v2.RouteConfiguration
 	}{
 		"nothing": {
 			objs: nil,
@@ -297,19 +301,618 @@ func TestRouteVisit(t *testing.T) {
 				},
 			},
 		},
+		"httpproxy with regex condition": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []projcontour.Route{{
+							Condition: &projcontour.Condition{
+								Regex: "/[^/]+/invoices(/.*|/?)",
+							},
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RouteSafeRegex("/[^/]+/invoices(/.*|/?)"), routecluster("default/backend/80/da39a3ee5e")),
+						),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
+		"httpproxy with virtual host rate limit policy": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							RateLimitPolicy: &projcontour.RateLimitPolicy{
+								Descriptors: []projcontour.RateLimitDescriptor{{
+									Entries: []projcontour.RateLimitDescriptorEntry{{
+										GenericKey: &projcontour.GenericKeyDescriptor{
+											Value: "www",
+										},
+									}},
+								}},
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/backend/80/da39a3ee5e")),
+						),
+						RateLimits: []*envoy_api_v2_route.RateLimit{{
+							Actions: []*envoy_api_v2_route.RateLimit_Action{{
+								ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_GenericKey_{
+									GenericKey: &envoy_api_v2_route.RateLimit_Action_GenericKey{
+										DescriptorValue: "www",
+									},
+								},
+							}},
+						}},
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
+		"httpproxy with virtual host cors policy": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							CORSPolicy: &projcontour.CORSPolicy{
+								AllowOrigin:  []string{"https://example.com"},
+								AllowMethods: []string{"GET"},
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/backend/80/da39a3ee5e")),
+						),
+						Cors: envoy.CORSPolicy(&dag.CORSPolicy{
+							AllowOrigin:  []string{"https://example.com"},
+							AllowMethods: []string{"GET"},
+						}),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
+		"httpproxy with headers policy": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []projcontour.Route{{
+							RequestHeadersPolicy: &projcontour.HeadersPolicy{
+								Set: []projcontour.HeaderValue{{
+									Name:  "x-forwarded-app",
+									Value: "backend",
+								}},
+							},
+							ResponseHeadersPolicy: &projcontour.HeadersPolicy{
+								Remove: []string{"x-powered-by"},
+							},
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							&envoy_api_v2_route.Route{
+								Match:  envoy.RoutePrefix("/"),
+								Action: routecluster("default/backend/80/da39a3ee5e"),
+								RequestHeadersToAdd: []*envoy_api_v2_core.HeaderValueOption{{
+									Header: &envoy_api_v2_core.HeaderValue{
+										Key:   "x-request-start",
+										Value: "t=%START_TIME(%s.%3f)%",
+									},
+									Append: protobuf.Bool(true),
+								}, {
+									Header: &envoy_api_v2_core.HeaderValue{
+										Key:   "X-Forwarded-App",
+										Value: "backend",
+									},
+									Append: protobuf.Bool(false),
+								}},
+								ResponseHeadersToRemove: []string{"X-Powered-By"},
+							},
+						),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
+		"httpproxy with tracing policy": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						TracingPolicy: &projcontour.TracingPolicy{
+							Sampling: 5,
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							&envoy_api_v2_route.Route{
+								Match:  envoy.RoutePrefix("/"),
+								Action: routecluster("default/backend/80/da39a3ee5e"),
+								RequestHeadersToAdd: []*envoy_api_v2_core.HeaderValueOption{{
+									Header: &envoy_api_v2_core.HeaderValue{
+										Key:   "x-request-start",
+										Value: "t=%START_TIME(%s.%3f)%",
+									},
+									Append: protobuf.Bool(true),
+								}},
+								Tracing: envoy.RouteTracing(5),
+							},
+						),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
+		"httpproxy with externalname service": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Type:         v1.ServiceTypeExternalName,
+						ExternalName: "foo.io",
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), &envoy_api_v2_route.Route_Route{
+								Route: &envoy_api_v2_route.RouteAction{
+									ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+										Cluster: "default/backend/80/da39a3ee5e",
+									},
+									HostRewriteSpecifier: &envoy_api_v2_route.RouteAction_AutoHostRewrite{
+										AutoHostRewrite: protobuf.Bool(true),
+									},
+								},
+							}),
+						),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+				},
+			},
+		},
 		"default backend ingress with secret": {
 			objs: []interface{}{
-				&v1beta1.Ingress{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							Hosts:      []string{"whatever.example.com"},
+							SecretName: "secret",
+						}},
+						Backend: backend("kuard", 8080),
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata("certificate", "key"),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       8080,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "*", // default backend
+						Domains: domains("*"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
+						),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https", // no https for default backend
+				},
+			},
+		},
+		"vhost ingress with secret": {
+			objs: []interface{}{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							Hosts:      []string{"www.example.com"},
+							SecretName: "secret",
+						}},
+						Rules: []v1beta1.IngressRule{{
+							Host: "www.example.com",
+							IngressRuleValue: v1beta1.IngressRuleValue{
+								HTTP: &v1beta1.HTTPIngressRuleValue{
+									Paths: []v1beta1.HTTPIngressPath{{
+										Backend: v1beta1.IngressBackend{
+											ServiceName: "kuard",
+											ServicePort: intstr.FromString("www"),
+										},
+									}},
+								},
+							},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata("certificate", "key"),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:       "www",
+							Protocol:   "TCP",
+							Port:       8080,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
+						),
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
+						),
+					}},
+				},
+			},
+		},
+		"simple ingressroute with secret": {
+			objs: []interface{}{
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []ingressroutev1.Route{{
+							Match: "/",
+							Services: []ingressroutev1.Service{{
+								Name: "backend",
+								Port: 8080,
+							},
+							}},
+						},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata("certificate", "key"),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:       "www",
+							Protocol:   "TCP",
+							Port:       8080,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: map[string]*v2.RouteConfiguration{
+				"ingress_http": {
+					Name: "ingress_http",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match: envoy.RoutePrefix("/"),
+							Action: &envoy_api_v2_route.Route_Redirect{
+								Redirect: &envoy_api_v2_route.RedirectAction{
+									SchemeRewriteSpecifier: &envoy_api_v2_route.RedirectAction_HttpsRedirect{
+										HttpsRedirect: true,
+									},
+								},
+							},
+						}},
+					}},
+				},
+				"ingress_https": {
+					Name: "ingress_https",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: envoy.Routes(
+							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/backend/8080/da39a3ee5e")),
+						),
+					}},
+				},
+			},
+		},
+		"httpproxy with authorization": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "simple",
 						Namespace: "default",
 					},
-					Spec: v1beta1.IngressSpec{
-						TLS: []v1beta1.IngressTLS{{
-							Hosts:      []string{"whatever.example.com"},
-							SecretName: "secret",
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "secret",
+							},
+							Authorization: &projcontour.AuthorizationServer{
+								Name: "auth",
+								Port: 9000,
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}, {
+							Condition: &projcontour.Condition{
+								Prefix: "/public",
+							},
+							AuthPolicy: &projcontour.AuthorizationPolicy{
+								Disabled: true,
+							},
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
 						}},
-						Backend: backend("kuard", 8080),
 					},
 				},
 				&v1.Secret{
@@ -322,14 +925,30 @@ func TestRouteVisit(t *testing.T) {
 				},
 				&v1.Service{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "kuard",
+						Name:      "backend",
 						Namespace: "default",
 					},
 					Spec: v1.ServiceSpec{
 						Ports: []v1.ServicePort{{
-							Protocol:   "TCP",
-							Port:       8080,
-							TargetPort: intstr.FromInt(8080),
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "auth",
+						Namespace: "default",
+						Annotations: map[string]string{
+							"contour.heptio.com/upstream-protocol.h2c": "9000",
+						},
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "grpc",
+							Protocol: "TCP",
+							Port:     9000,
 						}},
 					},
 				},
@@ -338,42 +957,81 @@ func TestRouteVisit(t *testing.T) {
 				"ingress_http": {
 					Name: "ingress_http",
 					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
-						Name:    "*", // default backend
-						Domains: domains("*"),
-						Routes: envoy.Routes(
-							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
-						),
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:  envoy.RoutePrefix("/public"),
+							Action: envoy.UpgradeHTTPS(),
+						}, {
+							Match:  envoy.RoutePrefix("/"),
+							Action: envoy.UpgradeHTTPS(),
+						}},
 					}},
 				},
 				"ingress_https": {
-					Name: "ingress_https", // no https for default backend
+					Name: "ingress_https",
+				},
+				"ingress_https/www.example.com": {
+					Name: "ingress_https/www.example.com",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:                envoy.RoutePrefix("/public"),
+							Action:               routecluster("default/backend/80/da39a3ee5e"),
+							RequestHeadersToAdd:  envoy.RouteHeaders(),
+							TypedPerFilterConfig: envoy.DisableExtAuthz(),
+						}, {
+							Match:               envoy.RoutePrefix("/"),
+							Action:              routecluster("default/backend/80/da39a3ee5e"),
+							RequestHeadersToAdd: envoy.RouteHeaders(),
+						}},
+					}},
 				},
 			},
 		},
-		"vhost ingress with secret": {
+		"httpproxy with client validation": {
 			objs: []interface{}{
-				&v1beta1.Ingress{
+				&projcontour.HTTPProxy{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "simple",
+						Name:      "mtls",
 						Namespace: "default",
 					},
-					Spec: v1beta1.IngressSpec{
-						TLS: []v1beta1.IngressTLS{{
-							Hosts:      []string{"www.example.com"},
-							SecretName: "secret",
-						}},
-						Rules: []v1beta1.IngressRule{{
-							Host: "www.example.com",
-							IngressRuleValue: v1beta1.IngressRuleValue{
-								HTTP: &v1beta1.HTTPIngressRuleValue{
-									Paths: []v1beta1.HTTPIngressPath{{
-										Backend: v1beta1.IngressBackend{
-											ServiceName: "kuard",
-											ServicePort: intstr.FromString("www"),
-										},
-									}},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "secure.example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "secret",
+								ClientValidation: &projcontour.DownstreamValidation{
+									CACertificate: "ca",
 								},
 							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
 						}},
 					},
 				},
@@ -385,17 +1043,25 @@ func TestRouteVisit(t *testing.T) {
 					Type: "kubernetes.io/tls",
 					Data: secretdata("certificate", "key"),
 				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ca",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						envoy.CACertificateKey: []byte("ca"),
+					},
+				},
 				&v1.Service{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "kuard",
+						Name:      "backend",
 						Namespace: "default",
 					},
 					Spec: v1.ServiceSpec{
 						Ports: []v1.ServicePort{{
-							Name:       "www",
-							Protocol:   "TCP",
-							Port:       8080,
-							TargetPort: intstr.FromInt(8080),
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
 						}},
 					},
 				},
@@ -404,47 +1070,74 @@ func TestRouteVisit(t *testing.T) {
 				"ingress_http": {
 					Name: "ingress_http",
 					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "secure.example.com",
+						Domains: domains("secure.example.com"),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:  envoy.RoutePrefix("/"),
+							Action: envoy.UpgradeHTTPS(),
+						}},
+					}, {
 						Name:    "www.example.com",
 						Domains: domains("www.example.com"),
-						Routes: envoy.Routes(
-							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
-						),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:  envoy.RoutePrefix("/"),
+							Action: envoy.UpgradeHTTPS(),
+						}},
 					}},
 				},
+				// the virtual host which validates client certificates
+				// is not served on the filter chains of other hosts.
 				"ingress_https": {
 					Name: "ingress_https",
 					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
 						Name:    "www.example.com",
 						Domains: domains("www.example.com"),
-						Routes: envoy.Routes(
-							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
-						),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:               envoy.RoutePrefix("/"),
+							Action:              routecluster("default/backend/80/da39a3ee5e"),
+							RequestHeadersToAdd: envoy.RouteHeaders(),
+						}},
+					}},
+				},
+				"ingress_https/secure.example.com": {
+					Name: "ingress_https/secure.example.com",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "secure.example.com",
+						Domains: domains("secure.example.com"),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:               envoy.RoutePrefix("/"),
+							Action:              routecluster("default/backend/80/da39a3ee5e"),
+							RequestHeadersToAdd: envoy.RouteHeaders(),
+						}},
 					}},
 				},
 			},
 		},
-		"simple ingressroute with secret": {
+		"httpproxy with fallback certificate": {
+			fallbackCertificate: &types.NamespacedName{
+				Name:      "fallback",
+				Namespace: "heptio-contour",
+			},
 			objs: []interface{}{
-				&ingressroutev1.IngressRoute{
+				&projcontour.HTTPProxy{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "simple",
 						Namespace: "default",
 					},
-					Spec: ingressroutev1.IngressRouteSpec{
+					Spec: projcontour.HTTPProxySpec{
 						VirtualHost: &projcontour.VirtualHost{
 							Fqdn: "www.example.com",
 							TLS: &projcontour.TLS{
-								SecretName: "secret",
+								SecretName:                "secret",
+								EnableFallbackCertificate: true,
 							},
 						},
-						Routes: []ingressroutev1.Route{{
-							Match: "/",
-							Services: []ingressroutev1.Service{{
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
 								Name: "backend",
-								Port: 8080,
-							},
+								Port: 80,
 							}},
-						},
+						}},
 					},
 				},
 				&v1.Secret{
@@ -455,6 +1148,14 @@ func TestRouteVisit(t *testing.T) {
 					Type: "kubernetes.io/tls",
 					Data: secretdata("certificate", "key"),
 				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "fallback",
+						Namespace: "heptio-contour",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata("certificate", "key"),
+				},
 				&v1.Service{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "backend",
@@ -462,10 +1163,9 @@ func TestRouteVisit(t *testing.T) {
 					},
 					Spec: v1.ServiceSpec{
 						Ports: []v1.ServicePort{{
-							Name:       "www",
-							Protocol:   "TCP",
-							Port:       8080,
-							TargetPort: intstr.FromInt(8080),
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
 						}},
 					},
 				},
@@ -477,14 +1177,8 @@ func TestRouteVisit(t *testing.T) {
 						Name:    "www.example.com",
 						Domains: domains("www.example.com"),
 						Routes: []*envoy_api_v2_route.Route{{
-							Match: envoy.RoutePrefix("/"),
-							Action: &envoy_api_v2_route.Route_Redirect{
-								Redirect: &envoy_api_v2_route.RedirectAction{
-									SchemeRewriteSpecifier: &envoy_api_v2_route.RedirectAction_HttpsRedirect{
-										HttpsRedirect: true,
-									},
-								},
-							},
+							Match:  envoy.RoutePrefix("/"),
+							Action: envoy.UpgradeHTTPS(),
 						}},
 					}},
 				},
@@ -493,9 +1187,23 @@ func TestRouteVisit(t *testing.T) {
 					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
 						Name:    "www.example.com",
 						Domains: domains("www.example.com"),
-						Routes: envoy.Routes(
-							envoy.Route(envoy.RoutePrefix("/"), routecluster("default/backend/8080/da39a3ee5e")),
-						),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:               envoy.RoutePrefix("/"),
+							Action:              routecluster("default/backend/80/da39a3ee5e"),
+							RequestHeadersToAdd: envoy.RouteHeaders(),
+						}},
+					}},
+				},
+				"ingress_fallbackcert": {
+					Name: "ingress_fallbackcert",
+					VirtualHosts: []*envoy_api_v2_route.VirtualHost{{
+						Name:    "www.example.com",
+						Domains: domains("www.example.com"),
+						Routes: []*envoy_api_v2_route.Route{{
+							Match:               envoy.RoutePrefix("/"),
+							Action:              routecluster("default/backend/80/da39a3ee5e"),
+							RequestHeadersToAdd: envoy.RouteHeaders(),
+						}},
 					}},
 				},
 			},
@@ -1322,7 +2030,7 @@ func TestRouteVisit(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			root := buildDAG(tc.objs...)
+			root := buildDAGFallback(tc.fallbackCertificate, tc.objs...)
 			got := visitRoutes(root)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
@@ -1360,6 +2068,34 @@ func TestSortLongestRouteFirst(t *testing.T) {
 				Match: envoy.RouteRegex("/v1/.+"),
 			}},
 		},
+		"same prefix, more headers first": {
+			routes: []*envoy_api_v2_route.Route{{
+				Match: envoy.RoutePrefix("/"),
+			}, {
+				Match: envoy.RoutePrefix("/", dag.HeaderCondition{
+					Name: "x-canary", Value: "true", MatchType: dag.HeaderMatchTypeExact,
+				}),
+			}, {
+				Match: envoy.RoutePrefix("/", dag.HeaderCondition{
+					Name: "x-canary", Value: "true", MatchType: dag.HeaderMatchTypeExact,
+				}, dag.HeaderCondition{
+					Name: "x-tenant", Value: "acme", MatchType: dag.HeaderMatchTypeExact,
+				}),
+			}},
+			want: []*envoy_api_v2_route.Route{{
+				Match: envoy.RoutePrefix("/", dag.HeaderCondition{
+					Name: "x-canary", Value: "true", MatchType: dag.HeaderMatchTypeExact,
+				}, dag.HeaderCondition{
+					Name: "x-tenant", Value: "acme", MatchType: dag.HeaderMatchTypeExact,
+				}),
+			}, {
+				Match: envoy.RoutePrefix("/", dag.HeaderCondition{
+					Name: "x-canary", Value: "true", MatchType: dag.HeaderMatchTypeExact,
+				}),
+			}, {
+				Match: envoy.RoutePrefix("/"),
+			}},
+		},
 		"regex sorts before prefix": {
 			routes: []*envoy_api_v2_route.Route{{
 				Match: envoy.RouteRegex("/api/v?"),
@@ -1376,6 +2112,42 @@ func TestSortLongestRouteFirst(t *testing.T) {
 				Match: envoy.RoutePrefix("/"),
 			}},
 		},
+		"regex and safe regex sort together": {
+			routes: []*envoy_api_v2_route.Route{{
+				Match: envoy.RouteRegex("/a.*"),
+			}, {
+				Match: envoy.RoutePrefix("/"),
+			}, {
+				Match: envoy.RouteSafeRegex("/b.*"),
+			}},
+			want: []*envoy_api_v2_route.Route{{
+				Match: envoy.RouteSafeRegex("/b.*"),
+			}, {
+				Match: envoy.RouteRegex("/a.*"),
+			}, {
+				Match: envoy.RoutePrefix("/"),
+			}},
+		},
+		"exact sorts before regex and prefix": {
+			routes: []*envoy_api_v2_route.Route{{
+				Match: envoy.RoutePrefix("/"),
+			}, {
+				Match: envoy.RouteRegex("/api/.*"),
+			}, {
+				Match: envoy.RouteExact("/api"),
+			}, {
+				Match: envoy.RouteExact("/api/healthz"),
+			}},
+			want: []*envoy_api_v2_route.Route{{
+				Match: envoy.RouteExact("/api/healthz"),
+			}, {
+				Match: envoy.RouteExact("/api"),
+			}, {
+				Match: envoy.RouteRegex("/api/.*"),
+			}, {
+				Match: envoy.RoutePrefix("/"),
+			}},
+		},
 	}
 
 	for name, tc := range tests {
@@ -94,10 +94,20 @@ func visitSecrets(root dag.Vertex) map[string]*envoy_api_v2_auth.Secret {
 func (v *secretVisitor) visit(vertex dag.Vertex) {
 	switch svh := vertex.(type) {
 	case *dag.SecureVirtualHost:
-		if svh.Secret != nil {
-			name := envoy.Secretname(svh.Secret)
+		for _, secret := range []*dag.Secret{svh.Secret, svh.FallbackCertificate} {
+			if secret == nil {
+				continue
+			}
+			name := envoy.Secretname(secret)
+			if _, ok := v.secrets[name]; !ok {
+				s := envoy.Secret(secret)
+				v.secrets[s.Name] = s
+			}
+		}
+		if dv := svh.DownstreamValidation; dv != nil {
+			name := envoy.CASecretname(dv.CACertificate)
 			if _, ok := v.secrets[name]; !ok {
-				s := envoy.Secret(svh.Secret)
+				s := envoy.CASecret(dv.CACertificate)
 				v.secrets[s.Name] = s
 			}
 		}
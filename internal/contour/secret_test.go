@@ -11,9 +11,11 @@ import (
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
 	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
 	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/envoy"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -96,8 +98,9 @@ func TestSecretCacheQuery(t *testing.T) {
 
 func TestSecretVisit(t *testing.T) {
 	tests := map[string]struct {
-		objs []interface{}
-		want map[string]*envoy_api_v2_auth.Secret
+		fallbackCertificate *types.NamespacedName
+		objs                []interface{}
+		want                map[string]*envoy_api_v2_auth.Secret
 	}{
 		"nothing": {
 			objs: nil,
@@ -456,11 +459,124 @@ func TestSecretVisit(t *testing.T) {
 				secret("default/secret-b/0a068be4ba", "cert-b", "key-b"),
 			),
 		},
+		"httpproxy with fallback certificate": {
+			fallbackCertificate: &types.NamespacedName{
+				Name:      "fallback",
+				Namespace: "heptio-contour",
+			},
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &projcontour.TLS{
+								SecretName:                "secret",
+								EnableFallbackCertificate: true,
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+				tlssecret("default", "secret", secretdata("cert", "key")),
+				tlssecret("heptio-contour", "fallback", secretdata("fallback-cert", "fallback-key")),
+			},
+			want: secretmap(
+				secret("default/secret/cd1b506996", "cert", "key"),
+				secret("heptio-contour/fallback/5fa2c816ff", "fallback-cert", "fallback-key"),
+			),
+		},
+		"httpproxy with client validation": {
+			objs: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "secret",
+								ClientValidation: &projcontour.DownstreamValidation{
+									CACertificate: "ca",
+								},
+							},
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+				tlssecret("default", "secret", secretdata("cert", "key")),
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ca",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						envoy.CACertificateKey: []byte("ca"),
+					},
+				},
+			},
+			want: secretmap(
+				secret("default/secret/cd1b506996", "cert", "key"),
+				&envoy_api_v2_auth.Secret{
+					Name: "default/ca/ca/1c42c72cf9",
+					Type: &envoy_api_v2_auth.Secret_ValidationContext{
+						ValidationContext: &envoy_api_v2_auth.CertificateValidationContext{
+							TrustedCa: &envoy_api_v2_core.DataSource{
+								Specifier: &envoy_api_v2_core.DataSource_InlineBytes{
+									InlineBytes: []byte("ca"),
+								},
+							},
+						},
+					},
+				},
+			),
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			root := buildDAG(tc.objs...)
+			root := buildDAGFallback(tc.fallbackCertificate, tc.objs...)
 			got := visitSecrets(root)
 			if !reflect.DeepEqual(tc.want, got) {
 				t.Fatalf("expected:\n%+v\ngot:\n%+v", tc.want, got)
@@ -471,7 +587,15 @@ func TestSecretVisit(t *testing.T) {
 
 // buildDAG produces a dag.DAG from the supplied objects.
 func buildDAG(objs ...interface{}) *dag.DAG {
-	var builder dag.Builder
+	return buildDAGFallback(nil, objs...)
+}
+
+// buildDAGFallback produces a dag.DAG from the supplied objects
+// with the fallback certificate fallback.
+func buildDAGFallback(fallback *types.NamespacedName, objs ...interface{}) *dag.DAG {
+	builder := dag.Builder{
+		FallbackCertificate: fallback,
+	}
 	for _, o := range objs {
 		builder.Source.Insert(o)
 	}
@@ -79,7 +79,7 @@ func TestVisitClusters(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := visitClusters(tc.root)
+			got := visitClusters(tc.root, new(ClusterVisitorConfig))
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -103,6 +103,7 @@ func TestVisitListeners(t *testing.T) {
 	}
 
 	tests := map[string]struct {
+		lvc  ListenerVisitorConfig
 		root dag.Visitable
 		want map[string]*envoy_api_v2.Listener
 	}{
@@ -137,7 +138,50 @@ func TestVisitListeners(t *testing.T) {
 							ServerNames: []string{"tcpproxy.example.com"},
 						},
 						TlsContext: tlscontext(envoy_api_v2_auth.TlsParameters_TLSv1_1),
-						Filters:    envoy.Filters(envoy.TCPProxy(ENVOY_HTTPS_LISTENER, p1, DEFAULT_HTTPS_ACCESS_LOG)),
+						Filters:    envoy.Filters(envoy.TCPProxy(ENVOY_HTTPS_LISTENER, p1, envoy.FileAccessLog(DEFAULT_HTTPS_ACCESS_LOG), 0)),
+					}},
+					ListenerFilters: envoy.ListenerFilters(
+						envoy.TLSInspector(),
+					),
+				},
+			),
+		},
+		"TCPService forward with idle timeouts": {
+			lvc: ListenerVisitorConfig{
+				ConnectionIdleTimeout: time.Minute,
+				TCPIdleTimeout:        time.Hour,
+			},
+			root: &dag.Listener{
+				Port: 443,
+				VirtualHosts: virtualhosts(
+					&dag.SecureVirtualHost{
+						VirtualHost: dag.VirtualHost{
+							Name: "tcpproxy.example.com",
+						},
+						TCPProxy: p1,
+						Secret: &dag.Secret{
+							Object: &v1.Secret{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:      "secret",
+									Namespace: "default",
+								},
+								Data: secretdata("certificate", "key"),
+							},
+						},
+						MinProtoVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+					},
+				),
+			},
+			want: listenermap(
+				&envoy_api_v2.Listener{
+					Name:    ENVOY_HTTPS_LISTENER,
+					Address: envoy.SocketAddress("0.0.0.0", 8443),
+					FilterChains: []*envoy_api_v2_listener.FilterChain{{
+						FilterChainMatch: &envoy_api_v2_listener.FilterChainMatch{
+							ServerNames: []string{"tcpproxy.example.com"},
+						},
+						TlsContext: tlscontext(envoy_api_v2_auth.TlsParameters_TLSv1_1),
+						Filters:    envoy.Filters(envoy.TCPProxy(ENVOY_HTTPS_LISTENER, p1, envoy.FileAccessLog(DEFAULT_HTTPS_ACCESS_LOG), time.Hour)),
 					}},
 					ListenerFilters: envoy.ListenerFilters(
 						envoy.TLSInspector(),
@@ -149,7 +193,7 @@ func TestVisitListeners(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := visitListeners(tc.root, new(ListenerVisitorConfig))
+			got := visitListeners(tc.root, &tc.lvc)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
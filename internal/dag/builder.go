@@ -14,13 +14,17 @@
 package dag
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
@@ -39,6 +43,29 @@ type Builder struct {
 	// permitInsecure field in IngressRoute.
 	DisablePermitInsecure bool
 
+	// TLSPolicy is the TLS policy of the HTTPS listener,
+	// which the TLS settings of an HTTPProxy may only tighten.
+	TLSPolicy TLSPolicy
+
+	// FallbackCertificate is the Secret whose cert and key are
+	// presented to clients which do not send SNI. HTTPProxies opt
+	// in to receiving those clients with enableFallbackCertificate.
+	FallbackCertificate *types.NamespacedName
+
+	// RateLimitServiceConfigured is true if the HTTP listeners
+	// consult a rate limit service. Otherwise rateLimitPolicy has
+	// no effect and a warning is added to the status of HTTPProxies
+	// which set it.
+	RateLimitServiceConfigured bool
+
+	// Clock returns the time at which the validity of TLS
+	// certificates is checked. If nil, time.Now is used.
+	Clock func() time.Time
+
+	// recheck is the earliest time at which the validity of a
+	// certificate checked during Build changes.
+	recheck time.Time
+
 	services map[servicemeta]*Service
 	secrets  map[Meta]*Secret
 
@@ -65,6 +92,8 @@ func (b *Builder) Build() *DAG {
 
 	b.computeHTTPProxies()
 
+	b.computeDelegations()
+
 	return b.buildDAG()
 }
 
@@ -78,6 +107,16 @@ func (b *Builder) reset() {
 	b.securevirtualhosts = make(map[string]*SecureVirtualHost)
 
 	b.statuses = make(map[Meta]Status, len(b.statuses))
+
+	b.recheck = time.Time{}
+}
+
+// now returns the current time of the Builder's Clock.
+func (b *Builder) now() time.Time {
+	if b.Clock == nil {
+		return time.Now()
+	}
+	return b.Clock()
 }
 
 // lookupService returns a Service that matches the Meta and Port of the Kubernetes' Service.
@@ -265,7 +304,7 @@ func (b *Builder) computeSecureVirtualhosts() {
 		for _, tls := range ing.Spec.TLS {
 			m := splitSecret(tls.SecretName, ing.Namespace)
 			sec := b.lookupSecret(m, validSecret)
-			if sec != nil && b.delegationPermitted(m, ing.Namespace) {
+			if _, ok := b.delegationPermitted(m, ing.Namespace); sec != nil && ok {
 				for _, host := range tls.Hosts {
 					svhost := b.lookupSecureVirtualHost(host)
 					svhost.Secret = sec
@@ -277,38 +316,244 @@ func (b *Builder) computeSecureVirtualhosts() {
 	}
 }
 
-func (b *Builder) delegationPermitted(secret Meta, to string) bool {
-	contains := func(haystack []string, needle string) bool {
-		if len(haystack) == 1 && haystack[0] == "*" {
-			return true
+// delegationPermitted returns true if the secret may be referenced from
+// the namespace to. If the secret is in another namespace, the delegation
+// which permits the reference is also returned.
+func (b *Builder) delegationPermitted(secret Meta, to string) (*certificateDelegation, bool) {
+	if secret.namespace == to {
+		// secret is in the same namespace as target
+		return nil, true
+	}
+	for _, d := range b.certificateDelegations() {
+		if d.GetObjectMeta().GetNamespace() != secret.namespace {
+			continue
 		}
-		for _, h := range haystack {
-			if h == needle {
-				return true
+		for _, cd := range d.delegations {
+			if cd.SecretName == secret.name && delegatedTo(cd.TargetNamespaces, to) {
+				return d, true
 			}
 		}
-		return false
 	}
+	return nil, false
+}
 
-	if secret.namespace == to {
-		// secret is in the same namespace as target
+// delegatedTo returns true if namespace is one of the target namespaces
+// of a CertificateDelegation, or the targets are only the wildcard "*".
+func delegatedTo(targets []string, namespace string) bool {
+	if len(targets) == 1 && targets[0] == "*" {
 		return true
 	}
+	for _, t := range targets {
+		if t == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateDelegation is a TLSCertificateDelegation of either API group.
+type certificateDelegation struct {
+	Object
+	group       string
+	delegations []projcontour.CertificateDelegation
+}
+
+func (d *certificateDelegation) String() string {
+	m := d.GetObjectMeta()
+	return fmt.Sprintf("%s TLSCertificateDelegation %s/%s", d.group, m.GetNamespace(), m.GetName())
+}
+
+// certificateDelegations returns the TLSCertificateDelegations of both API
+// groups in order of precedence; projectcontour.io delegations before
+// contour.heptio.com delegations, each ordered by namespace and name.
+func (b *Builder) certificateDelegations() []*certificateDelegation {
+	var pcds, irds []*certificateDelegation
+	for _, d := range b.Source.httpproxydelegations {
+		pcds = append(pcds, &certificateDelegation{
+			Object:      d,
+			group:       projcontour.GroupName,
+			delegations: d.Spec.Delegations,
+		})
+	}
 	for _, d := range b.Source.irdelegations {
-		if d.Namespace != secret.namespace {
+		cds := make([]projcontour.CertificateDelegation, 0, len(d.Spec.Delegations))
+		for _, cd := range d.Spec.Delegations {
+			cds = append(cds, projcontour.CertificateDelegation(cd))
+		}
+		irds = append(irds, &certificateDelegation{
+			Object:      d,
+			group:       ingressroutev1.GroupName,
+			delegations: cds,
+		})
+	}
+	sort.Stable(delegationsByName(pcds))
+	sort.Stable(delegationsByName(irds))
+	return append(pcds, irds...)
+}
+
+type delegationsByName []*certificateDelegation
+
+func (d delegationsByName) Len() int      { return len(d) }
+func (d delegationsByName) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d delegationsByName) Less(i, j int) bool {
+	a, b := d[i].GetObjectMeta(), d[j].GetObjectMeta()
+	if a.GetNamespace() != b.GetNamespace() {
+		return a.GetNamespace() < b.GetNamespace()
+	}
+	return a.GetName() < b.GetName()
+}
+
+// computeDelegations sets the status of each TLSCertificateDelegation.
+// A delegation is invalid if a secret it delegates does not exist, or
+// if a delegation which takes precedence already delegates the secret
+// to some of the same namespaces. A delegation identical to one which
+// takes precedence is valid, but reported as superseded by it.
+func (b *Builder) computeDelegations() {
+	// granted records, for each delegated secret, the delegation
+	// which granted each target namespace.
+	granted := make(map[Meta]map[string]*certificateDelegation)
+	for _, d := range b.certificateDelegations() {
+		sw, commit := b.WithObject(d.Object)
+		superseded, err := b.grantDelegation(d, granted)
+		if err != nil {
+			sw.SetInvalid(err.Error())
+		} else {
+			sw.SetValid()
+			for _, s := range superseded {
+				sw.WithValue("description", fmt.Sprintf("%s, %s", sw.values["description"], s))
+			}
+		}
+		commit()
+	}
+}
+
+// grantDelegation records the namespaces d delegates each of its secrets
+// to in granted, returning an error if a secret does not exist or one of
+// its target namespaces has already been granted by another delegation.
+// The delegations of d which another delegation has already granted
+// identically are skipped and returned as superseded.
+func (b *Builder) grantDelegation(d *certificateDelegation, granted map[Meta]map[string]*certificateDelegation) ([]string, error) {
+	var superseded []string
+	namespace := d.GetObjectMeta().GetNamespace()
+	for _, cd := range d.delegations {
+		m := Meta{name: cd.SecretName, namespace: namespace}
+		if sec, ok := b.Source.secrets[m]; !ok || !validSecret(sec) {
+			return nil, fmt.Errorf("secret %q not found or is malformed", cd.SecretName)
+		}
+		if granted[m] == nil {
+			granted[m] = make(map[string]*certificateDelegation)
+		}
+		targets := delegationTargets(cd.TargetNamespaces)
+		if other := grantedBy(granted[m], targets); other != nil && other != d {
+			superseded = append(superseded, fmt.Sprintf("delegation of secret %q superseded by %s", cd.SecretName, other))
 			continue
 		}
-		for _, d := range d.Spec.Delegations {
-			if contains(d.TargetNamespaces, to) {
-				if secret.name == d.SecretName {
-					return true
+		for _, target := range targets {
+			for t, other := range granted[m] {
+				if other != d && (t == target || t == "*" || target == "*") {
+					return nil, fmt.Errorf("secret %q: delegation to namespace %q conflicts with %s", cd.SecretName, target, other)
 				}
 			}
+			granted[m][target] = d
+		}
+	}
+	return superseded, nil
+}
+
+// delegationTargets returns the distinct target namespaces of a
+// CertificateDelegation. "*" is only a wildcard when it is the only
+// target namespace, otherwise it is dropped.
+func delegationTargets(targetNamespaces []string) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, target := range targetNamespaces {
+		if len(targetNamespaces) > 1 && target == "*" {
+			continue
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// grantedBy returns the delegation which was granted exactly the
+// supplied target namespaces, or nil if there is none.
+func grantedBy(grants map[string]*certificateDelegation, targets []string) *certificateDelegation {
+	if len(targets) == 0 {
+		return nil
+	}
+	other := grants[targets[0]]
+	if other == nil {
+		return nil
+	}
+	n := 0
+	for _, g := range grants {
+		if g == other {
+			n++
+		}
+	}
+	if n != len(targets) {
+		return nil
+	}
+	for _, t := range targets {
+		if grants[t] != other {
+			return nil
+		}
+	}
+	return other
+}
+
+// reportDelegation adds the delegation which permitted the object to
+// use a TLS certificate from another namespace to its description,
+// if the object is valid.
+func reportDelegation(sw *ObjectStatusWriter, d *certificateDelegation) {
+	if d == nil || sw.values["status"] != StatusValid {
+		return
+	}
+	sw.WithValue("description", fmt.Sprintf("%s, TLS certificate delegated by %s", sw.values["description"], d))
+}
+
+// reportRateLimitWarning adds a warning to the description of the
+// object if it is valid and sets a rateLimitPolicy, but there is
+// no rate limit service to enforce it.
+func (b *Builder) reportRateLimitWarning(sw *ObjectStatusWriter, proxy *projcontour.HTTPProxy) {
+	if b.RateLimitServiceConfigured || sw.values["status"] != StatusValid || !hasRateLimitPolicy(proxy) {
+		return
+	}
+	sw.WithValue("description", fmt.Sprintf("%s, warning: rateLimitPolicy has no effect, no rate limit service is configured", sw.values["description"]))
+}
+
+// hasRateLimitPolicy returns true if the virtual host
+// or any route of proxy sets a rateLimitPolicy.
+func hasRateLimitPolicy(proxy *projcontour.HTTPProxy) bool {
+	if vhost := proxy.Spec.VirtualHost; vhost != nil && vhost.RateLimitPolicy != nil && len(vhost.RateLimitPolicy.Descriptors) > 0 {
+		return true
+	}
+	for _, route := range proxy.Spec.Routes {
+		if route.RateLimitPolicy != nil && len(route.RateLimitPolicy.Descriptors) > 0 {
+			return true
 		}
 	}
 	return false
 }
 
+// reportCertificateWarning adds a warning to the description of the
+// object if it is valid but its TLS certificate is not valid now.
+func (b *Builder) reportCertificateWarning(sw *ObjectStatusWriter, secret *Secret) {
+	if secret == nil || sw.values["status"] != StatusValid {
+		return
+	}
+	warning, change := certificateWarning(secret, b.now())
+	if warning != "" {
+		sw.WithValue("description", fmt.Sprintf("%s, warning: %s", sw.values["description"], warning))
+	}
+	if !change.IsZero() && (b.recheck.IsZero() || change.Before(b.recheck)) {
+		b.recheck = change
+	}
+}
+
 func (b *Builder) computeIngresses() {
 	// deconstruct each ingress into routes and virtualhost entries
 	for _, ing := range b.Source.ingresses {
@@ -402,11 +647,13 @@ func (b *Builder) computeIngressRoute(ir *ingressroutev1.IngressRoute) {
 	}
 
 	var enforceTLS, passthrough bool
+	var delegation *certificateDelegation
 	if tls := ir.Spec.VirtualHost.TLS; tls != nil {
 		m := splitSecret(tls.SecretName, ir.Namespace)
 		sec := b.lookupSecret(m, validSecret)
 		if sec != nil {
-			if !b.delegationPermitted(m, ir.Namespace) {
+			var ok bool
+			if delegation, ok = b.delegationPermitted(m, ir.Namespace); !ok {
 				sw.SetInvalid(fmt.Sprintf("%s: certificate delegation not permitted", tls.SecretName))
 				return
 			}
@@ -430,6 +677,7 @@ func (b *Builder) computeIngressRoute(ir *ingressroutev1.IngressRoute) {
 		b.processTCPProxy(sw, ir, nil, host)
 	}
 	b.processIngressRoutes(sw, ir, "", nil, host, ir.Spec.TCPProxy == nil && enforceTLS)
+	reportDelegation(sw, delegation)
 }
 
 func (b *Builder) computeHTTPProxies() {
@@ -460,17 +708,25 @@ func (b *Builder) computeHTTPProxy(proxy *projcontour.HTTPProxy) {
 		return
 	}
 	sw = sw.WithValue("vhost", host)
-	if strings.Contains(host, "*") {
-		sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost.Fqdn %q cannot use wildcards", host))
+	if strings.Contains(host, "*") && !validWildcard(host) {
+		sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost.Fqdn %q can only use a wildcard as the leftmost label", host))
 		return
 	}
 
 	var enforceTLS, passthrough bool
+	var delegation *certificateDelegation
+	var secret *Secret
 	if tls := proxy.Spec.VirtualHost.TLS; tls != nil {
 		// attach secrets to TLS enabled vhosts
 		m := splitSecret(tls.SecretName, proxy.Namespace)
 		sec := b.lookupSecret(m, validSecret)
-		if sec != nil && b.delegationPermitted(m, proxy.Namespace) {
+		if sec != nil {
+			var ok bool
+			if delegation, ok = b.delegationPermitted(m, proxy.Namespace); !ok {
+				sw.SetInvalid(fmt.Sprintf("%s: certificate delegation not permitted", tls.SecretName))
+				return
+			}
+			secret = sec
 			svhost := b.lookupSecureVirtualHost(host)
 			svhost.Secret = sec
 			svhost.MinProtoVersion = MinProtoVersion(proxy.Spec.VirtualHost.TLS.MinimumProtocolVersion)
@@ -485,22 +741,138 @@ func (b *Builder) computeHTTPProxy(proxy *projcontour.HTTPProxy) {
 			sw.SetInvalid(fmt.Sprintf("TLS Secret [%s] not found or is malformed", tls.SecretName))
 			return
 		}
+
+		tp, err := tlsPolicy(tls, b.TLSPolicy)
+		if err != nil {
+			sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost.TLS: %s", err))
+			return
+		}
+		if enforceTLS {
+			svhost := b.lookupSecureVirtualHost(host)
+			svhost.MaxProtoVersion = tp.MaximumProtocolVersion
+			svhost.CipherSuites = tp.CipherSuites
+			svhost.ECDHCurves = tp.ECDHCurves
+		}
+
+		if tls.ClientValidation != nil {
+			if !enforceTLS {
+				sw.SetInvalid("Spec.VirtualHost.TLS.ClientValidation: tls.secretName must be specified")
+				return
+			}
+			dv, err := b.lookupDownstreamValidation(tls.ClientValidation, proxy.Namespace)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost.TLS.ClientValidation: %s", err))
+				return
+			}
+			b.lookupSecureVirtualHost(host).DownstreamValidation = dv
+		}
+
+		if tls.EnableFallbackCertificate {
+			sec, err := b.lookupFallbackCertificate(proxy, enforceTLS)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost.TLS.EnableFallbackCertificate: %s", err))
+				return
+			}
+			b.lookupSecureVirtualHost(host).FallbackCertificate = sec
+		}
+	}
+
+	rlp, err := rateLimitPolicy(proxy.Spec.VirtualHost.RateLimitPolicy)
+	if err != nil {
+		sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost: %s", err))
+		return
+	}
+	if rlp != nil {
+		b.lookupVirtualHost(host).RateLimitPolicy = rlp
+		if enforceTLS {
+			b.lookupSecureVirtualHost(host).RateLimitPolicy = rlp
+		}
+	}
+
+	cp, err := corsPolicy(proxy.Spec.VirtualHost.CORSPolicy)
+	if err != nil {
+		sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost: %s", err))
+		return
+	}
+	if cp != nil {
+		b.lookupVirtualHost(host).CORSPolicy = cp
+		if enforceTLS {
+			b.lookupSecureVirtualHost(host).CORSPolicy = cp
+		}
+	}
+
+	if auth := proxy.Spec.VirtualHost.Authorization; auth != nil {
+		if !enforceTLS {
+			sw.SetInvalid("Spec.VirtualHost.Authorization: tls.secretName must be specified")
+			return
+		}
+		if proxy.Spec.TCPProxy != nil {
+			sw.SetInvalid("Spec.VirtualHost.Authorization: cannot be specified with tcpproxy")
+			return
+		}
+		if err := b.setAuthorizationServer(b.lookupSecureVirtualHost(host), auth, proxy.Namespace); err != nil {
+			sw.SetInvalid(fmt.Sprintf("Spec.VirtualHost.Authorization: %s", err))
+			return
+		}
 	}
 
 	// Set default status
 	sw.SetValid()
 
+	if proxy.Spec.TCPProxy != nil {
+		if !passthrough && !enforceTLS {
+			sw.SetInvalid("tcpproxy: missing tls.passthrough or tls.secretName")
+			return
+		}
+		b.processHTTPProxyTCPProxy(sw, proxy, nil, host)
+
+		// routes on a tcpproxy virtual host are only served
+		// over insecure HTTP, as is the case for IngressRoute.
+		enforceTLS = false
+	}
+
 	// Loop over and process all includes
 	b.processIncludes(sw, proxy, host, nil, enforceTLS, nil)
 
 	// Process any routes
-	switch {
-	//	case ir.Spec.TCPProxy != nil && (passthrough || enforceTLS):
-	//		b.processTCPProxy(ir, nil, host)
-	case proxy.Spec.Routes != nil:
+	if proxy.Spec.Routes != nil {
 		b.processRoutes(sw, proxy, host, nil, enforceTLS)
 	}
+	reportDelegation(sw, delegation)
+	b.reportCertificateWarning(sw, secret)
+	b.reportRateLimitWarning(sw, proxy)
+}
+
+// setAuthorizationServer attaches the authorization service referenced by
+// auth, in namespace, to svhost, returning an error if the service is
+// missing or does not speak gRPC.
+func (b *Builder) setAuthorizationServer(svhost *SecureVirtualHost, auth *projcontour.AuthorizationServer, namespace string) error {
+	if auth.Port < 1 || auth.Port > 65535 {
+		return fmt.Errorf("service %s/%s: port must be in the range 1-65535", namespace, auth.Name)
+	}
+	s := b.lookupService(Meta{name: auth.Name, namespace: namespace}, intstr.FromInt(auth.Port))
+	if s == nil {
+		return fmt.Errorf("service %s/%s/%d: not found", namespace, auth.Name, auth.Port)
+	}
+	if s.Protocol != "h2" && s.Protocol != "h2c" {
+		return fmt.Errorf("service %s/%s/%d: must use the h2 or h2c upstream protocol", namespace, auth.Name, auth.Port)
+	}
 
+	var timeout time.Duration
+	if auth.ResponseTimeout != "" {
+		d, err := time.ParseDuration(auth.ResponseTimeout)
+		if err != nil {
+			return fmt.Errorf("responseTimeout %q: %s", auth.ResponseTimeout, err)
+		}
+		timeout = d
+	}
+
+	svhost.AuthorizationService = &Cluster{
+		Upstream: s,
+	}
+	svhost.AuthorizationFailOpen = auth.FailOpen
+	svhost.AuthorizationResponseTimeout = timeout
+	return nil
 }
 
 // mergeConditions merges any two conditions when they are delegated
@@ -511,6 +883,23 @@ func mergeConditions(delegate, include *projcontour.Condition) *projcontour.Cond
 
 	result := delegate.DeepCopy()
 	result.Prefix = delegate.Prefix + include.Prefix
+	result.HeadersMatch = mergeHeaders(delegate.HeadersMatch, include.HeadersMatch)
+	result.HeadersContain = mergeHeaders(delegate.HeadersContain, include.HeadersContain)
+	return result
+}
+
+// mergeHeaders returns the union of the header values in a and b.
+func mergeHeaders(a, b map[string][]string) map[string][]string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	result := make(map[string][]string, len(a)+len(b))
+	for k, v := range a {
+		result[k] = append(result[k], v...)
+	}
+	for k, v := range b {
+		result[k] = append(result[k], v...)
+	}
 	return result
 }
 
@@ -525,18 +914,21 @@ func (b *Builder) processIncludes(sw *ObjectStatusWriter, proxy *projcontour.HTT
 				return
 			}
 
+			if include.Exact != "" || include.Regex != "" {
+				sw.SetInvalid(fmt.Sprintf("include %s/%s: exact and regex conditions are only valid on routes", include.Namespace, include.Name))
+				return
+			}
+
 			var path []string
 			for _, vproxy := range visited {
 				path = append(path, fmt.Sprintf("%s/%s", vproxy.Namespace, vproxy.Name))
 			}
 
 			// Process any routes
-			switch {
-			//	case ir.Spec.TCPProxy != nil && (passthrough || enforceTLS):
-			//		b.processTCPProxy(ir, nil, host)
-			case delegatedProxy.Spec.Routes != nil:
+			if delegatedProxy.Spec.Routes != nil {
 				sw, commit := sw.WithObject(delegatedProxy)
 				b.processRoutes(sw, delegatedProxy, host, mergeConditions(delegatedCondition, &include.Condition), enforceTLS)
+				b.reportRateLimitWarning(sw, delegatedProxy)
 				commit()
 			}
 
@@ -552,6 +944,7 @@ func (b *Builder) processIncludes(sw *ObjectStatusWriter, proxy *projcontour.HTT
 				}
 				sw, commit := sw.WithObject(delegatedProxy)
 				b.processIncludes(sw, delegatedProxy, host, mergeConditions(delegatedCondition, &include.Condition), enforceTLS, visited)
+				b.reportRateLimitWarning(sw, delegatedProxy)
 				commit()
 			}
 
@@ -592,6 +985,7 @@ func (b *Builder) buildDAG() *DAG {
 		}
 	}
 	dag.statuses = b.statuses
+	dag.recheck = b.recheck
 	return &dag
 }
 
@@ -771,27 +1165,81 @@ func (b *Builder) processIngressRoutes(sw *ObjectStatusWriter, ir *ingressroutev
 }
 
 func (b *Builder) processRoutes(sw *ObjectStatusWriter, proxy *projcontour.HTTPProxy, host string, condition *projcontour.Condition, enforceTLS bool) {
+	tp, err := tracingPolicy(proxy.Spec.TracingPolicy)
+	if err != nil {
+		sw.SetInvalid(fmt.Sprintf("tracingPolicy: %s", err))
+		return
+	}
+
 	for _, route := range proxy.Spec.Routes {
+		routePath, pathMatch := conditionPath(route.Condition, condition)
 
 		// Cannot support multiple services with websockets (See: https://github.com/heptio/contour/issues/732)
 		if len(route.Services) > 1 && route.EnableWebsockets {
-			sw.SetInvalid(fmt.Sprintf("route %q: cannot specify multiple services and enable websockets", conditionPath(route.Condition, condition)))
+			sw.SetInvalid(fmt.Sprintf("route %q: cannot specify multiple services and enable websockets", routePath))
 			return
 		}
 
 		// base case: The route points to services, so we add them to the vhost
 		if len(route.Services) > 0 {
-			routePath := conditionPath(route.Condition, condition)
+			if err := validPathCondition(route.Condition); err != nil {
+				sw.SetInvalid(fmt.Sprintf("route %q: condition: %s", routePath, err))
+				return
+			}
 
-			r := &PrefixRoute{
-				Prefix: routePath,
-				Route: Route{
-					Websocket:     route.EnableWebsockets,
-					HTTPSUpgrade:  routeEnforceTLS(enforceTLS, route.PermitInsecure && !b.DisablePermitInsecure),
-					PrefixRewrite: route.PrefixRewrite,
-					TimeoutPolicy: timeoutPolicy(route.TimeoutPolicy),
-					RetryPolicy:   retryPolicy(route.RetryPolicy),
-				},
+			headers, err := conditionHeaders(route.Condition, condition)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("route %q: %s", routePath, err))
+				return
+			}
+
+			rlp, err := rateLimitPolicy(route.RateLimitPolicy)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("route %q: %s", routePath, err))
+				return
+			}
+
+			reqHP, err := headersPolicy(route.RequestHeadersPolicy)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("route %q: requestHeadersPolicy: %s", routePath, err))
+				return
+			}
+
+			respHP, err := headersPolicy(route.ResponseHeadersPolicy)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("route %q: responseHeadersPolicy: %s", routePath, err))
+				return
+			}
+
+			if hrp := route.HostRewritePolicy; hrp != nil && hrp.Hostname != "" && hrp.Auto {
+				sw.SetInvalid(fmt.Sprintf("route %q: hostRewritePolicy: only one of hostname or auto may be specified", routePath))
+				return
+			}
+
+			authDisabled := route.AuthPolicy != nil && route.AuthPolicy.Disabled
+			if authDisabled && proxy.Spec.VirtualHost == nil {
+				// only the root HTTPProxy, which configures the
+				// authorization service, may disable it.
+				sw.SetInvalid(fmt.Sprintf("route %q: authPolicy: authorization may only be disabled by the root HTTPProxy", routePath))
+				return
+			}
+
+			// routes on a virtual host protected by an authorization
+			// service may not bypass it by permitting insecure requests.
+			permitInsecure := route.PermitInsecure && !b.DisablePermitInsecure && !b.authorized(host)
+
+			r := Route{
+				HeaderConditions:      headers,
+				Websocket:             route.EnableWebsockets,
+				HTTPSUpgrade:          routeEnforceTLS(enforceTLS, permitInsecure),
+				PrefixRewrite:         route.PrefixRewrite,
+				TimeoutPolicy:         timeoutPolicy(route.TimeoutPolicy),
+				RetryPolicy:           retryPolicy(route.RetryPolicy),
+				RateLimitPolicy:       rlp,
+				AuthDisabled:          authDisabled,
+				RequestHeadersPolicy:  reqHP,
+				ResponseHeadersPolicy: respHP,
+				TracingPolicy:         tp,
 			}
 
 			for _, service := range route.Services {
@@ -816,19 +1264,71 @@ func (b *Builder) processRoutes(sw *ObjectStatusWriter, proxy *projcontour.HTTPP
 						sw.SetInvalid(err.Error())
 					}
 				}
+
+				reqHP, err := headersPolicy(service.RequestHeadersPolicy)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: requestHeadersPolicy: %s", routePath, service.Name, err))
+					return
+				}
+
+				respHP, err := headersPolicy(service.ResponseHeadersPolicy)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: responseHeadersPolicy: %s", routePath, service.Name, err))
+					return
+				}
+
+				connectTimeout, err := connectTimeout(service.ConnectTimeout)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: connectTimeout: %s", routePath, service.Name, err))
+					return
+				}
+
 				r.Clusters = append(r.Clusters, &Cluster{
-					Upstream:             s,
-					LoadBalancerStrategy: service.Strategy,
-					Weight:               service.Weight,
-					HealthCheckPolicy:    healthCheckPolicy(service.HealthCheck),
-					UpstreamValidation:   uv,
+					Upstream:              s,
+					LoadBalancerStrategy:  service.Strategy,
+					Weight:                service.Weight,
+					HealthCheckPolicy:     healthCheckPolicy(service.HealthCheck),
+					UpstreamValidation:    uv,
+					RequestHeadersPolicy:  reqHP,
+					ResponseHeadersPolicy: respHP,
+					ConnectTimeout:        connectTimeout,
 				})
 			}
 
-			b.lookupVirtualHost(host).addRoute(r)
-			b.lookupSecureVirtualHost(host).addRoute(r)
+			if hrp := route.HostRewritePolicy; hrp != nil {
+				r.HostRewrite = hrp.Hostname
+				r.AutoHostRewrite = hrp.Auto
+			} else {
+				// upstreams reached by an ExternalName service
+				// generally expect their own name as the Host header.
+				r.AutoHostRewrite = hasExternalName(r.Clusters)
+			}
+
+			v := pathRoute(pathMatch, routePath, r)
+			b.lookupVirtualHost(host).addRoute(v)
+			if enforceTLS {
+				b.lookupSecureVirtualHost(host).addRoute(v)
+			}
+		}
+	}
+}
+
+// hasExternalName returns true if any of the clusters
+// is backed by an ExternalName service.
+func hasExternalName(clusters []*Cluster) bool {
+	for _, c := range clusters {
+		if c.Upstream.ExternalName != "" {
+			return true
 		}
 	}
+	return false
+}
+
+// authorized returns true if the secure virtual host
+// for host is protected by an authorization service.
+func (b *Builder) authorized(host string) bool {
+	svhost, ok := b.securevirtualhosts[host]
+	return ok && svhost.AuthorizationService != nil
 }
 
 func (b *Builder) lookupUpstreamValidation(match string, serviceName string, uv *projcontour.UpstreamValidation, namespace string) (*UpstreamValidation, error) {
@@ -854,6 +1354,68 @@ func (b *Builder) lookupUpstreamValidation(match string, serviceName string, uv
 	}, nil
 }
 
+// lookupDownstreamValidation returns the DownstreamValidation described
+// by dv, returning an error if its CA secret is missing or malformed.
+func (b *Builder) lookupDownstreamValidation(dv *projcontour.DownstreamValidation, namespace string) (*DownstreamValidation, error) {
+	cacert := b.lookupSecret(Meta{name: dv.CACertificate, namespace: namespace}, validCA)
+	if cacert == nil {
+		return nil, fmt.Errorf("CA secret %q not found or is malformed", dv.CACertificate)
+	}
+
+	v := &DownstreamValidation{
+		CACertificate: cacert,
+	}
+	if fcc := dv.ForwardClientCertificate; fcc != nil {
+		v.ForwardClientCertificate = &ClientCertificateDetails{
+			Subject: fcc.Subject,
+			Cert:    fcc.Cert,
+			Chain:   fcc.Chain,
+			DNS:     fcc.DNS,
+			URI:     fcc.URI,
+		}
+	}
+	return v, nil
+}
+
+// lookupFallbackCertificate returns the fallback certificate of the
+// TLS enabled virtual host of proxy, which must neither validate
+// client certificates, nor authorize requests, nor proxy TCP, as
+// clients without SNI are routed by an HTTP filter chain shared by
+// every virtual host which enables the fallback certificate.
+func (b *Builder) lookupFallbackCertificate(proxy *projcontour.HTTPProxy, enforceTLS bool) (*Secret, error) {
+	switch {
+	case !enforceTLS:
+		return nil, errors.New("tls.secretName must be specified")
+	case proxy.Spec.VirtualHost.TLS.ClientValidation != nil:
+		return nil, errors.New("cannot be used with clientValidation")
+	case proxy.Spec.VirtualHost.Authorization != nil:
+		return nil, errors.New("cannot be used with authorization")
+	case tightensTLS(proxy.Spec.VirtualHost.TLS):
+		// the fallback filter chain uses the TLS parameters
+		// of the listener, which would loosen the vhost's.
+		return nil, errors.New("cannot be used with minimumProtocolVersion, maximumProtocolVersion, cipherSuites, or ecdhCurves")
+	case proxy.Spec.TCPProxy != nil:
+		return nil, errors.New("cannot be used with tcpproxy")
+	case b.FallbackCertificate == nil:
+		return nil, errors.New("no fallback certificate is configured")
+	}
+	fallback := Meta{name: b.FallbackCertificate.Name, namespace: b.FallbackCertificate.Namespace}
+	sec := b.lookupSecret(fallback, validSecret)
+	if sec == nil {
+		return nil, fmt.Errorf("fallback certificate secret %q not found or is malformed", b.FallbackCertificate)
+	}
+	return sec, nil
+}
+
+// tightensTLS returns true if tls sets any TLS parameter
+// which would otherwise be taken from the listener.
+func tightensTLS(tls *projcontour.TLS) bool {
+	return tls.MinimumProtocolVersion != "" ||
+		tls.MaximumProtocolVersion != "" ||
+		len(tls.CipherSuites) > 0 ||
+		len(tls.ECDHCurves) > 0
+}
+
 func (b *Builder) processTCPProxy(sw *ObjectStatusWriter, ir *ingressroutev1.IngressRoute, visited []*ingressroutev1.IngressRoute, host string) {
 	visited = append(visited, ir)
 
@@ -920,21 +1482,212 @@ func (b *Builder) processTCPProxy(sw *ObjectStatusWriter, ir *ingressroutev1.Ing
 	sw.SetValid()
 }
 
-func conditionPath(routeCondition, includeCondition *projcontour.Condition) string {
+func (b *Builder) processHTTPProxyTCPProxy(sw *ObjectStatusWriter, httpproxy *projcontour.HTTPProxy, visited []*projcontour.HTTPProxy, host string) {
+	tcpproxy := httpproxy.Spec.TCPProxy
+	visited = append(visited, httpproxy)
+
+	// tcpproxy cannot both include and point to services
+	include := tcpproxy.Include
+	if len(tcpproxy.Services) > 0 && include.Name != "" {
+		sw.SetInvalid("tcpproxy: cannot specify services and include in the same tcpproxy")
+		return
+	}
+
+	if len(tcpproxy.Services) > 0 {
+		var proxy TCPProxy
+		for _, service := range tcpproxy.Services {
+			m := Meta{name: service.Name, namespace: httpproxy.Namespace}
+			s := b.lookupService(m, intstr.FromInt(service.Port))
+			if s == nil {
+				sw.SetInvalid(fmt.Sprintf("tcpproxy: service %s/%s/%d: not found", httpproxy.Namespace, service.Name, service.Port))
+				return
+			}
+			connectTimeout, err := connectTimeout(service.ConnectTimeout)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("tcpproxy: service %s/%s/%d: connectTimeout: %s", httpproxy.Namespace, service.Name, service.Port, err))
+				return
+			}
+			proxy.Clusters = append(proxy.Clusters, &Cluster{
+				Upstream:             s,
+				LoadBalancerStrategy: service.Strategy,
+				Weight:               service.Weight,
+				ConnectTimeout:       connectTimeout,
+			})
+		}
+		b.lookupSecureVirtualHost(host).TCPProxy = &proxy
+		sw.SetValid()
+		return
+	}
+
+	if include.Name == "" {
+		sw.SetInvalid("tcpproxy: either services or include must be specified")
+		return
+	}
+
+	namespace := include.Namespace
+	if namespace == "" {
+		// we are including another HTTPProxy in the same namespace
+		namespace = httpproxy.Namespace
+	}
+
+	dest, ok := b.Source.httpproxies[Meta{name: include.Name, namespace: namespace}]
+	if !ok {
+		sw.SetInvalid(fmt.Sprintf("tcpproxy: include %s/%s not found", namespace, include.Name))
+		return
+	}
+
+	if dest.Spec.VirtualHost != nil {
+		sw.SetInvalid("root httpproxy cannot delegate to another root httpproxy")
+		return
+	}
+
+	if dest.Spec.TCPProxy == nil {
+		sw.SetInvalid(fmt.Sprintf("tcpproxy: include %s/%s does not define a tcpproxy", dest.Namespace, dest.Name))
+		return
+	}
+
+	// dest is not an orphaned httpproxy, as there is an httpproxy that points to it
+	delete(b.orphaned, Meta{name: dest.Name, namespace: dest.Namespace})
+
+	// ensure we are not following an edge that produces a cycle
+	var path []string
+	for _, vproxy := range visited {
+		path = append(path, fmt.Sprintf("%s/%s", vproxy.Namespace, vproxy.Name))
+	}
+	for _, vproxy := range visited {
+		if dest.Name == vproxy.Name && dest.Namespace == vproxy.Namespace {
+			path = append(path, fmt.Sprintf("%s/%s", dest.Namespace, dest.Name))
+			sw.SetInvalid(fmt.Sprintf("tcpproxy include creates a cycle: %s", strings.Join(path, " -> ")))
+			return
+		}
+	}
+
+	// follow the link and process the target httpproxy
+	sw, commit := sw.WithObject(dest)
+	defer commit()
+	b.processHTTPProxyTCPProxy(sw, dest, visited, host)
+}
+
+// Path match types for route conditions.
+const (
+	pathMatchPrefix = "prefix"
+	pathMatchExact  = "exact"
+	pathMatchRegex  = "regex"
+)
+
+// conditionPath returns the path described by the include and route
+// conditions, and whether it is a prefix, exact, or regex match. The
+// include prefix is prepended to the route's prefix, exact path, or
+// regular expression.
+func conditionPath(routeCondition, includeCondition *projcontour.Condition) (string, string) {
 	pathPrefix := ""
 
 	if includeCondition != nil {
 		pathPrefix = includeCondition.Prefix
 	}
 	if routeCondition != nil {
+		switch {
+		case routeCondition.Exact != "":
+			return pathPrefix + routeCondition.Exact, pathMatchExact
+		case routeCondition.Regex != "":
+			return regexp.QuoteMeta(pathPrefix) + routeCondition.Regex, pathMatchRegex
+		}
 		pathPrefix += routeCondition.Prefix
 	}
 
 	if pathPrefix == "" {
-		return "/"
+		return "/", pathMatchPrefix
+	}
+
+	return pathPrefix, pathMatchPrefix
+}
+
+// validPathCondition returns an error if the route condition
+// specifies more than one kind of path match, or an invalid regex.
+func validPathCondition(c *projcontour.Condition) error {
+	if c == nil {
+		return nil
+	}
+	n := 0
+	for _, p := range []string{c.Prefix, c.Exact, c.Regex} {
+		if p != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New("only one of prefix, exact, or regex may be specified")
+	}
+	if c.Regex != "" {
+		if _, err := regexp.Compile(c.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %s", err)
+		}
 	}
+	return nil
+}
 
-	return pathPrefix
+// pathRoute returns a route vertex which matches path as described by match.
+func pathRoute(match, path string, r Route) Vertex {
+	switch match {
+	case pathMatchExact:
+		return &ExactRoute{Path: path, Route: r}
+	case pathMatchRegex:
+		return &RegexRoute{Regex: path, RE2: true, Route: r}
+	default:
+		return &PrefixRoute{Prefix: path, Route: r}
+	}
+}
+
+// conditionHeaders returns the set of header conditions described by the
+// include and route conditions, sorted by header name, match type, and value.
+func conditionHeaders(routeCondition, includeCondition *projcontour.Condition) ([]HeaderCondition, error) {
+	seen := make(map[HeaderCondition]bool)
+	var headers []HeaderCondition
+	add := func(matchType string, conditions map[string][]string) error {
+		for name, values := range conditions {
+			if isBlank(name) {
+				return fmt.Errorf("%s header condition must specify a header name", matchType)
+			}
+			for _, value := range values {
+				hc := HeaderCondition{
+					// header names are case insensitive.
+					Name:      strings.ToLower(name),
+					Value:     value,
+					MatchType: matchType,
+				}
+				if !seen[hc] {
+					seen[hc] = true
+					headers = append(headers, hc)
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, c := range []*projcontour.Condition{includeCondition, routeCondition} {
+		if c == nil {
+			continue
+		}
+		if err := add(HeaderMatchTypeExact, c.HeadersMatch); err != nil {
+			return nil, err
+		}
+		if err := add(HeaderMatchTypeContains, c.HeadersContain); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].String() < headers[j].String()
+	})
+
+	// a header has one value, so exact conditions on
+	// the same header with different values never match.
+	for i := 1; i < len(headers); i++ {
+		a, b := headers[i-1], headers[i]
+		if a.MatchType == HeaderMatchTypeExact && b.MatchType == HeaderMatchTypeExact && a.Name == b.Name {
+			return nil, fmt.Errorf("exact header conditions on %q conflict: %q and %q", a.Name, a.Value, b.Value)
+		}
+	}
+	return headers, nil
 }
 
 func externalName(svc *v1.Service) string {
@@ -979,6 +1732,17 @@ func route(ingress *v1beta1.Ingress, path string) Route {
 	}
 }
 
+// validWildcard returns true if host is a wildcard domain of the
+// form *.example.com. The wildcard must be the entire leftmost label
+// and may not appear anywhere else in the name.
+func validWildcard(host string) bool {
+	if !strings.HasPrefix(host, "*.") {
+		return false
+	}
+	rest := host[len("*."):]
+	return len(rest) > 0 && !strings.Contains(rest, "*")
+}
+
 // isBlank indicates if a string contains nothing but blank characters.
 func isBlank(s string) bool {
 	return len(strings.TrimSpace(s)) == 0
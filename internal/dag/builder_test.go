@@ -25,6 +25,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -51,6 +52,15 @@ func TestDAGInsert(t *testing.T) {
 		Data: secretdata("", ""),
 	}
 
+	fallbackCertificate := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fallback",
+			Namespace: "heptio-contour",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata("fallback certificate", "fallback key"),
+	}
+
 	cert1 := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "ca",
@@ -1892,175 +1902,717 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
-	tests := map[string]struct {
-		objs                  []interface{}
-		disablePermitInsecure bool
-		want                  []Vertex
-	}{
-		"insert ingress w/ default backend w/o matching service": {
-			objs: []interface{}{
-				i1,
-			},
-			want: listeners(),
+	// proxy102 tcp forwards traffic to default/kuard:8080 with TLS termination.
+	proxy102 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-tcp",
+			Namespace: "default",
 		},
-		"insert ingress w/ default backend": {
-			objs: []interface{}{
-				i1,
-				s1,
-			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("*", prefixroute("/", service(s1))),
-					),
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "kuard.example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec1.Name,
 				},
-			),
-		},
-		"insert ingress w/ single unnamed backend w/o matching service": {
-			objs: []interface{}{
-				i2,
 			},
-			want: listeners(),
+			TCPProxy: &projcontour.TCPProxy{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			},
 		},
-		"insert ingress w/ single unnamed backend": {
-			objs: []interface{}{
-				i2,
-				s1,
+	}
+
+	// proxy102a tcp includes marketing/kuard-tcp and passes TLS through.
+	proxy102a := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-tcp",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "kuard.example.com",
+				TLS: &projcontour.TLS{
+					Passthrough: true,
+				},
 			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("*", prefixroute("/", service(s1))),
-					),
+			TCPProxy: &projcontour.TCPProxy{
+				Include: projcontour.Include{
+					Name:      "kuard-tcp",
+					Namespace: "marketing",
 				},
-			),
-		},
-		"insert ingress with missing spec.rule.http key": {
-			objs: []interface{}{
-				i2a,
 			},
-			want: listeners(),
 		},
-		"insert ingress w/ host name and single backend w/o matching service": {
-			objs: []interface{}{
-				i3,
-			},
-			want: listeners(),
+	}
+
+	proxy102b := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-tcp",
+			Namespace: "marketing",
 		},
-		"insert ingress w/ host name and single backend": {
-			objs: []interface{}{
-				i3,
-				s1,
+		Spec: projcontour.HTTPProxySpec{
+			TCPProxy: &projcontour.TCPProxy{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
 			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("kuard.example.com", prefixroute("/", service(s1))),
-					),
-				},
-			),
 		},
-		"insert non matching service then ingress w/ default backend": {
-			objs: []interface{}{
-				s2,
-				i1,
-			},
-			want: listeners(),
+	}
+
+	// proxy103 is a wildcard root serving every subdomain of example.com.
+	proxy103 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard",
+			Namespace: "default",
 		},
-		"insert ingress w/ default backend then matching service with wrong port": {
-			objs: []interface{}{
-				i1,
-				s3,
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "*.example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec1.Name,
+				},
 			},
-			want: listeners(),
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
 		},
-		"insert unnamed ingress w/ single backend then matching service with wrong port": {
-			objs: []interface{}{
-				i2,
-				s3,
-			},
-			want: listeners(),
+	}
+
+	// proxy103a is an exact root which lives alongside the wildcard root.
+	proxy103a := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "www",
+			Namespace: "default",
 		},
-		"insert ingress w/ default backend then matching service w/ named port": {
-			objs: []interface{}{
-				i4,
-				s1,
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "www.example.com",
 			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("*", prefixroute("/", service(s1))),
-					),
-				},
-			),
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
 		},
-		"insert service w/ named port then ingress w/ default backend": {
-			objs: []interface{}{
-				s1,
-				i4,
-			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("*", prefixroute("/", service(s1))),
-					),
-				},
-			),
+	}
+
+	// proxy104 rate limits every request by client address
+	// and the /api route by tenant.
+	proxy104 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ratelimit",
+			Namespace: "default",
 		},
-		"insert ingress w/ single unnamed backend w/ named service port then service": {
-			objs: []interface{}{
-				i5,
-				s1,
-			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("*", prefixroute("/", service(s1))),
-					),
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				RateLimitPolicy: &projcontour.RateLimitPolicy{
+					Descriptors: []projcontour.RateLimitDescriptor{{
+						Entries: []projcontour.RateLimitDescriptorEntry{{
+							RemoteAddress: &projcontour.RemoteAddressDescriptor{},
+						}},
+					}},
 				},
-			),
-		},
-		"insert service then ingress w/ single unnamed backend w/ named service port": {
-			objs: []interface{}{
-				s1,
-				i5,
 			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("*", prefixroute("/", service(s1))),
-					),
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Condition: &projcontour.Condition{
+					Prefix: "/api",
+				},
+				RateLimitPolicy: &projcontour.RateLimitPolicy{
+					Descriptors: []projcontour.RateLimitDescriptor{{
+						Entries: []projcontour.RateLimitDescriptorEntry{{
+							RequestHeader: &projcontour.RequestHeaderDescriptor{
+								HeaderName:    "X-Tenant",
+								DescriptorKey: "tenant",
+							},
+						}},
+					}},
 				},
-			),
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
 		},
-		"insert secret": {
-			objs: []interface{}{
-				sec1,
-			},
-			want: listeners(),
+	}
+
+	// s15 is an ExternalName service
+	s15 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "s3",
+			Namespace: "default",
 		},
-		"insert secret then ingress w/o tls": {
-			objs: []interface{}{
-				sec1,
-				i1,
-			},
-			want: listeners(),
+		Spec: v1.ServiceSpec{
+			Type:         v1.ServiceTypeExternalName,
+			ExternalName: "bucket.s3.amazonaws.com",
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       80,
+				TargetPort: intstr.FromInt(80),
+			}},
 		},
-		"insert service, secret then ingress w/o tls": {
-			objs: []interface{}{
-				s1,
-				sec1,
-				i1,
+	}
+
+	// proxy107 routes to an ExternalName service and
+	// rewrites the Host header to a literal value on /static.
+	proxy107 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "external",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
 			},
-			want: listeners(
-				&Listener{
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "s3",
+					Port: 80,
+				}},
+			}, {
+				Condition: &projcontour.Condition{
+					Prefix: "/static",
+				},
+				HostRewritePolicy: &projcontour.HostRewritePolicy{
+					Hostname: "static.example.com",
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Condition: &projcontour.Condition{
+					Prefix: "/raw",
+				},
+				HostRewritePolicy: &projcontour.HostRewritePolicy{},
+				Services: []projcontour.Service{{
+					Name: "s3",
+					Port: 80,
+				}},
+			}},
+		},
+	}
+
+	// proxy108 includes proxy108a, whose routes
+	// match exact paths and regular expressions.
+	proxy108 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []projcontour.Include{{
+				Name:      "api",
+				Namespace: "default",
+				Condition: projcontour.Condition{
+					Prefix: "/api",
+				},
+			}},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	proxy108a := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Exact: "/healthz",
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Condition: &projcontour.Condition{
+					Regex: "/v[0-9]+/.*",
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy108b has a prefix and a regex condition
+	// for the same path.
+	proxy108b := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/api",
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Condition: &projcontour.Condition{
+					Regex: "/api",
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy106 manages request and response headers
+	// on its route and on the route's service.
+	proxy106 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headers",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				RequestHeadersPolicy: &projcontour.HeadersPolicy{
+					Set: []projcontour.HeaderValue{{
+						Name:  "x-route",
+						Value: "kuard",
+					}},
+				},
+				ResponseHeadersPolicy: &projcontour.HeadersPolicy{
+					Remove: []string{"server"},
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+					RequestHeadersPolicy: &projcontour.HeadersPolicy{
+						Remove: []string{"x-route"},
+					},
+				}},
+			}},
+		},
+	}
+
+	// authsvc is an external authorization service.
+	authsvc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "auth",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"contour.heptio.com/upstream-protocol.h2c": "9000",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "grpc",
+				Protocol: "TCP",
+				Port:     9000,
+			}},
+		},
+	}
+
+	// proxy105 is protected by an authorization service, except
+	// for its /public route.
+	proxy105 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "authorized",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec1.Name,
+				},
+				Authorization: &projcontour.AuthorizationServer{
+					Name:            "auth",
+					Port:            9000,
+					FailOpen:        true,
+					ResponseTimeout: "500ms",
+				},
+			},
+			Routes: []projcontour.Route{{
+				// permitInsecure is ignored on an authorized virtual host.
+				PermitInsecure: true,
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Condition: &projcontour.Condition{
+					Prefix: "/public",
+				},
+				AuthPolicy: &projcontour.AuthorizationPolicy{
+					Disabled: true,
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy109 requires clients to present a certificate signed by
+	// the CA in cert1 and forwards the certificate subject.
+	proxy109 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mtls",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec1.Name,
+					ClientValidation: &projcontour.DownstreamValidation{
+						CACertificate: cert1.Name,
+						ForwardClientCertificate: &projcontour.ClientCertificateDetails{
+							Subject: true,
+						},
+					},
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy110 enables the fallback certificate
+	proxy110 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fallback",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName:                sec1.Name,
+					EnableFallbackCertificate: true,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy101 routes on header conditions and includes a child which
+	// adds its own header conditions.
+	proxy101 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []projcontour.Include{{
+				Name:      "marketingwww",
+				Namespace: "marketing",
+				Condition: projcontour.Condition{
+					Prefix: "/blog",
+					HeadersContain: map[string][]string{
+						"User-Agent": {"Chrome"},
+					},
+				},
+			}},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					HeadersMatch: map[string][]string{
+						"x-canary": {"true"},
+					},
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	proxy101a := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "marketingwww",
+			Namespace: "marketing",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					HeadersMatch: map[string][]string{
+						"x-tenant": {"acme"},
+					},
+				},
+				Services: []projcontour.Service{{
+					Name: "blog",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy101b includes proxy101c on a header condition
+	// which conflicts with the condition of proxy101c's route.
+	proxy101b := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []projcontour.Include{{
+				Name:      "marketingwww",
+				Namespace: "marketing",
+				Condition: projcontour.Condition{
+					Prefix: "/blog",
+					HeadersMatch: map[string][]string{
+						"x-tenant": {"globex"},
+					},
+				},
+			}},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	proxy101c := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "marketingwww",
+			Namespace: "marketing",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					HeadersMatch: map[string][]string{
+						"X-Tenant": {"acme"},
+					},
+				},
+				Services: []projcontour.Service{{
+					Name: "blog",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		objs                  []interface{}
+		disablePermitInsecure bool
+		fallbackCertificate   *types.NamespacedName
+		want                  []Vertex
+	}{
+		"insert ingress w/ default backend w/o matching service": {
+			objs: []interface{}{
+				i1,
+			},
+			want: listeners(),
+		},
+		"insert ingress w/ default backend": {
+			objs: []interface{}{
+				i1,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert ingress w/ single unnamed backend w/o matching service": {
+			objs: []interface{}{
+				i2,
+			},
+			want: listeners(),
+		},
+		"insert ingress w/ single unnamed backend": {
+			objs: []interface{}{
+				i2,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert ingress with missing spec.rule.http key": {
+			objs: []interface{}{
+				i2a,
+			},
+			want: listeners(),
+		},
+		"insert ingress w/ host name and single backend w/o matching service": {
+			objs: []interface{}{
+				i3,
+			},
+			want: listeners(),
+		},
+		"insert ingress w/ host name and single backend": {
+			objs: []interface{}{
+				i3,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("kuard.example.com", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert non matching service then ingress w/ default backend": {
+			objs: []interface{}{
+				s2,
+				i1,
+			},
+			want: listeners(),
+		},
+		"insert ingress w/ default backend then matching service with wrong port": {
+			objs: []interface{}{
+				i1,
+				s3,
+			},
+			want: listeners(),
+		},
+		"insert unnamed ingress w/ single backend then matching service with wrong port": {
+			objs: []interface{}{
+				i2,
+				s3,
+			},
+			want: listeners(),
+		},
+		"insert ingress w/ default backend then matching service w/ named port": {
+			objs: []interface{}{
+				i4,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert service w/ named port then ingress w/ default backend": {
+			objs: []interface{}{
+				s1,
+				i4,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert ingress w/ single unnamed backend w/ named service port then service": {
+			objs: []interface{}{
+				i5,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert service then ingress w/ single unnamed backend w/ named service port": {
+			objs: []interface{}{
+				s1,
+				i5,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert secret": {
+			objs: []interface{}{
+				sec1,
+			},
+			want: listeners(),
+		},
+		"insert secret then ingress w/o tls": {
+			objs: []interface{}{
+				sec1,
+				i1,
+			},
+			want: listeners(),
+		},
+		"insert service, secret then ingress w/o tls": {
+			objs: []interface{}{
+				s1,
+				sec1,
+				i1,
+			},
+			want: listeners(
+				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
 						virtualhost("*", prefixroute("/", service(s1))),
@@ -3270,9 +3822,92 @@ func TestDAGInsert(t *testing.T) {
 						),
 					),
 				},
-			),
+			),
+		},
+		"ingressroute delegated to non existent object": {
+			objs: []interface{}{
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "example-com",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
+						},
+						Routes: []ingressroutev1.Route{{
+							Match: "/finance",
+							Delegate: &ingressroutev1.Delegate{
+								Name:      "non-existent",
+								Namespace: "non-existent",
+							},
+						}},
+					},
+				},
+			},
+			want: nil, // no listener created
+		},
+		"ingressroute delegates to itself": {
+			objs: []interface{}{
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "example-com",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
+						},
+						Routes: []ingressroutev1.Route{{
+							Match: "/finance",
+							Delegate: &ingressroutev1.Delegate{
+								Name:      "example-com",
+								Namespace: "default",
+							},
+						}},
+					},
+				},
+			},
+			want: nil, // no listener created
+		},
+		"ingressroute delegates to incorrect prefix": {
+			objs: []interface{}{
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "default",
+						Name:      "example-com",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
+						},
+						Routes: []ingressroutev1.Route{{
+							Match: "/finance",
+							Delegate: &ingressroutev1.Delegate{
+								Name:      "finance-root",
+								Namespace: "finance",
+							},
+						}},
+					},
+				},
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "finance",
+						Name:      "finance-root",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						Routes: []ingressroutev1.Route{{
+							Match: "/prefixDoesntMatch",
+							Services: []ingressroutev1.Service{{
+								Name: "home",
+							}},
+						}},
+					},
+				},
+			},
+			want: nil, // no listener created
 		},
-		"ingressroute delegated to non existent object": {
+		"ingressroute delegate to prefix, but no matching path in delegate": {
 			objs: []interface{}{
 				&ingressroutev1.IngressRoute{
 					ObjectMeta: metav1.ObjectMeta{
@@ -3284,41 +3919,37 @@ func TestDAGInsert(t *testing.T) {
 							Fqdn: "example.com",
 						},
 						Routes: []ingressroutev1.Route{{
-							Match: "/finance",
+							Match: "/foo",
 							Delegate: &ingressroutev1.Delegate{
-								Name:      "non-existent",
-								Namespace: "non-existent",
+								Name:      "finance-root",
+								Namespace: "finance",
 							},
 						}},
 					},
 				},
-			},
-			want: nil, // no listener created
-		},
-		"ingressroute delegates to itself": {
-			objs: []interface{}{
 				&ingressroutev1.IngressRoute{
 					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "default",
-						Name:      "example-com",
+						Namespace: "finance",
+						Name:      "finance-root",
 					},
 					Spec: ingressroutev1.IngressRouteSpec{
-						VirtualHost: &projcontour.VirtualHost{
-							Fqdn: "example.com",
-						},
 						Routes: []ingressroutev1.Route{{
-							Match: "/finance",
-							Delegate: &ingressroutev1.Delegate{
-								Name:      "example-com",
-								Namespace: "default",
-							},
+							Match: "/foobar",
+							Services: []ingressroutev1.Service{{
+								Name: "home",
+							}},
+						}, {
+							Match: "/foo/bar",
+							Services: []ingressroutev1.Service{{
+								Name: "home",
+							}},
 						}},
 					},
 				},
 			},
 			want: nil, // no listener created
 		},
-		"ingressroute delegates to incorrect prefix": {
+		"ingressroute cycle": {
 			objs: []interface{}{
 				&ingressroutev1.IngressRoute{
 					ObjectMeta: metav1.ObjectMeta{
@@ -3345,261 +3976,528 @@ func TestDAGInsert(t *testing.T) {
 					},
 					Spec: ingressroutev1.IngressRouteSpec{
 						Routes: []ingressroutev1.Route{{
-							Match: "/prefixDoesntMatch",
+							Match: "/finance",
 							Services: []ingressroutev1.Service{{
 								Name: "home",
+								Port: 8080,
 							}},
+						}, {
+							Match: "/finance/stocks",
+							Delegate: &ingressroutev1.Delegate{
+								Name:      "example-com",
+								Namespace: "default",
+							},
 						}},
 					},
 				},
+				s7,
 			},
-			want: nil, // no listener created
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", prefixroute("/finance", service(s7))),
+					),
+				},
+			),
 		},
-		"ingressroute delegate to prefix, but no matching path in delegate": {
+		"ingressroute root delegates to another ingressroute root": {
 			objs: []interface{}{
 				&ingressroutev1.IngressRoute{
 					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "default",
-						Name:      "example-com",
+						Name:      "root-blog",
+						Namespace: "roots",
 					},
 					Spec: ingressroutev1.IngressRouteSpec{
 						VirtualHost: &projcontour.VirtualHost{
-							Fqdn: "example.com",
+							Fqdn: "blog.containersteve.com",
 						},
 						Routes: []ingressroutev1.Route{{
-							Match: "/foo",
+							Match: "/",
 							Delegate: &ingressroutev1.Delegate{
-								Name:      "finance-root",
-								Namespace: "finance",
+								Name:      "blog",
+								Namespace: "marketing",
 							},
 						}},
 					},
 				},
 				&ingressroutev1.IngressRoute{
 					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "finance",
-						Name:      "finance-root",
+						Name:      "blog",
+						Namespace: "marketing",
 					},
 					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.containersteve.com",
+						},
 						Routes: []ingressroutev1.Route{{
-							Match: "/foobar",
+							Match: "/",
 							Services: []ingressroutev1.Service{{
-								Name: "home",
+								Name: "green",
+								Port: 80,
 							}},
-						}, {
-							Match: "/foo/bar",
+						}},
+					},
+				},
+				s8,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("www.containersteve.com", prefixroute("/", service(s8))),
+					),
+				},
+			),
+		},
+		// issue 1399
+		"service shared across ingress and ingressroute tcpproxy": {
+			objs: []interface{}{
+				sec1,
+				s9,
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nginx",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							Hosts:      []string{"example.com"},
+							SecretName: s1.Name,
+						}},
+						Rules: []v1beta1.IngressRule{{
+							Host:             "example.com",
+							IngressRuleValue: ingressrulevalue(backend(s9.Name, intstr.FromInt(80))),
+						}},
+					},
+				},
+				&ingressroutev1.IngressRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "nginx",
+						Namespace: "default",
+					},
+					Spec: ingressroutev1.IngressRouteSpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
+							TLS: &projcontour.TLS{
+								SecretName: sec1.Name,
+							},
+						},
+						TCPProxy: &ingressroutev1.TCPProxy{
 							Services: []ingressroutev1.Service{{
-								Name: "home",
+								Name: s9.Name,
+								Port: 80,
 							}},
-						}},
+						},
 					},
 				},
-			},
-			want: nil, // no listener created
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", prefixroute("/", service(s9))),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "example.com",
+							},
+							MinProtoVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+							Secret:          secret(sec1),
+							TCPProxy: &TCPProxy{
+								Clusters: clusters(service(s9)),
+							},
+						},
+					),
+				},
+			),
+		},
+		"insert httproxy": {
+			objs: []interface{}{
+				proxy1, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert httpproxy w/ healthcheck": {
+			objs: []interface{}{
+				proxy1e, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: service(s1),
+								HealthCheckPolicy: &HealthCheckPolicy{
+									Path: "/healthz",
+								},
+							}),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy with websocket route": {
+			objs: []interface{}{
+				proxy11, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							prefixroute("/", service(s1)),
+							routeRewrite("/websocket", "/", service(s1)),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy with prefix rewrite route": {
+			objs: []interface{}{
+				proxy10, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							prefixroute("/", service(s1)),
+							routeWebsocket("/websocket", service(s1)),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy with multiple upstreams prefix rewrite route, websocket routes are dropped": {
+			objs: []interface{}{
+				proxy10b, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							prefixroute("/", service(s1)),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy and service": {
+			objs: []interface{}{
+				proxy1, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", prefixroute("/", service(s1))),
+					),
+				},
+			),
 		},
-		"ingressroute cycle": {
+		"insert httpproxy without tls version": {
 			objs: []interface{}{
-				&ingressroutev1.IngressRoute{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "default",
-						Name:      "example-com",
-					},
-					Spec: ingressroutev1.IngressRouteSpec{
-						VirtualHost: &projcontour.VirtualHost{
-							Fqdn: "example.com",
-						},
-						Routes: []ingressroutev1.Route{{
-							Match: "/finance",
-							Delegate: &ingressroutev1.Delegate{
-								Name:      "finance-root",
-								Namespace: "finance",
-							},
-						}},
-					},
-				},
-				&ingressroutev1.IngressRoute{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: "finance",
-						Name:      "finance-root",
-					},
-					Spec: ingressroutev1.IngressRouteSpec{
-						Routes: []ingressroutev1.Route{{
-							Match: "/finance",
-							Services: []ingressroutev1.Service{{
-								Name: "home",
-								Port: 8080,
-							}},
-						}, {
-							Match: "/finance/stocks",
-							Delegate: &ingressroutev1.Delegate{
-								Name:      "example-com",
-								Namespace: "default",
-							},
-						}},
-					},
-				},
-				s7,
+				proxy6, s1, sec1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com", prefixroute("/finance", service(s7))),
+						virtualhost("foo.com", routeUpgrade("/", service(s1))),
+					),
+				}, &Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("foo.com", sec1, routeUpgrade("/", service(s1))),
 					),
 				},
 			),
 		},
-		"ingressroute root delegates to another ingressroute root": {
+		"insert httpproxy expecting verification": {
 			objs: []interface{}{
-				&ingressroutev1.IngressRoute{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "root-blog",
-						Namespace: "roots",
-					},
-					Spec: ingressroutev1.IngressRouteSpec{
-						VirtualHost: &projcontour.VirtualHost{
-							Fqdn: "blog.containersteve.com",
-						},
-						Routes: []ingressroutev1.Route{{
-							Match: "/",
-							Delegate: &ingressroutev1.Delegate{
-								Name:      "blog",
-								Namespace: "marketing",
-							},
-						}},
-					},
-				},
-				&ingressroutev1.IngressRoute{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "blog",
-						Namespace: "marketing",
-					},
-					Spec: ingressroutev1.IngressRouteSpec{
-						VirtualHost: &projcontour.VirtualHost{
-							Fqdn: "www.containersteve.com",
-						},
-						Routes: []ingressroutev1.Route{{
-							Match: "/",
-							Services: []ingressroutev1.Service{{
-								Name: "green",
-								Port: 80,
-							}},
-						}},
-					},
+				cert1, proxy17, s1a,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s1a.Name,
+										Namespace:   s1a.Namespace,
+										ServicePort: &s1a.Spec.Ports[0],
+										Protocol:    "tls",
+									},
+									UpstreamValidation: &UpstreamValidation{
+										CACertificate: secret(cert1),
+										SubjectName:   "example.com",
+									},
+								},
+							),
+						),
+					),
 				},
-				s8,
+			),
+		},
+		"insert httpproxy with pathPrefix include": {
+			objs: []interface{}{
+				proxy100, proxy100a, s1, s4,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("www.containersteve.com", prefixroute("/", service(s8))),
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s1.Name,
+										Namespace:   s1.Namespace,
+										ServicePort: &s1.Spec.Ports[0],
+									},
+								},
+							),
+							routeCluster("/blog",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s4.Name,
+										Namespace:   s4.Namespace,
+										ServicePort: &s4.Spec.Ports[0],
+									},
+								},
+							),
+						),
 					),
 				},
 			),
 		},
-		// issue 1399
-		"service shared across ingress and ingressroute tcpproxy": {
+		"insert httpproxy with pathPrefix include, child adds to pathPrefix": {
 			objs: []interface{}{
-				sec1,
-				s9,
-				&v1beta1.Ingress{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "nginx",
-						Namespace: "default",
-					},
-					Spec: v1beta1.IngressSpec{
-						TLS: []v1beta1.IngressTLS{{
-							Hosts:      []string{"example.com"},
-							SecretName: s1.Name,
-						}},
-						Rules: []v1beta1.IngressRule{{
-							Host:             "example.com",
-							IngressRuleValue: ingressrulevalue(backend(s9.Name, intstr.FromInt(80))),
-						}},
-					},
+				proxy100, proxy100b, s1, s4,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s1.Name,
+										Namespace:   s1.Namespace,
+										ServicePort: &s1.Spec.Ports[0],
+									},
+								},
+							),
+							routeCluster("/blog/infotech",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s4.Name,
+										Namespace:   s4.Namespace,
+										ServicePort: &s4.Spec.Ports[0],
+									},
+								},
+							),
+						),
+					),
 				},
-				&ingressroutev1.IngressRoute{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "nginx",
-						Namespace: "default",
-					},
-					Spec: ingressroutev1.IngressRouteSpec{
-						VirtualHost: &projcontour.VirtualHost{
-							Fqdn: "example.com",
-							TLS: &projcontour.TLS{
-								SecretName: sec1.Name,
-							},
-						},
-						TCPProxy: &ingressroutev1.TCPProxy{
-							Services: []ingressroutev1.Service{{
-								Name: s9.Name,
-								Port: 80,
-							}},
-						},
-					},
+			),
+		},
+		"insert httpproxy with pathPrefix include, child adds to pathPrefix, delegates again": {
+			objs: []interface{}{
+				proxy100, proxy100c, proxy100d, s1, s4, s11,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s1.Name,
+										Namespace:   s1.Namespace,
+										ServicePort: &s1.Spec.Ports[0],
+									},
+								},
+							),
+							routeCluster("/blog/infotech",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s4.Name,
+										Namespace:   s4.Namespace,
+										ServicePort: &s4.Spec.Ports[0],
+									},
+								},
+							),
+							routeCluster("/blog",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s4.Name,
+										Namespace:   s4.Namespace,
+										ServicePort: &s4.Spec.Ports[0],
+									},
+								},
+							),
+							routeCluster("/blog/it/foo",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s11.Name,
+										Namespace:   s11.Namespace,
+										ServicePort: &s11.Spec.Ports[0],
+									},
+								},
+							),
+						),
+					),
 				},
+			),
+		},
+		"insert httpproxy with tcp forward with TLS termination": {
+			objs: []interface{}{
+				proxy102, s1, sec1,
 			},
 			want: listeners(
 				&Listener{
-					Port: 80,
+					Port: 443,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com", prefixroute("/", service(s9))),
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "kuard.example.com",
+							},
+							TCPProxy: &TCPProxy{
+								Clusters: clusters(
+									service(s1),
+								),
+							},
+							Secret:          secret(sec1),
+							MinProtoVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+						},
 					),
 				},
+			),
+		},
+		"insert root httpproxy and included httpproxy for a tcp proxy": {
+			objs: []interface{}{
+				proxy102a, proxy102b, s6,
+			},
+			want: listeners(
 				&Listener{
 					Port: 443,
 					VirtualHosts: virtualhosts(
 						&SecureVirtualHost{
 							VirtualHost: VirtualHost{
-								Name: "example.com",
+								Name: "kuard.example.com",
 							},
-							MinProtoVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
-							Secret:          secret(sec1),
 							TCPProxy: &TCPProxy{
-								Clusters: clusters(service(s9)),
+								Clusters: clusters(
+									service(s6),
+								),
 							},
 						},
 					),
 				},
 			),
 		},
-		"insert httproxy": {
+		"insert wildcard httpproxy": {
 			objs: []interface{}{
-				proxy1, s1,
+				proxy103, s1, sec1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com", prefixroute("/", service(s1))),
+						virtualhost("*.example.com", routeUpgrade("/", service(s1))),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("*.example.com", sec1, routeUpgrade("/", service(s1))),
 					),
 				},
 			),
 		},
-		"insert httpproxy w/ healthcheck": {
+		"insert wildcard httpproxy and exact httpproxy": {
 			objs: []interface{}{
-				proxy1e, s1,
+				proxy103, proxy103a, s1, sec1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com",
-							routeCluster("/", &Cluster{
-								Upstream: service(s1),
-								HealthCheckPolicy: &HealthCheckPolicy{
-									Path: "/healthz",
+						virtualhost("*.example.com", routeUpgrade("/", service(s1))),
+						virtualhost("www.example.com", prefixroute("/", service(s1))),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("*.example.com", sec1, routeUpgrade("/", service(s1))),
+					),
+				},
+			),
+		},
+		"insert httpproxy with rate limit policies": {
+			objs: []interface{}{
+				proxy104, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						&VirtualHost{
+							Name: "example.com",
+							RateLimitPolicy: &RateLimitPolicy{
+								Descriptors: []*RateLimitDescriptor{{
+									Entries: []RateLimitDescriptorEntry{{
+										RemoteAddress: &RemoteAddressDescriptorEntry{},
+									}},
+								}},
+							},
+							routes: routes(
+								prefixroute("/", service(s1)),
+								&PrefixRoute{
+									Prefix: "/api",
+									Route: Route{
+										Clusters: clusters(service(s1)),
+										RateLimitPolicy: &RateLimitPolicy{
+											Descriptors: []*RateLimitDescriptor{{
+												Entries: []RateLimitDescriptorEntry{{
+													RequestHeader: &RequestHeaderDescriptorEntry{
+														HeaderName: "X-Tenant",
+														Key:        "tenant",
+													},
+												}},
+											}},
+										},
+									},
 								},
-							}),
-						),
+							),
+						},
 					),
 				},
 			),
 		},
-		"insert httpproxy with websocket route": {
+		"insert httpproxy with exact and regex conditions": {
 			objs: []interface{}{
-				proxy11, s1,
+				proxy108, proxy108a, s1,
 			},
 			want: listeners(
 				&Listener{
@@ -3607,222 +4505,331 @@ func TestDAGInsert(t *testing.T) {
 					VirtualHosts: virtualhosts(
 						virtualhost("example.com",
 							prefixroute("/", service(s1)),
-							routeRewrite("/websocket", "/", service(s1)),
+							&ExactRoute{
+								Path: "/api/healthz",
+								Route: Route{
+									Clusters: clustermap(s1),
+								},
+							},
+							&RegexRoute{
+								Regex: "/api/v[0-9]+/.*",
+								RE2:   true,
+								Route: Route{
+									Clusters: clustermap(s1),
+								},
+							},
 						),
 					),
 				},
 			),
 		},
-		"insert httpproxy with prefix rewrite route": {
+		"insert httpproxy with prefix and regex conditions for the same path": {
 			objs: []interface{}{
-				proxy10, s1,
+				proxy108b, s1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
 						virtualhost("example.com",
-							prefixroute("/", service(s1)),
-							routeWebsocket("/websocket", service(s1)),
+							prefixroute("/api", service(s1)),
+							&RegexRoute{
+								Regex: "/api",
+								RE2:   true,
+								Route: Route{
+									Clusters: clustermap(s1),
+								},
+							},
 						),
 					),
 				},
 			),
 		},
-		"insert httpproxy with multiple upstreams prefix rewrite route, websocket routes are dropped": {
+		"insert httpproxy with host rewrite": {
 			objs: []interface{}{
-				proxy10b, s1,
+				proxy107, s1, s15,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
 						virtualhost("example.com",
-							prefixroute("/", service(s1)),
+							&PrefixRoute{
+								Prefix: "/",
+								Route: Route{
+									Clusters: []*Cluster{{
+										Upstream: &Service{
+											Name:         s15.Name,
+											Namespace:    s15.Namespace,
+											ServicePort:  &s15.Spec.Ports[0],
+											ExternalName: "bucket.s3.amazonaws.com",
+										},
+									}},
+									AutoHostRewrite: true,
+								},
+							},
+							&PrefixRoute{
+								Prefix: "/static",
+								Route: Route{
+									Clusters:    clustermap(s1),
+									HostRewrite: "static.example.com",
+								},
+							},
+							&PrefixRoute{
+								Prefix: "/raw",
+								Route: Route{
+									Clusters: []*Cluster{{
+										Upstream: &Service{
+											Name:         s15.Name,
+											Namespace:    s15.Namespace,
+											ServicePort:  &s15.Spec.Ports[0],
+											ExternalName: "bucket.s3.amazonaws.com",
+										},
+									}},
+								},
+							},
 						),
 					),
 				},
 			),
 		},
-		"insert httpproxy and service": {
+		"insert httpproxy with headers policies": {
 			objs: []interface{}{
-				proxy1, s1,
+				proxy106, s1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com", prefixroute("/", service(s1))),
+						virtualhost("example.com", &PrefixRoute{
+							Prefix: "/",
+							Route: Route{
+								Clusters: []*Cluster{{
+									Upstream: service(s1),
+									RequestHeadersPolicy: &HeadersPolicy{
+										Remove: []string{"X-Route"},
+									},
+								}},
+								RequestHeadersPolicy: &HeadersPolicy{
+									Set: map[string]string{
+										"X-Route": "kuard",
+									},
+								},
+								ResponseHeadersPolicy: &HeadersPolicy{
+									Remove: []string{"Server"},
+								},
+							},
+						}),
 					),
 				},
 			),
 		},
-		"insert httpproxy without tls version": {
+		"insert httpproxy with authorization": {
 			objs: []interface{}{
-				proxy6, s1, sec1,
+				proxy105, s1, authsvc, sec1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("foo.com", routeUpgrade("/", service(s1))),
+						virtualhost("example.com",
+							routeUpgrade("/", service(s1)),
+							&PrefixRoute{
+								Prefix: "/public",
+								Route: Route{
+									Clusters:     clusters(service(s1)),
+									HTTPSUpgrade: true,
+									AuthDisabled: true,
+								},
+							},
+						),
 					),
-				}, &Listener{
+				},
+				&Listener{
 					Port: 443,
 					VirtualHosts: virtualhosts(
-						securevirtualhost("foo.com", sec1, routeUpgrade("/", service(s1))),
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "example.com",
+								routes: routes(
+									routeUpgrade("/", service(s1)),
+									&PrefixRoute{
+										Prefix: "/public",
+										Route: Route{
+											Clusters:     clusters(service(s1)),
+											HTTPSUpgrade: true,
+											AuthDisabled: true,
+										},
+									},
+								),
+							},
+							MinProtoVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+							Secret:          secret(sec1),
+							AuthorizationService: &Cluster{
+								Upstream: &Service{
+									Name:        authsvc.Name,
+									Namespace:   authsvc.Namespace,
+									ServicePort: &authsvc.Spec.Ports[0],
+									Protocol:    "h2c",
+								},
+							},
+							AuthorizationFailOpen:        true,
+							AuthorizationResponseTimeout: 500 * time.Millisecond,
+						},
 					),
 				},
 			),
 		},
-		"insert httpproxy expecting verification": {
+		"insert httpproxy with client validation": {
 			objs: []interface{}{
-				cert1, proxy17, s1a,
+				proxy109, s1, sec1, cert1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com",
-							routeCluster("/",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s1a.Name,
-										Namespace:   s1a.Namespace,
-										ServicePort: &s1a.Spec.Ports[0],
-										Protocol:    "tls",
-									},
-									UpstreamValidation: &UpstreamValidation{
-										CACertificate: secret(cert1),
-										SubjectName:   "example.com",
-									},
+						virtualhost("example.com", routeUpgrade("/", service(s1))),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name:   "example.com",
+								routes: routes(routeUpgrade("/", service(s1))),
+							},
+							MinProtoVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+							Secret:          secret(sec1),
+							DownstreamValidation: &DownstreamValidation{
+								CACertificate: secret(cert1),
+								ForwardClientCertificate: &ClientCertificateDetails{
+									Subject: true,
 								},
-							),
-						),
+							},
+						},
 					),
 				},
 			),
 		},
-		"insert httpproxy with pathPrefix include": {
+		"insert httpproxy with fallback certificate": {
 			objs: []interface{}{
-				proxy100, proxy100a, s1, s4,
+				proxy110, s1, sec1, fallbackCertificate,
+			},
+			fallbackCertificate: &types.NamespacedName{
+				Name:      fallbackCertificate.Name,
+				Namespace: fallbackCertificate.Namespace,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
-						virtualhost("example.com",
-							routeCluster("/",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s1.Name,
-										Namespace:   s1.Namespace,
-										ServicePort: &s1.Spec.Ports[0],
-									},
-								},
-							),
-							routeCluster("/blog",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s4.Name,
-										Namespace:   s4.Namespace,
-										ServicePort: &s4.Spec.Ports[0],
-									},
-								},
-							),
-						),
+						virtualhost("example.com", routeUpgrade("/", service(s1))),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name:   "example.com",
+								routes: routes(routeUpgrade("/", service(s1))),
+							},
+							MinProtoVersion:     envoy_api_v2_auth.TlsParameters_TLSv1_1,
+							Secret:              secret(sec1),
+							FallbackCertificate: secret(fallbackCertificate),
+						},
 					),
 				},
 			),
 		},
-		"insert httpproxy with pathPrefix include, child adds to pathPrefix": {
+		"insert httpproxy with header conditions": {
 			objs: []interface{}{
-				proxy100, proxy100b, s1, s4,
+				proxy101, s1,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
 						virtualhost("example.com",
-							routeCluster("/",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s1.Name,
-										Namespace:   s1.Namespace,
-										ServicePort: &s1.Spec.Ports[0],
-									},
-								},
-							),
-							routeCluster("/blog/infotech",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s4.Name,
-										Namespace:   s4.Namespace,
-										ServicePort: &s4.Spec.Ports[0],
-									},
+							prefixroute("/", service(s1)),
+							&PrefixRoute{
+								Prefix: "/",
+								Route: Route{
+									Clusters: clustermap(s1),
+									HeaderConditions: []HeaderCondition{{
+										Name:      "x-canary",
+										Value:     "true",
+										MatchType: HeaderMatchTypeExact,
+									}},
 								},
-							),
+							},
 						),
 					),
 				},
 			),
 		},
-		"insert httpproxy with pathPrefix include, child adds to pathPrefix, delegates again": {
+		"insert httpproxy with header conditions, include merges header conditions": {
 			objs: []interface{}{
-				proxy100, proxy100c, proxy100d, s1, s4, s11,
+				proxy101, proxy101a, s1, s4,
 			},
 			want: listeners(
 				&Listener{
 					Port: 80,
 					VirtualHosts: virtualhosts(
 						virtualhost("example.com",
-							routeCluster("/",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s1.Name,
-										Namespace:   s1.Namespace,
-										ServicePort: &s1.Spec.Ports[0],
-									},
-								},
-							),
-							routeCluster("/blog/infotech",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s4.Name,
-										Namespace:   s4.Namespace,
-										ServicePort: &s4.Spec.Ports[0],
-									},
-								},
-							),
-							routeCluster("/blog",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s4.Name,
-										Namespace:   s4.Namespace,
-										ServicePort: &s4.Spec.Ports[0],
-									},
+							prefixroute("/", service(s1)),
+							&PrefixRoute{
+								Prefix: "/",
+								Route: Route{
+									Clusters: clustermap(s1),
+									HeaderConditions: []HeaderCondition{{
+										Name:      "x-canary",
+										Value:     "true",
+										MatchType: HeaderMatchTypeExact,
+									}},
 								},
-							),
-							routeCluster("/blog/it/foo",
-								&Cluster{
-									Upstream: &Service{
-										Name:        s11.Name,
-										Namespace:   s11.Namespace,
-										ServicePort: &s11.Spec.Ports[0],
-									},
+							},
+							&PrefixRoute{
+								Prefix: "/blog",
+								Route: Route{
+									Clusters: clustermap(s4),
+									HeaderConditions: []HeaderCondition{{
+										Name:      "user-agent",
+										Value:     "Chrome",
+										MatchType: HeaderMatchTypeContains,
+									}, {
+										Name:      "x-tenant",
+										Value:     "acme",
+										MatchType: HeaderMatchTypeExact,
+									}},
 								},
-							),
+							},
 						),
 					),
 				},
 			),
 		},
+		"insert httpproxy with header conditions, include with conflicting exact header conditions": {
+			objs: []interface{}{
+				proxy101b, proxy101c, s1, s4,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			builder := Builder{
 				DisablePermitInsecure: tc.disablePermitInsecure,
+				FallbackCertificate:   tc.fallbackCertificate,
 				Source: KubernetesCache{
 					FieldLogger: testLogger(t),
 				},
@@ -4412,9 +5419,11 @@ func routes(v ...Vertex) map[string]Vertex {
 	for _, r := range v {
 		switch r := r.(type) {
 		case *PrefixRoute:
-			m[r.Prefix] = r
+			m[r.Prefix+headerConditionsKey(r.HeaderConditions)] = r
 		case *RegexRoute:
-			m[r.Regex] = r
+			m["~"+r.Regex+headerConditionsKey(r.HeaderConditions)] = r
+		case *ExactRoute:
+			m["="+r.Path+headerConditionsKey(r.HeaderConditions)] = r
 		default:
 			panic(fmt.Sprintf("unexpected route type: %T %#v", r, r))
 		}
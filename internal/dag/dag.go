@@ -17,6 +17,8 @@ package dag
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
@@ -32,6 +34,10 @@ type DAG struct {
 
 	// status computed while building this dag.
 	statuses map[Meta]Status
+
+	// recheck is the time at which the validity
+	// of a certificate in this dag changes.
+	recheck time.Time
 }
 
 // Visit calls fn on each root of this DAG.
@@ -47,6 +53,13 @@ func (d *DAG) Statuses() map[Meta]Status {
 	return d.statuses
 }
 
+// Recheck returns the time at which a TLS certificate used by this
+// DAG expires or becomes valid, changing the status of the objects
+// which use it, or the zero time if there is no such certificate.
+func (d *DAG) Recheck() time.Time {
+	return d.recheck
+}
+
 // PrefixRoute defines a Route that matches a path prefix.
 type PrefixRoute struct {
 
@@ -60,13 +73,59 @@ type RegexRoute struct {
 
 	// Regex to match.
 	Regex string
+
+	// RE2 is true if Regex is matched with the RE2 engine rather
+	// than std::regex. Only HTTPProxy regex conditions, which the
+	// builder validates, are matched with RE2.
+	RE2 bool
 	Route
 }
 
+// ExactRoute defines a Route that matches a path exactly.
+type ExactRoute struct {
+
+	// Path to match.
+	Path string
+	Route
+}
+
+// HeaderCondition defines a condition that must be satisfied by
+// a request header for a Route to match.
+type HeaderCondition struct {
+	// Name is the name of the header to match.
+	Name string
+
+	// Value is the value the header is matched against.
+	Value string
+
+	// MatchType is the type of match to perform, either
+	// HeaderMatchTypeExact or HeaderMatchTypeContains.
+	MatchType string
+}
+
+const (
+	// HeaderMatchTypeExact matches a header whose value is
+	// equal to the condition's value.
+	HeaderMatchTypeExact = "exact"
+
+	// HeaderMatchTypeContains matches a header whose value
+	// contains the condition's value.
+	HeaderMatchTypeContains = "contains"
+)
+
+// String returns a string representation of the HeaderCondition.
+func (hc HeaderCondition) String() string {
+	return hc.Name + " " + hc.MatchType + " " + hc.Value
+}
+
 // Route defines the properties of a route to a Cluster.
 type Route struct {
 	Clusters []*Cluster
 
+	// HeaderConditions is the set of header conditions that
+	// must all be satisfied for this route to match.
+	HeaderConditions []HeaderCondition
+
 	// Should this route generate a 301 upgrade if accessed
 	// over HTTP?
 	HTTPSUpgrade bool
@@ -83,6 +142,39 @@ type Route struct {
 
 	// Indicates that during forwarding, the matched prefix (or path) should be swapped with this value
 	PrefixRewrite string
+
+	// RateLimitPolicy defines the rate limit descriptors for this route.
+	RateLimitPolicy *RateLimitPolicy
+
+	// AuthDisabled disables external authorization for this route.
+	AuthDisabled bool
+
+	// RequestHeadersPolicy defines how headers are managed during forwarding.
+	RequestHeadersPolicy *HeadersPolicy
+
+	// ResponseHeadersPolicy defines how headers are managed during forwarding.
+	ResponseHeadersPolicy *HeadersPolicy
+
+	// HostRewrite is the literal value to which the Host header
+	// is rewritten during forwarding.
+	HostRewrite string
+
+	// AutoHostRewrite rewrites the Host header to the DNS name
+	// of the upstream host. Mutually exclusive with HostRewrite.
+	AutoHostRewrite bool
+
+	// TracingPolicy overrides the tracing sampling rate of this route.
+	TracingPolicy *TracingPolicy
+}
+
+// HeadersPolicy defines how headers are managed during forwarding.
+type HeadersPolicy struct {
+	// Set is a map of header names to the values which
+	// replace any existing value of that header.
+	Set map[string]string
+
+	// Remove is a list of the names of headers to remove.
+	Remove []string
 }
 
 // TimeoutPolicy defines the timeout request/idle
@@ -94,6 +186,13 @@ type TimeoutPolicy struct {
 	Timeout time.Duration
 }
 
+// TracingPolicy defines the tracing settings of a route.
+type TracingPolicy struct {
+	// Sampling is the percentage, from 0 to 100, of
+	// requests which are traced.
+	Sampling float64
+}
+
 // RetryPolicy defines the retry / number / timeout options
 type RetryPolicy struct {
 	// RetryOn specifies the conditions under which retry takes place.
@@ -109,6 +208,54 @@ type RetryPolicy struct {
 	PerTryTimeout time.Duration
 }
 
+// RateLimitPolicy defines the descriptors sent to the
+// rate limit service for each request.
+type RateLimitPolicy struct {
+	Descriptors []*RateLimitDescriptor
+}
+
+// CORSPolicy defines the cross-origin requests permitted
+// by a virtual host.
+type CORSPolicy struct {
+	AllowOrigin      []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	MaxAge           time.Duration
+	AllowCredentials bool
+}
+
+// RateLimitDescriptor is a list of entries which together
+// form a single descriptor.
+type RateLimitDescriptor struct {
+	Entries []RateLimitDescriptorEntry
+}
+
+// RateLimitDescriptorEntry is a key-value pair generator.
+// Exactly one field is non-nil.
+type RateLimitDescriptorEntry struct {
+	GenericKey    *GenericKeyDescriptorEntry
+	RequestHeader *RequestHeaderDescriptorEntry
+	RemoteAddress *RemoteAddressDescriptorEntry
+}
+
+// GenericKeyDescriptorEntry generates a descriptor
+// entry with a static value.
+type GenericKeyDescriptorEntry struct {
+	Value string
+}
+
+// RequestHeaderDescriptorEntry generates a descriptor entry
+// from the value of a request header.
+type RequestHeaderDescriptorEntry struct {
+	HeaderName string
+	Key        string
+}
+
+// RemoteAddressDescriptorEntry generates a descriptor
+// entry from the client's address.
+type RemoteAddressDescriptorEntry struct{}
+
 // UpstreamValidation defines how to validate the certificate on the upstream service
 type UpstreamValidation struct {
 	// CACertificate holds a reference to the Secret containing the CA to be used to
@@ -119,6 +266,28 @@ type UpstreamValidation struct {
 	SubjectName string
 }
 
+// DownstreamValidation holds CA certificates used to validate
+// the certificates presented by clients.
+type DownstreamValidation struct {
+	// CACertificate holds a reference to the Secret containing
+	// the CA certificates which must have signed the client certificate.
+	CACertificate *Secret
+
+	// ForwardClientCertificate, if set, selects the details of the
+	// client certificate forwarded in the x-forwarded-client-cert header.
+	ForwardClientCertificate *ClientCertificateDetails
+}
+
+// ClientCertificateDetails selects the details of a client certificate
+// forwarded in the x-forwarded-client-cert header.
+type ClientCertificateDetails struct {
+	Subject bool
+	Cert    bool
+	Chain   bool
+	DNS     bool
+	URI     bool
+}
+
 func (r *Route) Visit(f func(Vertex)) {
 	for _, c := range r.Clusters {
 		f(c)
@@ -131,6 +300,14 @@ type VirtualHost struct {
 	// as defined by RFC 3986.
 	Name string
 
+	// RateLimitPolicy defines the rate limit descriptors
+	// for every route on this virtual host.
+	RateLimitPolicy *RateLimitPolicy
+
+	// CORSPolicy defines the cross-origin requests
+	// permitted by this virtual host.
+	CORSPolicy *CORSPolicy
+
 	routes map[string]Vertex
 }
 
@@ -140,14 +317,35 @@ func (v *VirtualHost) addRoute(route Vertex) {
 	}
 	switch r := route.(type) {
 	case *PrefixRoute:
-		v.routes[r.Prefix] = r
+		v.routes[r.Prefix+headerConditionsKey(r.HeaderConditions)] = r
 	case *RegexRoute:
-		v.routes[r.Regex] = r
+		// prefix the key so a regex route does not
+		// replace a prefix route for the same pattern.
+		v.routes["~"+r.Regex+headerConditionsKey(r.HeaderConditions)] = r
+	case *ExactRoute:
+		// prefix the key so an exact route does not
+		// replace a prefix route for the same path.
+		v.routes["="+r.Path+headerConditionsKey(r.HeaderConditions)] = r
 	default:
 		panic(fmt.Sprintf("unexpected route type: %T %#v", r, r))
 	}
 }
 
+// headerConditionsKey returns a suffix which distinguishes routes
+// with the same path but different header conditions. The key does
+// not depend on the order of the conditions.
+func headerConditionsKey(conditions []HeaderCondition) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	var keys []string
+	for _, hc := range conditions {
+		keys = append(keys, hc.String())
+	}
+	sort.Strings(keys)
+	return "[" + strings.Join(keys, ", ") + "]"
+}
+
 func (v *VirtualHost) Visit(f func(Vertex)) {
 	for _, r := range v.routes {
 		f(r)
@@ -166,11 +364,42 @@ type SecureVirtualHost struct {
 	// TLS minimum protocol version. Defaults to envoy_api_v2_auth.TlsParameters_TLS_AUTO
 	MinProtoVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
 
+	// TLS maximum protocol version. If not set, the
+	// maximum version of the listener is used.
+	MaxProtoVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
+
+	// CipherSuites, if set, replace the cipher suites of the listener.
+	CipherSuites []string
+
+	// ECDHCurves, if set, replace the ECDH curves of the listener.
+	ECDHCurves []string
+
 	// The cert and key for this host.
 	Secret *Secret
 
 	// Service to TCP proxy all incoming connections.
 	*TCPProxy
+
+	// AuthorizationService is the external authorization
+	// service which must allow each request to this host.
+	AuthorizationService *Cluster
+
+	// AuthorizationFailOpen allows requests if the
+	// authorization service cannot be reached.
+	AuthorizationFailOpen bool
+
+	// AuthorizationResponseTimeout is the time to wait for a response
+	// from the authorization service. Zero means Envoy's default.
+	AuthorizationResponseTimeout time.Duration
+
+	// DownstreamValidation, if set, requires clients to
+	// present a certificate which it validates.
+	DownstreamValidation *DownstreamValidation
+
+	// FallbackCertificate, if set, is the cert and key
+	// presented to clients which do not send SNI, who
+	// are routed to this host by their Host header.
+	FallbackCertificate *Secret
 }
 
 func (s *SecureVirtualHost) Visit(f func(Vertex)) {
@@ -178,6 +407,9 @@ func (s *SecureVirtualHost) Visit(f func(Vertex)) {
 	if s.TCPProxy != nil {
 		f(s.TCPProxy)
 	}
+	if s.AuthorizationService != nil {
+		f(s.AuthorizationService)
+	}
 	if s.Secret != nil {
 		f(s.Secret) // secret is not required if vhost is using tls passthrough
 	}
@@ -302,6 +534,18 @@ type Cluster struct {
 
 	// Cluster health check policy.
 	*HealthCheckPolicy
+
+	// RequestHeadersPolicy defines how headers are managed
+	// during forwarding to this cluster.
+	RequestHeadersPolicy *HeadersPolicy
+
+	// ResponseHeadersPolicy defines how headers are managed
+	// during forwarding from this cluster.
+	ResponseHeadersPolicy *HeadersPolicy
+
+	// ConnectTimeout is the timeout for new connections to the
+	// upstream hosts of this cluster. If zero, the default is used.
+	ConnectTimeout time.Duration
 }
 
 func (c Cluster) Visit(f func(Vertex)) {
@@ -314,6 +558,10 @@ type Secret struct {
 	Object *v1.Secret
 }
 
+// CertificateChainKey is the key of the secret's PEM encoded
+// intermediate certificates, which follow its tls certificate.
+const CertificateChainKey = "chain.crt"
+
 func (s *Secret) Name() string       { return s.Object.Name }
 func (s *Secret) Namespace() string  { return s.Object.Namespace }
 func (s *Secret) Visit(func(Vertex)) {}
@@ -333,6 +581,11 @@ func (s *Secret) PrivateKey() []byte {
 	return s.Object.Data[v1.TLSPrivateKeyKey]
 }
 
+// Chain returns the secret's intermediate certificates, if any.
+func (s *Secret) Chain() []byte {
+	return s.Object.Data[CertificateChainKey]
+}
+
 func (s *Secret) toMeta() Meta {
 	return Meta{
 		name:      s.Name(),
@@ -73,6 +73,28 @@ func TestSecureVirtualHostValid(t *testing.T) {
 	assert.True(vh.Valid())
 }
 
+func TestVirtualHostAddRouteHeaderConditionsOrder(t *testing.T) {
+	canary := HeaderCondition{Name: "x-canary", Value: "true", MatchType: HeaderMatchTypeExact}
+	tenant := HeaderCondition{Name: "x-tenant", Value: "acme", MatchType: HeaderMatchTypeExact}
+
+	var vh VirtualHost
+	vh.addRoute(&PrefixRoute{
+		Prefix: "/",
+		Route: Route{
+			HeaderConditions: []HeaderCondition{canary, tenant},
+		},
+	})
+	vh.addRoute(&PrefixRoute{
+		Prefix: "/",
+		Route: Route{
+			HeaderConditions: []HeaderCondition{tenant, canary},
+		},
+	})
+	if len(vh.routes) != 1 {
+		t.Fatalf("expected 1 route, got %d", len(vh.routes))
+	}
+}
+
 type Assert struct {
 	*testing.T
 }
@@ -14,6 +14,10 @@
 package dag
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
@@ -40,6 +44,179 @@ func timeoutPolicy(tp *projcontour.TimeoutPolicy) *TimeoutPolicy {
 	}
 }
 
+// connectTimeout parses the connect timeout of a service,
+// returning an error if it is not a positive duration.
+// An empty timeout returns zero, so the default applies.
+func connectTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", timeout)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q must be greater than zero", timeout)
+	}
+	return d, nil
+}
+
+// tracingPolicy converts a projcontour.TracingPolicy to a TracingPolicy,
+// returning an error if its sampling rate is not a percentage.
+func tracingPolicy(tp *projcontour.TracingPolicy) (*TracingPolicy, error) {
+	if tp == nil {
+		return nil, nil
+	}
+	if tp.Sampling < 0 || tp.Sampling > 100 {
+		return nil, fmt.Errorf("sampling must be in the range 0-100")
+	}
+	return &TracingPolicy{
+		Sampling: tp.Sampling,
+	}, nil
+}
+
+// headersPolicy converts a projcontour.HeadersPolicy to a HeadersPolicy,
+// returning an error if it manages the Host header or a pseudo header.
+func headersPolicy(policy *projcontour.HeadersPolicy) (*HeadersPolicy, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	set := make(map[string]string, len(policy.Set))
+	for _, entry := range policy.Set {
+		key := http.CanonicalHeaderKey(entry.Name)
+		if _, ok := set[key]; ok {
+			return nil, fmt.Errorf("duplicate header addition: %q", key)
+		}
+		if err := validHeaderName(key); err != nil {
+			return nil, err
+		}
+		set[key] = entry.Value
+	}
+
+	var remove []string
+	seen := make(map[string]bool, len(policy.Remove))
+	for _, entry := range policy.Remove {
+		key := http.CanonicalHeaderKey(entry)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate header removal: %q", key)
+		}
+		if err := validHeaderName(key); err != nil {
+			return nil, err
+		}
+		seen[key] = true
+		remove = append(remove, key)
+	}
+
+	if len(set) == 0 {
+		set = nil
+	}
+	return &HeadersPolicy{
+		Set:    set,
+		Remove: remove,
+	}, nil
+}
+
+// validHeaderName returns an error if the named
+// header cannot be managed by a headers policy.
+func validHeaderName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("header name must be specified")
+	case strings.HasPrefix(name, ":"):
+		return fmt.Errorf("pseudo header %q cannot be managed", name)
+	case name == "Host":
+		return errors.New("rewriting \"Host\" header is not supported")
+	}
+	return nil
+}
+
+// rateLimitPolicy converts a projcontour.RateLimitPolicy to a RateLimitPolicy,
+// returning an error if any of its descriptor entries are invalid.
+func rateLimitPolicy(rlp *projcontour.RateLimitPolicy) (*RateLimitPolicy, error) {
+	if rlp == nil || len(rlp.Descriptors) == 0 {
+		return nil, nil
+	}
+
+	policy := &RateLimitPolicy{}
+	for i, d := range rlp.Descriptors {
+		if len(d.Entries) == 0 {
+			return nil, fmt.Errorf("rateLimitPolicy descriptor %d: must have at least one entry", i)
+		}
+		descriptor := &RateLimitDescriptor{}
+		for j, entry := range d.Entries {
+			e, err := rateLimitDescriptorEntry(entry)
+			if err != nil {
+				return nil, fmt.Errorf("rateLimitPolicy descriptor %d entry %d: %s", i, j, err)
+			}
+			descriptor.Entries = append(descriptor.Entries, e)
+		}
+		policy.Descriptors = append(policy.Descriptors, descriptor)
+	}
+	return policy, nil
+}
+
+// corsPolicy converts a projcontour.CORSPolicy to a CORSPolicy,
+// returning an error if no origins are allowed or maxAge is invalid.
+func corsPolicy(cp *projcontour.CORSPolicy) (*CORSPolicy, error) {
+	if cp == nil {
+		return nil, nil
+	}
+	if len(cp.AllowOrigin) == 0 {
+		return nil, errors.New("corsPolicy: allowOrigin must be specified")
+	}
+	policy := &CORSPolicy{
+		AllowOrigin:      cp.AllowOrigin,
+		AllowMethods:     cp.AllowMethods,
+		AllowHeaders:     cp.AllowHeaders,
+		ExposeHeaders:    cp.ExposeHeaders,
+		AllowCredentials: cp.AllowCredentials,
+	}
+	if cp.MaxAge != "" {
+		d, err := time.ParseDuration(cp.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("corsPolicy: invalid maxAge %q", cp.MaxAge)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("corsPolicy: maxAge %q must not be negative", cp.MaxAge)
+		}
+		policy.MaxAge = d
+	}
+	return policy, nil
+}
+
+func rateLimitDescriptorEntry(entry projcontour.RateLimitDescriptorEntry) (RateLimitDescriptorEntry, error) {
+	var set int
+	var e RateLimitDescriptorEntry
+	if entry.GenericKey != nil {
+		set++
+		if entry.GenericKey.Value == "" {
+			return e, errors.New("genericKey value must be specified")
+		}
+		e.GenericKey = &GenericKeyDescriptorEntry{
+			Value: entry.GenericKey.Value,
+		}
+	}
+	if entry.RequestHeader != nil {
+		set++
+		if entry.RequestHeader.HeaderName == "" || entry.RequestHeader.DescriptorKey == "" {
+			return e, errors.New("requestHeader headerName and descriptorKey must be specified")
+		}
+		e.RequestHeader = &RequestHeaderDescriptorEntry{
+			HeaderName: entry.RequestHeader.HeaderName,
+			Key:        entry.RequestHeader.DescriptorKey,
+		}
+	}
+	if entry.RemoteAddress != nil {
+		set++
+		e.RemoteAddress = &RemoteAddressDescriptorEntry{}
+	}
+	if set != 1 {
+		return e, errors.New("exactly one of genericKey, requestHeader, or remoteAddress must be specified")
+	}
+	return e, nil
+}
+
 func healthCheckPolicy(hc *projcontour.HealthCheck) *HealthCheckPolicy {
 	if hc == nil {
 		return nil
@@ -133,6 +133,362 @@ func TestTimeoutPolicyIngressRoute(t *testing.T) {
 	}
 }
 
+func TestHeadersPolicy(t *testing.T) {
+	tests := map[string]struct {
+		hp      *projcontour.HeadersPolicy
+		want    *HeadersPolicy
+		wantErr string
+	}{
+		"nil headers policy": {
+			hp:   nil,
+			want: nil,
+		},
+		"set and remove": {
+			hp: &projcontour.HeadersPolicy{
+				Set: []projcontour.HeaderValue{{
+					Name:  "x-header",
+					Value: "1",
+				}},
+				Remove: []string{"x-secret"},
+			},
+			want: &HeadersPolicy{
+				Set: map[string]string{
+					"X-Header": "1",
+				},
+				Remove: []string{"X-Secret"},
+			},
+		},
+		"duplicate set": {
+			hp: &projcontour.HeadersPolicy{
+				Set: []projcontour.HeaderValue{{
+					Name:  "x-header",
+					Value: "1",
+				}, {
+					Name:  "X-Header",
+					Value: "2",
+				}},
+			},
+			wantErr: `duplicate header addition: "X-Header"`,
+		},
+		"duplicate remove": {
+			hp: &projcontour.HeadersPolicy{
+				Remove: []string{"x-secret", "X-SECRET"},
+			},
+			wantErr: `duplicate header removal: "X-Secret"`,
+		},
+		"set host": {
+			hp: &projcontour.HeadersPolicy{
+				Set: []projcontour.HeaderValue{{
+					Name:  "host",
+					Value: "example.com",
+				}},
+			},
+			wantErr: `rewriting "Host" header is not supported`,
+		},
+		"remove pseudo header": {
+			hp: &projcontour.HeadersPolicy{
+				Remove: []string{":path"},
+			},
+			wantErr: `pseudo header ":path" cannot be managed`,
+		},
+		"empty name": {
+			hp: &projcontour.HeadersPolicy{
+				Set: []projcontour.HeaderValue{{
+					Value: "1",
+				}},
+			},
+			wantErr: "header name must be specified",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := headersPolicy(tc.hp)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicy(t *testing.T) {
+	tests := map[string]struct {
+		rlp     *projcontour.RateLimitPolicy
+		want    *RateLimitPolicy
+		wantErr string
+	}{
+		"nil policy": {
+			rlp:  nil,
+			want: nil,
+		},
+		"no descriptors": {
+			rlp:  &projcontour.RateLimitPolicy{},
+			want: nil,
+		},
+		"all entry types": {
+			rlp: &projcontour.RateLimitPolicy{
+				Descriptors: []projcontour.RateLimitDescriptor{{
+					Entries: []projcontour.RateLimitDescriptorEntry{{
+						GenericKey: &projcontour.GenericKeyDescriptor{Value: "api"},
+					}, {
+						RequestHeader: &projcontour.RequestHeaderDescriptor{
+							HeaderName:    "X-Tenant",
+							DescriptorKey: "tenant",
+						},
+					}, {
+						RemoteAddress: &projcontour.RemoteAddressDescriptor{},
+					}},
+				}},
+			},
+			want: &RateLimitPolicy{
+				Descriptors: []*RateLimitDescriptor{{
+					Entries: []RateLimitDescriptorEntry{{
+						GenericKey: &GenericKeyDescriptorEntry{Value: "api"},
+					}, {
+						RequestHeader: &RequestHeaderDescriptorEntry{
+							HeaderName: "X-Tenant",
+							Key:        "tenant",
+						},
+					}, {
+						RemoteAddress: &RemoteAddressDescriptorEntry{},
+					}},
+				}},
+			},
+		},
+		"descriptor without entries": {
+			rlp: &projcontour.RateLimitPolicy{
+				Descriptors: []projcontour.RateLimitDescriptor{{}},
+			},
+			wantErr: "rateLimitPolicy descriptor 0: must have at least one entry",
+		},
+		"entry without a type": {
+			rlp: &projcontour.RateLimitPolicy{
+				Descriptors: []projcontour.RateLimitDescriptor{{
+					Entries: []projcontour.RateLimitDescriptorEntry{{}},
+				}},
+			},
+			wantErr: "rateLimitPolicy descriptor 0 entry 0: exactly one of genericKey, requestHeader, or remoteAddress must be specified",
+		},
+		"entry with two types": {
+			rlp: &projcontour.RateLimitPolicy{
+				Descriptors: []projcontour.RateLimitDescriptor{{
+					Entries: []projcontour.RateLimitDescriptorEntry{{
+						GenericKey:    &projcontour.GenericKeyDescriptor{Value: "api"},
+						RemoteAddress: &projcontour.RemoteAddressDescriptor{},
+					}},
+				}},
+			},
+			wantErr: "rateLimitPolicy descriptor 0 entry 0: exactly one of genericKey, requestHeader, or remoteAddress must be specified",
+		},
+		"generic key without value": {
+			rlp: &projcontour.RateLimitPolicy{
+				Descriptors: []projcontour.RateLimitDescriptor{{
+					Entries: []projcontour.RateLimitDescriptorEntry{{
+						GenericKey: &projcontour.GenericKeyDescriptor{},
+					}},
+				}},
+			},
+			wantErr: "rateLimitPolicy descriptor 0 entry 0: genericKey value must be specified",
+		},
+		"request header without descriptor key": {
+			rlp: &projcontour.RateLimitPolicy{
+				Descriptors: []projcontour.RateLimitDescriptor{{
+					Entries: []projcontour.RateLimitDescriptorEntry{{
+						RequestHeader: &projcontour.RequestHeaderDescriptor{
+							HeaderName: "X-Tenant",
+						},
+					}},
+				}},
+			},
+			wantErr: "rateLimitPolicy descriptor 0 entry 0: requestHeader headerName and descriptorKey must be specified",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := rateLimitPolicy(tc.rlp)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestCORSPolicy(t *testing.T) {
+	tests := map[string]struct {
+		cp      *projcontour.CORSPolicy
+		want    *CORSPolicy
+		wantErr string
+	}{
+		"nil policy": {
+			cp:   nil,
+			want: nil,
+		},
+		"all fields": {
+			cp: &projcontour.CORSPolicy{
+				AllowOrigin:      []string{"https://example.com"},
+				AllowMethods:     []string{"GET", "POST"},
+				AllowHeaders:     []string{"Authorization"},
+				ExposeHeaders:    []string{"X-Request-Id"},
+				MaxAge:           "10m",
+				AllowCredentials: true,
+			},
+			want: &CORSPolicy{
+				AllowOrigin:      []string{"https://example.com"},
+				AllowMethods:     []string{"GET", "POST"},
+				AllowHeaders:     []string{"Authorization"},
+				ExposeHeaders:    []string{"X-Request-Id"},
+				MaxAge:           10 * time.Minute,
+				AllowCredentials: true,
+			},
+		},
+		"no origins": {
+			cp: &projcontour.CORSPolicy{
+				AllowMethods: []string{"GET"},
+			},
+			wantErr: "corsPolicy: allowOrigin must be specified",
+		},
+		"invalid max age": {
+			cp: &projcontour.CORSPolicy{
+				AllowOrigin: []string{"*"},
+				MaxAge:      "ten minutes",
+			},
+			wantErr: `corsPolicy: invalid maxAge "ten minutes"`,
+		},
+		"negative max age": {
+			cp: &projcontour.CORSPolicy{
+				AllowOrigin: []string{"*"},
+				MaxAge:      "-1s",
+			},
+			wantErr: `corsPolicy: maxAge "-1s" must not be negative`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := corsPolicy(tc.cp)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	tests := map[string]struct {
+		timeout string
+		want    time.Duration
+		wantErr string
+	}{
+		"not set": {
+			timeout: "",
+			want:    0,
+		},
+		"seconds": {
+			timeout: "2s",
+			want:    2 * time.Second,
+		},
+		"invalid": {
+			timeout: "forever",
+			wantErr: `invalid duration "forever"`,
+		},
+		"zero": {
+			timeout: "0s",
+			wantErr: `"0s" must be greater than zero`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := connectTimeout(tc.timeout)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestTracingPolicy(t *testing.T) {
+	tests := map[string]struct {
+		tp      *projcontour.TracingPolicy
+		want    *TracingPolicy
+		wantErr string
+	}{
+		"nil policy": {
+			tp:   nil,
+			want: nil,
+		},
+		"no sampling": {
+			tp:   &projcontour.TracingPolicy{},
+			want: &TracingPolicy{},
+		},
+		"fractional sampling": {
+			tp: &projcontour.TracingPolicy{
+				Sampling: 0.5,
+			},
+			want: &TracingPolicy{
+				Sampling: 0.5,
+			},
+		},
+		"negative sampling": {
+			tp: &projcontour.TracingPolicy{
+				Sampling: -1,
+			},
+			wantErr: "sampling must be in the range 0-100",
+		},
+		"sampling above 100": {
+			tp: &projcontour.TracingPolicy{
+				Sampling: 100.5,
+			},
+			wantErr: "sampling must be in the range 0-100",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tracingPolicy(tc.tp)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestParseTimeout(t *testing.T) {
 	tests := map[string]struct {
 		duration string
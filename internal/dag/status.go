@@ -100,6 +100,8 @@ func (osw *ObjectStatusWriter) SetValid() {
 		osw.WithValue("description", "valid HTTPProxy").WithValue("status", StatusValid)
 	case *ingressroutev1.IngressRoute:
 		osw.WithValue("description", "valid IngressRoute").WithValue("status", StatusValid)
+	case *projcontour.TLSCertificateDelegation, *ingressroutev1.TLSCertificateDelegation:
+		osw.WithValue("description", "valid TLSCertificateDelegation").WithValue("status", StatusValid)
 	default:
 		// not a supported type
 	}
@@ -15,10 +15,12 @@ package dag
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
 	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
+	"github.com/heptio/contour/internal/certgen"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -1352,6 +1354,50 @@ func TestDAGHTTPProxyStatus(t *testing.T) {
 		},
 	}
 
+	// proxy15a is valid because its wildcard is the leftmost label
+	proxy15a := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "*.example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy15b is invalid because its wildcard is not a whole label
+	proxy15b := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "*example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
 	// proxy16 is invalid because it references an invalid service
 	proxy16 := &projcontour.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1494,206 +1540,1553 @@ func TestDAGHTTPProxyStatus(t *testing.T) {
 		},
 	}
 
-	s2 := &v1.Service{
+	// proxy25 tcp includes roots/tcp-child which includes it back.
+	proxy25 := &projcontour.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "kuard",
 			Namespace: "roots",
+			Name:      "tcp-root",
 		},
-		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{{
-				Name:     "http",
-				Protocol: "TCP",
-				Port:     8080,
-			}},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "tcp.example.com",
+				TLS: &projcontour.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &projcontour.TCPProxy{
+				Include: projcontour.Include{
+					Name: "tcp-child",
+				},
+			},
 		},
 	}
 
-	s4 := &v1.Service{
+	proxy25a := &projcontour.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "home",
 			Namespace: "roots",
+			Name:      "tcp-child",
 		},
-		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{{
-				Name:     "http",
-				Protocol: "TCP",
-				Port:     8080,
-			}},
+		Spec: projcontour.HTTPProxySpec{
+			TCPProxy: &projcontour.TCPProxy{
+				Include: projcontour.Include{
+					Name: "tcp-grandchild",
+				},
+			},
 		},
 	}
 
-	s8 := &v1.Service{
+	proxy25b := &projcontour.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "green",
-			Namespace: "marketing",
+			Namespace: "roots",
+			Name:      "tcp-grandchild",
 		},
-		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{{
-				Name:     "http",
-				Protocol: "TCP",
-				Port:     80,
-			}},
+		Spec: projcontour.HTTPProxySpec{
+			TCPProxy: &projcontour.TCPProxy{
+				Include: projcontour.Include{
+					Name: "tcp-child",
+				},
+			},
 		},
 	}
 
-	tests := map[string]struct {
-		objs []interface{}
-		want map[Meta]Status
-	}{
-		"valid proxy": {
-			objs: []interface{}{proxy1, s4},
-			want: map[Meta]Status{
-				{name: proxy1.Name, namespace: proxy1.Namespace}: {Object: proxy1, Status: "valid", Description: "valid HTTPProxy", Vhost: "example.com"},
-			},
-		},
-		"invalid port in service": {
-			objs: []interface{}{proxy2},
-			want: map[Meta]Status{
-				{name: proxy2.Name, namespace: proxy2.Namespace}: {Object: proxy2, Status: "invalid", Description: `route "/foo": service "home": port must be in the range 1-65535`, Vhost: "example.com"},
-			},
-		},
-		"root proxy outside of roots namespace": {
-			objs: []interface{}{proxy3},
-			want: map[Meta]Status{
-				{name: proxy3.Name, namespace: proxy3.Namespace}: {Object: proxy3, Status: "invalid", Description: "root HTTPProxy cannot be defined in this namespace"},
-			},
-		},
-		"root proxy does not specify FQDN": {
-			objs: []interface{}{proxy13},
-			want: map[Meta]Status{
-				{name: proxy13.Name, namespace: proxy13.Namespace}: {Object: proxy13, Status: "invalid", Description: "Spec.VirtualHost.Fqdn must be specified"},
-			},
+	// proxy26 is invalid because its tcpproxy has both services and an include.
+	proxy26 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "tcp-root",
 		},
-		"self-edge produces a cycle": {
-			objs: []interface{}{proxy6, s1},
-			want: map[Meta]Status{
-				{name: proxy6.Name, namespace: proxy6.Namespace}: {
-					Object:      proxy6,
-					Status:      "invalid",
-					Description: "root httpproxy cannot delegate to another root httpproxy",
-					Vhost:       "example.com",
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "tcp.example.com",
+				TLS: &projcontour.TLS{
+					Passthrough: true,
 				},
 			},
-		},
-		"child delegates to parent, producing a cycle": {
-			objs: []interface{}{proxy7, proxy8},
-			want: map[Meta]Status{
-				{name: proxy7.Name, namespace: proxy7.Namespace}: {
-					Object:      proxy7,
-					Status:      "valid",
-					Description: "valid HTTPProxy",
-					Vhost:       "example.com",
-				},
-				{name: proxy8.Name, namespace: proxy8.Namespace}: {
-					Object:      proxy8,
-					Status:      "invalid",
-					Description: "include creates a delegation cycle: roots/parent -> roots/child -> roots/child",
-					Vhost:       "example.com",
+			TCPProxy: &projcontour.TCPProxy{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+				Include: projcontour.Include{
+					Name: "tcp-child",
 				},
 			},
 		},
-		"orphaned route": {
-			objs: []interface{}{proxy8},
-			want: map[Meta]Status{
-				{name: proxy8.Name, namespace: proxy8.Namespace}: {Object: proxy8, Status: "orphaned", Description: "this HTTPProxy is not part of a delegation chain from a root HTTPProxy"},
-			},
+	}
+
+	// proxy27 is invalid because its tcpproxy is not protected by TLS.
+	proxy27 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "tcp-root",
 		},
-		"invalid parent orphans children": {
-			objs: []interface{}{proxy14, proxy11},
-			want: map[Meta]Status{
-				{name: proxy14.Name, namespace: proxy14.Namespace}: {Object: proxy14, Status: "invalid", Description: "Spec.VirtualHost.Fqdn must be specified"},
-				{name: proxy11.Name, namespace: proxy11.Namespace}: {Object: proxy11, Status: "orphaned", Description: "this HTTPProxy is not part of a delegation chain from a root HTTPProxy"},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "tcp.example.com",
 			},
-		},
-		"invalid FQDN contains wildcard": {
-			objs: []interface{}{proxy15},
-			want: map[Meta]Status{
-				{name: proxy15.Name, namespace: proxy15.Namespace}: {Object: proxy15, Status: "invalid", Description: `Spec.VirtualHost.Fqdn "example.*.com" cannot use wildcards`, Vhost: "example.*.com"},
+			TCPProxy: &projcontour.TCPProxy{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
 			},
 		},
-		"missing service shows invalid status": {
-			objs: []interface{}{proxy16},
-			want: map[Meta]Status{
-				{name: proxy16.Name, namespace: proxy16.Namespace}: {
-					Object:      proxy16,
-					Status:      "invalid",
-					Description: `Service [invalid:8080] is invalid or missing`,
-					Vhost:       proxy16.Spec.VirtualHost.Fqdn,
-				},
-			},
+	}
+
+	// proxy28 is invalid because it includes a missing tcpproxy.
+	proxy28 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "tcp-root",
 		},
-		"insert proxy": {
-			objs: []interface{}{s2, proxy17},
-			want: map[Meta]Status{
-				{name: proxy17.Name, namespace: proxy17.Namespace}: {
-					Object:      proxy17,
-					Status:      StatusValid,
-					Description: "valid HTTPProxy",
-					Vhost:       "example.com",
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "tcp.example.com",
+				TLS: &projcontour.TLS{
+					Passthrough: true,
 				},
 			},
-		},
-		"insert conflicting proxies due to fqdn reuse": {
-			objs: []interface{}{proxy17, proxy18},
-			want: map[Meta]Status{
-				{name: proxy17.Name, namespace: proxy17.Namespace}: {
-					Object:      proxy17,
-					Status:      StatusInvalid,
-					Description: `fqdn "example.com" is used in multiple HTTPProxies: roots/example-com, roots/other-example`,
-					Vhost:       "example.com",
-				},
-				{name: proxy18.Name, namespace: proxy18.Namespace}: {
-					Object:      proxy18,
-					Status:      StatusInvalid,
-					Description: `fqdn "example.com" is used in multiple HTTPProxies: roots/example-com, roots/other-example`,
-					Vhost:       "example.com",
+			TCPProxy: &projcontour.TCPProxy{
+				Include: projcontour.Include{
+					Name:      "missing",
+					Namespace: "marketing",
 				},
 			},
 		},
-		"root proxy delegating to another root": {
-			objs: []interface{}{proxy20, proxy21},
-			want: map[Meta]Status{
-				{name: proxy20.Name, namespace: proxy20.Namespace}: {
-					Object:      proxy20,
-					Status:      StatusInvalid,
-					Description: `fqdn "blog.containersteve.com" is used in multiple HTTPProxies: marketing/blog, roots/root-blog`,
-					Vhost:       "blog.containersteve.com",
-				},
-				{name: proxy21.Name, namespace: proxy21.Namespace}: {
-					Object:      proxy21,
-					Status:      StatusInvalid,
-					Description: `fqdn "blog.containersteve.com" is used in multiple HTTPProxies: marketing/blog, roots/root-blog`,
-					Vhost:       "blog.containersteve.com",
-				},
-			},
+	}
+
+	// proxy29 is invalid because its virtual host rate limit
+	// descriptor entry does not specify a type.
+	proxy29 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
 		},
-		"root ingress delegating to another root w/ different hostname": {
-			objs: []interface{}{proxy22, proxy23, s8},
-			want: map[Meta]Status{
-				{name: proxy22.Name, namespace: proxy22.Namespace}: {
-					Object:      proxy22,
-					Status:      StatusInvalid,
-					Description: "root httpproxy cannot delegate to another root httpproxy",
-					Vhost:       "blog.containersteve.com",
-				},
-				{name: proxy23.Name, namespace: proxy23.Namespace}: {
-					Object:      proxy23,
-					Status:      StatusValid,
-					Description: `valid HTTPProxy`,
-					Vhost:       "www.containersteve.com",
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				RateLimitPolicy: &projcontour.RateLimitPolicy{
+					Descriptors: []projcontour.RateLimitDescriptor{{
+						Entries: []projcontour.RateLimitDescriptorEntry{{}},
+					}},
 				},
 			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
 		},
 	}
 
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			builder := Builder{
-				Source: KubernetesCache{
-					RootNamespaces: []string{"roots", "marketing"},
-					FieldLogger:    testLogger(t),
-				},
-			}
+	// proxy30 is invalid because its route rate limit
+	// descriptor has no entries.
+	proxy30 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				RateLimitPolicy: &projcontour.RateLimitPolicy{
+					Descriptors: []projcontour.RateLimitDescriptor{{}},
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	sec3 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ssl-cert",
+			Namespace: "roots",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata("certificate", "key"),
+	}
+
+	// proxy31 is invalid because its authorization service is missing.
+	proxy31 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec3.Name,
+				},
+				Authorization: &projcontour.AuthorizationServer{
+					Name: "auth",
+					Port: 9000,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy32 is invalid because authorization requires TLS.
+	proxy32 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				Authorization: &projcontour.AuthorizationServer{
+					Name: "auth",
+					Port: 9000,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy33 is invalid because its authorization service does not speak gRPC.
+	proxy33 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec3.Name,
+				},
+				Authorization: &projcontour.AuthorizationServer{
+					Name: "home",
+					Port: 8080,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy34 is invalid because it rewrites the host header.
+	proxy34 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				RequestHeadersPolicy: &projcontour.HeadersPolicy{
+					Set: []projcontour.HeaderValue{{
+						Name:  "Host",
+						Value: "external.com",
+					}},
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy35 is invalid because its service removes a pseudo header.
+	proxy35 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+					ResponseHeadersPolicy: &projcontour.HeadersPolicy{
+						Remove: []string{":status"},
+					},
+				}},
+			}},
+		},
+	}
+
+	// proxy36 is invalid because its host rewrite policy
+	// specifies both a hostname and auto.
+	proxy36 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				HostRewritePolicy: &projcontour.HostRewritePolicy{
+					Hostname: "external.com",
+					Auto:     true,
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy37 is invalid because its route condition
+	// has an invalid regular expression.
+	proxy37 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Regex: "/foo/(bar",
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy38 is invalid because its route condition
+	// specifies both a prefix and an exact path.
+	proxy38 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+					Exact:  "/foo/bar",
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy39 is invalid because it includes another
+	// proxy with an exact path condition.
+	proxy39 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []projcontour.Include{{
+				Name:      "blog",
+				Namespace: "roots",
+				Condition: projcontour.Condition{
+					Exact: "/blog",
+				},
+			}},
+		},
+	}
+
+	proxy39a := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "blog",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy40 is invalid because its tracing
+	// sampling rate is not a percentage.
+	proxy40 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			TracingPolicy: &projcontour.TracingPolicy{
+				Sampling: 101,
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy41 is invalid because its service's
+	// connect timeout is not a duration.
+	proxy41 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+				},
+				Services: []projcontour.Service{{
+					Name:           "home",
+					Port:           8080,
+					ConnectTimeout: "soon",
+				}},
+			}},
+		},
+	}
+
+	// proxy42 is invalid because its client validation CA secret is missing.
+	proxy42 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec3.Name,
+					ClientValidation: &projcontour.DownstreamValidation{
+						CACertificate: "missing",
+					},
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy43 is invalid because client validation
+	// cannot be used with tls passthrough.
+	proxy43 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					Passthrough: true,
+					ClientValidation: &projcontour.DownstreamValidation{
+						CACertificate: "ca",
+					},
+				},
+			},
+			TCPProxy: &projcontour.TCPProxy{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			},
+		},
+	}
+
+	// proxy44 is invalid because it permits a cipher
+	// suite which the listener does not
+	proxy44 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName:   sec3.Name,
+					CipherSuites: []string{"AES256-SHA"},
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy45 is invalid because it enables the fallback
+	// certificate, which Contour is not configured with
+	proxy45 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName:                sec3.Name,
+					EnableFallbackCertificate: true,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy46 is invalid because it enables the fallback
+	// certificate with tls passthrough
+	proxy46 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					Passthrough:               true,
+					EnableFallbackCertificate: true,
+				},
+			},
+			TCPProxy: &projcontour.TCPProxy{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			},
+		},
+	}
+
+	// proxy50 is invalid because the fallback certificate would
+	// allow clients to use TLS versions it does not permit.
+	proxy50 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName:                sec3.Name,
+					MinimumProtocolVersion:    "1.3",
+					EnableFallbackCertificate: true,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy47 is valid, but its certificate has expired
+	expiry := time.Now().Add(-time.Hour).Truncate(time.Second)
+	cert, key, err := certgen.NewCA("example.com", expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec4 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "expired",
+			Namespace: "roots",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(string(cert), string(key)),
+	}
+	proxy47 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec4.Name,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy48 is protected by an authorization service and
+	// includes proxy49 from another namespace.
+	proxy48 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec3.Name,
+				},
+				Authorization: &projcontour.AuthorizationServer{
+					Name: "auth",
+					Port: 9000,
+				},
+			},
+			Includes: []projcontour.Include{{
+				Name:      "child",
+				Namespace: "marketing",
+			}},
+		},
+	}
+
+	// proxy49 is invalid because only the root HTTPProxy
+	// may disable authorization.
+	proxy49 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "marketing",
+			Name:      "child",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Routes: []projcontour.Route{{
+				AuthPolicy: &projcontour.AuthorizationPolicy{
+					Disabled: true,
+				},
+				Services: []projcontour.Service{{
+					Name: "green",
+					Port: 80,
+				}},
+			}},
+		},
+	}
+
+	authsvc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "auth",
+			Namespace: "roots",
+			Annotations: map[string]string{
+				"contour.heptio.com/upstream-protocol.h2c": "9000",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "grpc",
+				Protocol: "TCP",
+				Port:     9000,
+			}},
+		},
+	}
+
+	// proxy24 is invalid because its header condition has no name
+	proxy24 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+					HeadersMatch: map[string][]string{
+						"": {"true"},
+					},
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy51 is invalid because its exact header conditions
+	// require one header to have two different values.
+	proxy51 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/foo",
+					HeadersMatch: map[string][]string{
+						"X-Canary": {"true"},
+						"x-canary": {"false"},
+					},
+				},
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	s4 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "home",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	s8 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "green",
+			Namespace: "marketing",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     80,
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		objs []interface{}
+		want map[Meta]Status
+	}{
+		"valid proxy": {
+			objs: []interface{}{proxy1, s4},
+			want: map[Meta]Status{
+				{name: proxy1.Name, namespace: proxy1.Namespace}: {Object: proxy1, Status: "valid", Description: "valid HTTPProxy", Vhost: "example.com"},
+			},
+		},
+		"invalid port in service": {
+			objs: []interface{}{proxy2},
+			want: map[Meta]Status{
+				{name: proxy2.Name, namespace: proxy2.Namespace}: {Object: proxy2, Status: "invalid", Description: `route "/foo": service "home": port must be in the range 1-65535`, Vhost: "example.com"},
+			},
+		},
+		"root proxy outside of roots namespace": {
+			objs: []interface{}{proxy3},
+			want: map[Meta]Status{
+				{name: proxy3.Name, namespace: proxy3.Namespace}: {Object: proxy3, Status: "invalid", Description: "root HTTPProxy cannot be defined in this namespace"},
+			},
+		},
+		"root proxy does not specify FQDN": {
+			objs: []interface{}{proxy13},
+			want: map[Meta]Status{
+				{name: proxy13.Name, namespace: proxy13.Namespace}: {Object: proxy13, Status: "invalid", Description: "Spec.VirtualHost.Fqdn must be specified"},
+			},
+		},
+		"self-edge produces a cycle": {
+			objs: []interface{}{proxy6, s1},
+			want: map[Meta]Status{
+				{name: proxy6.Name, namespace: proxy6.Namespace}: {
+					Object:      proxy6,
+					Status:      "invalid",
+					Description: "root httpproxy cannot delegate to another root httpproxy",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"child delegates to parent, producing a cycle": {
+			objs: []interface{}{proxy7, proxy8},
+			want: map[Meta]Status{
+				{name: proxy7.Name, namespace: proxy7.Namespace}: {
+					Object:      proxy7,
+					Status:      "valid",
+					Description: "valid HTTPProxy",
+					Vhost:       "example.com",
+				},
+				{name: proxy8.Name, namespace: proxy8.Namespace}: {
+					Object:      proxy8,
+					Status:      "invalid",
+					Description: "include creates a delegation cycle: roots/parent -> roots/child -> roots/child",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"orphaned route": {
+			objs: []interface{}{proxy8},
+			want: map[Meta]Status{
+				{name: proxy8.Name, namespace: proxy8.Namespace}: {Object: proxy8, Status: "orphaned", Description: "this HTTPProxy is not part of a delegation chain from a root HTTPProxy"},
+			},
+		},
+		"invalid parent orphans children": {
+			objs: []interface{}{proxy14, proxy11},
+			want: map[Meta]Status{
+				{name: proxy14.Name, namespace: proxy14.Namespace}: {Object: proxy14, Status: "invalid", Description: "Spec.VirtualHost.Fqdn must be specified"},
+				{name: proxy11.Name, namespace: proxy11.Namespace}: {Object: proxy11, Status: "orphaned", Description: "this HTTPProxy is not part of a delegation chain from a root HTTPProxy"},
+			},
+		},
+		"invalid FQDN contains wildcard": {
+			objs: []interface{}{proxy15},
+			want: map[Meta]Status{
+				{name: proxy15.Name, namespace: proxy15.Namespace}: {Object: proxy15, Status: "invalid", Description: `Spec.VirtualHost.Fqdn "example.*.com" can only use a wildcard as the leftmost label`, Vhost: "example.*.com"},
+			},
+		},
+		"valid FQDN with leftmost wildcard": {
+			objs: []interface{}{proxy15a, s4},
+			want: map[Meta]Status{
+				{name: proxy15a.Name, namespace: proxy15a.Namespace}: {Object: proxy15a, Status: "valid", Description: "valid HTTPProxy", Vhost: "*.example.com"},
+			},
+		},
+		"invalid FQDN wildcard is not a whole label": {
+			objs: []interface{}{proxy15b},
+			want: map[Meta]Status{
+				{name: proxy15b.Name, namespace: proxy15b.Namespace}: {Object: proxy15b, Status: "invalid", Description: `Spec.VirtualHost.Fqdn "*example.com" can only use a wildcard as the leftmost label`, Vhost: "*example.com"},
+			},
+		},
+		"missing service shows invalid status": {
+			objs: []interface{}{proxy16},
+			want: map[Meta]Status{
+				{name: proxy16.Name, namespace: proxy16.Namespace}: {
+					Object:      proxy16,
+					Status:      "invalid",
+					Description: `Service [invalid:8080] is invalid or missing`,
+					Vhost:       proxy16.Spec.VirtualHost.Fqdn,
+				},
+			},
+		},
+		"insert proxy": {
+			objs: []interface{}{s2, proxy17},
+			want: map[Meta]Status{
+				{name: proxy17.Name, namespace: proxy17.Namespace}: {
+					Object:      proxy17,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"insert conflicting proxies due to fqdn reuse": {
+			objs: []interface{}{proxy17, proxy18},
+			want: map[Meta]Status{
+				{name: proxy17.Name, namespace: proxy17.Namespace}: {
+					Object:      proxy17,
+					Status:      StatusInvalid,
+					Description: `fqdn "example.com" is used in multiple HTTPProxies: roots/example-com, roots/other-example`,
+					Vhost:       "example.com",
+				},
+				{name: proxy18.Name, namespace: proxy18.Namespace}: {
+					Object:      proxy18,
+					Status:      StatusInvalid,
+					Description: `fqdn "example.com" is used in multiple HTTPProxies: roots/example-com, roots/other-example`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"root proxy delegating to another root": {
+			objs: []interface{}{proxy20, proxy21},
+			want: map[Meta]Status{
+				{name: proxy20.Name, namespace: proxy20.Namespace}: {
+					Object:      proxy20,
+					Status:      StatusInvalid,
+					Description: `fqdn "blog.containersteve.com" is used in multiple HTTPProxies: marketing/blog, roots/root-blog`,
+					Vhost:       "blog.containersteve.com",
+				},
+				{name: proxy21.Name, namespace: proxy21.Namespace}: {
+					Object:      proxy21,
+					Status:      StatusInvalid,
+					Description: `fqdn "blog.containersteve.com" is used in multiple HTTPProxies: marketing/blog, roots/root-blog`,
+					Vhost:       "blog.containersteve.com",
+				},
+			},
+		},
+		"root ingress delegating to another root w/ different hostname": {
+			objs: []interface{}{proxy22, proxy23, s8},
+			want: map[Meta]Status{
+				{name: proxy22.Name, namespace: proxy22.Namespace}: {
+					Object:      proxy22,
+					Status:      StatusInvalid,
+					Description: "root httpproxy cannot delegate to another root httpproxy",
+					Vhost:       "blog.containersteve.com",
+				},
+				{name: proxy23.Name, namespace: proxy23.Namespace}: {
+					Object:      proxy23,
+					Status:      StatusValid,
+					Description: `valid HTTPProxy`,
+					Vhost:       "www.containersteve.com",
+				},
+			},
+		},
+		"proxy with blank header condition name": {
+			objs: []interface{}{proxy24, s4},
+			want: map[Meta]Status{
+				{name: proxy24.Name, namespace: proxy24.Namespace}: {
+					Object:      proxy24,
+					Status:      StatusInvalid,
+					Description: `route "/foo": exact header condition must specify a header name`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"proxy with conflicting exact header conditions": {
+			objs: []interface{}{proxy51, s4},
+			want: map[Meta]Status{
+				{name: proxy51.Name, namespace: proxy51.Namespace}: {
+					Object:      proxy51,
+					Status:      StatusInvalid,
+					Description: `route "/foo": exact header conditions on "x-canary" conflict: "false" and "true"`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"tcpproxy include creates a cycle": {
+			objs: []interface{}{proxy25, proxy25a, proxy25b},
+			want: map[Meta]Status{
+				{name: proxy25.Name, namespace: proxy25.Namespace}: {
+					Object:      proxy25,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy",
+					Vhost:       "tcp.example.com",
+				},
+				{name: proxy25a.Name, namespace: proxy25a.Namespace}: {
+					Object:      proxy25a,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy",
+					Vhost:       "tcp.example.com",
+				},
+				{name: proxy25b.Name, namespace: proxy25b.Namespace}: {
+					Object:      proxy25b,
+					Status:      StatusInvalid,
+					Description: "tcpproxy include creates a cycle: roots/tcp-root -> roots/tcp-child -> roots/tcp-grandchild -> roots/tcp-child",
+					Vhost:       "tcp.example.com",
+				},
+			},
+		},
+		"tcpproxy with services and include": {
+			objs: []interface{}{proxy26, s4},
+			want: map[Meta]Status{
+				{name: proxy26.Name, namespace: proxy26.Namespace}: {
+					Object:      proxy26,
+					Status:      StatusInvalid,
+					Description: "tcpproxy: cannot specify services and include in the same tcpproxy",
+					Vhost:       "tcp.example.com",
+				},
+			},
+		},
+		"tcpproxy without tls": {
+			objs: []interface{}{proxy27, s4},
+			want: map[Meta]Status{
+				{name: proxy27.Name, namespace: proxy27.Namespace}: {
+					Object:      proxy27,
+					Status:      StatusInvalid,
+					Description: "tcpproxy: missing tls.passthrough or tls.secretName",
+					Vhost:       "tcp.example.com",
+				},
+			},
+		},
+		"tcpproxy includes missing httpproxy": {
+			objs: []interface{}{proxy28},
+			want: map[Meta]Status{
+				{name: proxy28.Name, namespace: proxy28.Namespace}: {
+					Object:      proxy28,
+					Status:      StatusInvalid,
+					Description: "tcpproxy: include marketing/missing not found",
+					Vhost:       "tcp.example.com",
+				},
+			},
+		},
+		"missing authorization service": {
+			objs: []interface{}{proxy31, s4, sec3},
+			want: map[Meta]Status{
+				{name: proxy31.Name, namespace: proxy31.Namespace}: {
+					Object:      proxy31,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.Authorization: service roots/auth/9000: not found",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"authorization without tls": {
+			objs: []interface{}{proxy32, s4},
+			want: map[Meta]Status{
+				{name: proxy32.Name, namespace: proxy32.Namespace}: {
+					Object:      proxy32,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.Authorization: tls.secretName must be specified",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"authorization service without grpc": {
+			objs: []interface{}{proxy33, s4, sec3},
+			want: map[Meta]Status{
+				{name: proxy33.Name, namespace: proxy33.Namespace}: {
+					Object:      proxy33,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.Authorization: service roots/home/8080: must use the h2 or h2c upstream protocol",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"included proxy disables authorization": {
+			objs: []interface{}{proxy48, proxy49, authsvc, sec3, s8},
+			want: map[Meta]Status{
+				{name: proxy48.Name, namespace: proxy48.Namespace}: {
+					Object:      proxy48,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy",
+					Vhost:       "example.com",
+				},
+				{name: proxy49.Name, namespace: proxy49.Namespace}: {
+					Object:      proxy49,
+					Status:      StatusInvalid,
+					Description: `route "/": authPolicy: authorization may only be disabled by the root HTTPProxy`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"route rewrites host header": {
+			objs: []interface{}{proxy34, s4},
+			want: map[Meta]Status{
+				{name: proxy34.Name, namespace: proxy34.Namespace}: {
+					Object:      proxy34,
+					Status:      StatusInvalid,
+					Description: `route "/foo": requestHeadersPolicy: rewriting "Host" header is not supported`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"service removes pseudo header": {
+			objs: []interface{}{proxy35, s4},
+			want: map[Meta]Status{
+				{name: proxy35.Name, namespace: proxy35.Namespace}: {
+					Object:      proxy35,
+					Status:      StatusInvalid,
+					Description: `route "/foo": service "home": responseHeadersPolicy: pseudo header ":status" cannot be managed`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"route host rewrite policy with hostname and auto": {
+			objs: []interface{}{proxy36, s4},
+			want: map[Meta]Status{
+				{name: proxy36.Name, namespace: proxy36.Namespace}: {
+					Object:      proxy36,
+					Status:      StatusInvalid,
+					Description: `route "/foo": hostRewritePolicy: only one of hostname or auto may be specified`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"route condition with invalid regex": {
+			objs: []interface{}{proxy37, s4},
+			want: map[Meta]Status{
+				{name: proxy37.Name, namespace: proxy37.Namespace}: {
+					Object:      proxy37,
+					Status:      StatusInvalid,
+					Description: "route \"/foo/(bar\": condition: invalid regex: error parsing regexp: missing closing ): `/foo/(bar`",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"route condition with prefix and exact path": {
+			objs: []interface{}{proxy38, s4},
+			want: map[Meta]Status{
+				{name: proxy38.Name, namespace: proxy38.Namespace}: {
+					Object:      proxy38,
+					Status:      StatusInvalid,
+					Description: `route "/foo/bar": condition: only one of prefix, exact, or regex may be specified`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"include with exact path condition": {
+			objs: []interface{}{proxy39, proxy39a, s4},
+			want: map[Meta]Status{
+				{name: proxy39.Name, namespace: proxy39.Namespace}: {
+					Object:      proxy39,
+					Status:      StatusInvalid,
+					Description: "include roots/blog: exact and regex conditions are only valid on routes",
+					Vhost:       "example.com",
+				},
+				{name: proxy39a.Name, namespace: proxy39a.Namespace}: {
+					Object:      proxy39a,
+					Status:      StatusOrphaned,
+					Description: "this HTTPProxy is not part of a delegation chain from a root HTTPProxy",
+				},
+			},
+		},
+		"tracing policy sampling out of range": {
+			objs: []interface{}{proxy40, s4},
+			want: map[Meta]Status{
+				{name: proxy40.Name, namespace: proxy40.Namespace}: {
+					Object:      proxy40,
+					Status:      StatusInvalid,
+					Description: "tracingPolicy: sampling must be in the range 0-100",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"service connect timeout invalid": {
+			objs: []interface{}{proxy41, s4},
+			want: map[Meta]Status{
+				{name: proxy41.Name, namespace: proxy41.Namespace}: {
+					Object:      proxy41,
+					Status:      StatusInvalid,
+					Description: `route "/foo": service "home": connectTimeout: invalid duration "soon"`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"client validation CA secret missing": {
+			objs: []interface{}{proxy42, s4, sec3},
+			want: map[Meta]Status{
+				{name: proxy42.Name, namespace: proxy42.Namespace}: {
+					Object:      proxy42,
+					Status:      StatusInvalid,
+					Description: `Spec.VirtualHost.TLS.ClientValidation: CA secret "missing" not found or is malformed`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"client validation with tls passthrough": {
+			objs: []interface{}{proxy43, s4},
+			want: map[Meta]Status{
+				{name: proxy43.Name, namespace: proxy43.Namespace}: {
+					Object:      proxy43,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.TLS.ClientValidation: tls.secretName must be specified",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"tls cipher suite not permitted by the listener": {
+			objs: []interface{}{proxy44, s4, sec3},
+			want: map[Meta]Status{
+				{name: proxy44.Name, namespace: proxy44.Namespace}: {
+					Object:      proxy44,
+					Status:      StatusInvalid,
+					Description: `Spec.VirtualHost.TLS: cipherSuites: cipher suite "AES256-SHA" is not permitted by the listener`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"fallback certificate not configured": {
+			objs: []interface{}{proxy45, s4, sec3},
+			want: map[Meta]Status{
+				{name: proxy45.Name, namespace: proxy45.Namespace}: {
+					Object:      proxy45,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.TLS.EnableFallbackCertificate: no fallback certificate is configured",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"fallback certificate with tls passthrough": {
+			objs: []interface{}{proxy46, s4},
+			want: map[Meta]Status{
+				{name: proxy46.Name, namespace: proxy46.Namespace}: {
+					Object:      proxy46,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.TLS.EnableFallbackCertificate: tls.secretName must be specified",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"fallback certificate with tightened tls parameters": {
+			objs: []interface{}{proxy50, s4, sec3},
+			want: map[Meta]Status{
+				{name: proxy50.Name, namespace: proxy50.Namespace}: {
+					Object:      proxy50,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost.TLS.EnableFallbackCertificate: cannot be used with minimumProtocolVersion, maximumProtocolVersion, cipherSuites, or ecdhCurves",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"valid proxy with expired certificate": {
+			objs: []interface{}{proxy47, s4, sec4},
+			want: map[Meta]Status{
+				{name: proxy47.Name, namespace: proxy47.Namespace}: {
+					Object:      proxy47,
+					Status:      StatusValid,
+					Description: `valid HTTPProxy, warning: TLS certificate "roots/expired" expired at ` + expiry.UTC().Format(time.RFC3339),
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"invalid virtual host rate limit policy": {
+			objs: []interface{}{proxy29, s4},
+			want: map[Meta]Status{
+				{name: proxy29.Name, namespace: proxy29.Namespace}: {
+					Object:      proxy29,
+					Status:      StatusInvalid,
+					Description: "Spec.VirtualHost: rateLimitPolicy descriptor 0 entry 0: exactly one of genericKey, requestHeader, or remoteAddress must be specified",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"invalid route rate limit policy": {
+			objs: []interface{}{proxy30, s4},
+			want: map[Meta]Status{
+				{name: proxy30.Name, namespace: proxy30.Namespace}: {
+					Object:      proxy30,
+					Status:      StatusInvalid,
+					Description: `route "/foo": rateLimitPolicy descriptor 0: must have at least one entry`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					RootNamespaces: []string{"roots", "marketing"},
+					FieldLogger:    testLogger(t),
+				},
+			}
+			for _, o := range tc.objs {
+				builder.Source.Insert(o)
+			}
+
+			dag := builder.Build()
+			got := dag.Statuses()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDAGTLSCertificateDelegationStatus(t *testing.T) {
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard",
+			Namespace: "secret",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata("certificate", "key"),
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	// proxy1 uses the secret/wildcard certificate.
+	proxy1 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: "secret/wildcard",
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// ir1 uses the secret/wildcard certificate.
+	ir1 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: "secret/wildcard",
+				},
+			},
+			Routes: []ingressroutev1.Route{{
+				Match: "/",
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// d1 delegates secret/wildcard to the default namespace.
+	d1 := &projcontour.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "delegation",
+			Namespace: "secret",
+		},
+		Spec: projcontour.TLSCertificateDelegationSpec{
+			Delegations: []projcontour.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"default"},
+			}},
+		},
+	}
+
+	// d2 delegates secret/wildcard to all namespaces.
+	d2 := &ingressroutev1.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "legacy",
+			Namespace: "secret",
+		},
+		Spec: ingressroutev1.TLSCertificateDelegationSpec{
+			Delegations: []ingressroutev1.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"*"},
+			}},
+		},
+	}
+
+	// d3 delegates a secret which does not exist.
+	d3 := &projcontour.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "missing",
+			Namespace: "secret",
+		},
+		Spec: projcontour.TLSCertificateDelegationSpec{
+			Delegations: []projcontour.CertificateDelegation{{
+				SecretName:       "missing",
+				TargetNamespaces: []string{"default"},
+			}},
+		},
+	}
+
+	// d4 delegates secret/wildcard to the kube-system namespace.
+	d4 := &projcontour.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-system",
+			Namespace: "secret",
+		},
+		Spec: projcontour.TLSCertificateDelegationSpec{
+			Delegations: []projcontour.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"kube-system"},
+			}},
+		},
+	}
+
+	// d5 delegates secret/wildcard to the default namespace, as d1 does.
+	d5 := &ingressroutev1.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "duplicate",
+			Namespace: "secret",
+		},
+		Spec: ingressroutev1.TLSCertificateDelegationSpec{
+			Delegations: []ingressroutev1.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"default"},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		objs []interface{}
+		want map[Meta]Status
+	}{
+		"httpproxy delegated by projectcontour.io delegation": {
+			objs: []interface{}{sec1, s1, proxy1, d1},
+			want: map[Meta]Status{
+				{name: proxy1.Name, namespace: proxy1.Namespace}: {
+					Object:      proxy1,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy, TLS certificate delegated by projectcontour.io TLSCertificateDelegation secret/delegation",
+					Vhost:       "example.com",
+				},
+				{name: d1.Name, namespace: d1.Namespace}: {Object: d1, Status: StatusValid, Description: "valid TLSCertificateDelegation"},
+			},
+		},
+		"httpproxy delegated by contour.heptio.com delegation": {
+			objs: []interface{}{sec1, s1, proxy1, d2},
+			want: map[Meta]Status{
+				{name: proxy1.Name, namespace: proxy1.Namespace}: {
+					Object:      proxy1,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy, TLS certificate delegated by contour.heptio.com TLSCertificateDelegation secret/legacy",
+					Vhost:       "example.com",
+				},
+				{name: d2.Name, namespace: d2.Namespace}: {Object: d2, Status: StatusValid, Description: "valid TLSCertificateDelegation"},
+			},
+		},
+		"ingressroute delegated by projectcontour.io delegation": {
+			objs: []interface{}{sec1, s1, ir1, d1},
+			want: map[Meta]Status{
+				{name: ir1.Name, namespace: ir1.Namespace}: {
+					Object:      ir1,
+					Status:      StatusValid,
+					Description: "valid IngressRoute, TLS certificate delegated by projectcontour.io TLSCertificateDelegation secret/delegation",
+					Vhost:       "example.com",
+				},
+				{name: d1.Name, namespace: d1.Namespace}: {Object: d1, Status: StatusValid, Description: "valid TLSCertificateDelegation"},
+			},
+		},
+		"projectcontour.io delegation takes precedence": {
+			objs: []interface{}{sec1, s1, proxy1, d1, d2},
+			want: map[Meta]Status{
+				{name: proxy1.Name, namespace: proxy1.Namespace}: {
+					Object:      proxy1,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy, TLS certificate delegated by projectcontour.io TLSCertificateDelegation secret/delegation",
+					Vhost:       "example.com",
+				},
+				{name: d1.Name, namespace: d1.Namespace}: {Object: d1, Status: StatusValid, Description: "valid TLSCertificateDelegation"},
+				{name: d2.Name, namespace: d2.Namespace}: {
+					Object:      d2,
+					Status:      StatusInvalid,
+					Description: `secret "wildcard": delegation to namespace "*" conflicts with projectcontour.io TLSCertificateDelegation secret/delegation`,
+				},
+			},
+		},
+		"identical delegation is superseded": {
+			objs: []interface{}{sec1, s1, proxy1, d1, d5},
+			want: map[Meta]Status{
+				{name: proxy1.Name, namespace: proxy1.Namespace}: {
+					Object:      proxy1,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy, TLS certificate delegated by projectcontour.io TLSCertificateDelegation secret/delegation",
+					Vhost:       "example.com",
+				},
+				{name: d1.Name, namespace: d1.Namespace}: {Object: d1, Status: StatusValid, Description: "valid TLSCertificateDelegation"},
+				{name: d5.Name, namespace: d5.Namespace}: {
+					Object:      d5,
+					Status:      StatusValid,
+					Description: `valid TLSCertificateDelegation, delegation of secret "wildcard" superseded by projectcontour.io TLSCertificateDelegation secret/delegation`,
+				},
+			},
+		},
+		"dangling delegation": {
+			objs: []interface{}{sec1, d3},
+			want: map[Meta]Status{
+				{name: d3.Name, namespace: d3.Namespace}: {
+					Object:      d3,
+					Status:      StatusInvalid,
+					Description: `secret "missing" not found or is malformed`,
+				},
+			},
+		},
+		"delegation not permitted": {
+			objs: []interface{}{sec1, s1, proxy1, d4},
+			want: map[Meta]Status{
+				{name: proxy1.Name, namespace: proxy1.Namespace}: {
+					Object:      proxy1,
+					Status:      StatusInvalid,
+					Description: "secret/wildcard: certificate delegation not permitted",
+					Vhost:       "example.com",
+				},
+				{name: d4.Name, namespace: d4.Namespace}: {Object: d4, Status: StatusValid, Description: "valid TLSCertificateDelegation"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: testLogger(t),
+				},
+			}
 			for _, o := range tc.objs {
 				builder.Source.Insert(o)
 			}
@@ -1707,3 +3100,163 @@ func TestDAGHTTPProxyStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestDAGCertificateRecheck(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	cert, key, err := certgen.NewCA("example.com", expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example",
+			Namespace: "roots",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(string(cert), string(key)),
+	}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "home",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+	proxy := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec.Name,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		now         time.Time
+		want        string
+		wantRecheck time.Time
+	}{
+		"valid": {
+			now:         expiry.Add(-time.Minute),
+			want:        "valid HTTPProxy",
+			wantRecheck: expiry.Add(time.Second),
+		},
+		"expired": {
+			now:  expiry.Add(time.Minute),
+			want: `valid HTTPProxy, warning: TLS certificate "roots/example" expired at ` + expiry.UTC().Format(time.RFC3339),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: testLogger(t),
+				},
+				Clock: func() time.Time { return tc.now },
+			}
+			builder.Source.Insert(sec)
+			builder.Source.Insert(svc)
+			builder.Source.Insert(proxy)
+
+			dag := builder.Build()
+			got := dag.Statuses()[Meta{name: proxy.Name, namespace: proxy.Namespace}].Description
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+			if recheck := dag.Recheck(); !recheck.Equal(tc.wantRecheck) {
+				t.Fatalf("expected recheck at %v, got %v", tc.wantRecheck, recheck)
+			}
+		})
+	}
+}
+
+func TestDAGRateLimitWarning(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "home",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+	proxy := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+				RateLimitPolicy: &projcontour.RateLimitPolicy{
+					Descriptors: []projcontour.RateLimitDescriptor{{
+						Entries: []projcontour.RateLimitDescriptorEntry{{
+							RemoteAddress: &projcontour.RemoteAddressDescriptor{},
+						}},
+					}},
+				},
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		configured bool
+		want       string
+	}{
+		"rate limit service configured": {
+			configured: true,
+			want:       "valid HTTPProxy",
+		},
+		"no rate limit service": {
+			configured: false,
+			want:       "valid HTTPProxy, warning: rateLimitPolicy has no effect, no rate limit service is configured",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: testLogger(t),
+				},
+				RateLimitServiceConfigured: tc.configured,
+			}
+			builder.Source.Insert(svc)
+			builder.Source.Insert(proxy)
+
+			got := builder.Build().Statuses()[Meta{name: proxy.Name, namespace: proxy.Namespace}].Description
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
+)
+
+var (
+	// DefaultCipherSuites is the list of default ciphers used by contour 1.9.1.
+	// A handful are commented out, as they're arguably less secure. They're also
+	// unnecessary - most of the clients that might need to use the commented
+	// ciphers are unable to connect without TLS 1.0, which contour never enables.
+	//
+	// This list is ignored if the client and server negotiate TLS 1.3.
+	//
+	// The commented ciphers are left in place to simplify updating this list for future
+	// versions of envoy.
+	DefaultCipherSuites = []string{
+		"[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305]",
+		"[ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]",
+		"ECDHE-ECDSA-AES128-SHA",
+		"ECDHE-RSA-AES128-SHA",
+		//"AES128-GCM-SHA256",
+		//"AES128-SHA",
+		"ECDHE-ECDSA-AES256-GCM-SHA384",
+		"ECDHE-RSA-AES256-GCM-SHA384",
+		"ECDHE-ECDSA-AES256-SHA",
+		"ECDHE-RSA-AES256-SHA",
+		//"AES256-GCM-SHA384",
+		//"AES256-SHA",
+	}
+
+	// DefaultECDHCurves are the ECDH curves Envoy offers
+	// when the TLS policy does not restrict them.
+	DefaultECDHCurves = []string{"X25519", "P-256"}
+)
+
+// cipherSuites are the cipher suites Envoy supports for TLS 1.2 and earlier.
+var cipherSuites = map[string]bool{
+	"ECDHE-ECDSA-AES128-GCM-SHA256": true,
+	"ECDHE-RSA-AES128-GCM-SHA256":   true,
+	"ECDHE-ECDSA-AES256-GCM-SHA384": true,
+	"ECDHE-RSA-AES256-GCM-SHA384":   true,
+	"ECDHE-ECDSA-CHACHA20-POLY1305": true,
+	"ECDHE-RSA-CHACHA20-POLY1305":   true,
+	"ECDHE-PSK-CHACHA20-POLY1305":   true,
+	"ECDHE-ECDSA-AES128-SHA":        true,
+	"ECDHE-RSA-AES128-SHA":          true,
+	"ECDHE-PSK-AES128-CBC-SHA":      true,
+	"ECDHE-ECDSA-AES256-SHA":        true,
+	"ECDHE-RSA-AES256-SHA":          true,
+	"ECDHE-PSK-AES256-CBC-SHA":      true,
+	"AES128-GCM-SHA256":             true,
+	"AES256-GCM-SHA384":             true,
+	"AES128-SHA":                    true,
+	"PSK-AES128-CBC-SHA":            true,
+	"AES256-SHA":                    true,
+	"PSK-AES256-CBC-SHA":            true,
+	"DES-CBC3-SHA":                  true,
+}
+
+// ecdhCurves are the ECDH curves Envoy supports.
+var ecdhCurves = map[string]bool{
+	"X25519": true,
+	"P-256":  true,
+	"P-384":  true,
+	"P-521":  true,
+}
+
+// TLSPolicy restricts the TLS protocol versions, cipher suites,
+// and ECDH curves which may be negotiated with clients.
+type TLSPolicy struct {
+	// MinimumProtocolVersion is the minimum TLS version.
+	// If not set, TLS 1.1 is the minimum.
+	MinimumProtocolVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
+
+	// MaximumProtocolVersion is the maximum TLS version.
+	// If not set, TLS 1.3 is the maximum.
+	MaximumProtocolVersion envoy_api_v2_auth.TlsParameters_TlsProtocol
+
+	// CipherSuites are the cipher suites, or equal preference
+	// groups of cipher suites such as "[A|B]", negotiated for
+	// TLS 1.2 and earlier. If empty, DefaultCipherSuites is used.
+	CipherSuites []string
+
+	// ECDHCurves are the ECDH curves offered.
+	// If empty, DefaultECDHCurves is used.
+	ECDHCurves []string
+}
+
+// ProtoVersion returns the TLS protocol version named by version,
+// one of 1.1, 1.2, or 1.3, or zero if version is blank.
+func ProtoVersion(version string) (envoy_api_v2_auth.TlsParameters_TlsProtocol, error) {
+	switch version {
+	case "":
+		return 0, nil
+	case "1.1":
+		return envoy_api_v2_auth.TlsParameters_TLSv1_1, nil
+	case "1.2":
+		return envoy_api_v2_auth.TlsParameters_TLSv1_2, nil
+	case "1.3":
+		return envoy_api_v2_auth.TlsParameters_TLSv1_3, nil
+	default:
+		return 0, fmt.Errorf("invalid TLS protocol version %q", version)
+	}
+}
+
+// ValidateCipherSuites returns an error if any of suites is not
+// a cipher suite supported by Envoy, or an equal preference group
+// of them.
+func ValidateCipherSuites(suites []string) error {
+	for _, s := range suites {
+		for _, name := range cipherSuiteNames(s) {
+			if !cipherSuites[name] {
+				return fmt.Errorf("unknown cipher suite %q", name)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateECDHCurves returns an error if any of curves
+// is not an ECDH curve supported by Envoy.
+func ValidateECDHCurves(curves []string) error {
+	for _, c := range curves {
+		if !ecdhCurves[c] {
+			return fmt.Errorf("unknown ECDH curve %q", c)
+		}
+	}
+	return nil
+}
+
+// cipherSuiteNames returns the names of the cipher suites in
+// suite, which may be an equal preference group such as "[A|B]".
+func cipherSuiteNames(suite string) []string {
+	if strings.HasPrefix(suite, "[") && strings.HasSuffix(suite, "]") {
+		return strings.Split(suite[1:len(suite)-1], "|")
+	}
+	return []string{suite}
+}
+
+// tlsPolicy returns the TLS policy requested by the TLS settings of
+// a virtual host. The settings may only tighten policy, the TLS
+// policy of the HTTPS listener, so an error is returned if they
+// permit a protocol version, cipher suite, or curve policy does not.
+func tlsPolicy(tls *projcontour.TLS, policy TLSPolicy) (*TLSPolicy, error) {
+	maxVersion, err := ProtoVersion(tls.MaximumProtocolVersion)
+	if err != nil {
+		return nil, fmt.Errorf("maximumProtocolVersion: %s", err)
+	}
+	if policy.MaximumProtocolVersion == 0 {
+		policy.MaximumProtocolVersion = envoy_api_v2_auth.TlsParameters_TLSv1_3
+	}
+	if maxVersion > policy.MaximumProtocolVersion {
+		return nil, fmt.Errorf("maximumProtocolVersion %q exceeds the maximum protocol version of the listener", tls.MaximumProtocolVersion)
+	}
+	minVersion := MinProtoVersion(tls.MinimumProtocolVersion)
+	if minVersion > policy.MaximumProtocolVersion || (maxVersion != 0 && minVersion > maxVersion) {
+		return nil, fmt.Errorf("minimumProtocolVersion %q exceeds the maximum protocol version", tls.MinimumProtocolVersion)
+	}
+	if maxVersion != 0 && maxVersion < policy.MinimumProtocolVersion {
+		return nil, fmt.Errorf("maximumProtocolVersion %q is less than the minimum protocol version of the listener", tls.MaximumProtocolVersion)
+	}
+
+	if err := ValidateCipherSuites(tls.CipherSuites); err != nil {
+		return nil, fmt.Errorf("cipherSuites: %s", err)
+	}
+	permitted := policy.CipherSuites
+	if len(permitted) == 0 {
+		permitted = DefaultCipherSuites
+	}
+	if name, ok := subset(tls.CipherSuites, permitted, cipherSuiteNames); !ok {
+		return nil, fmt.Errorf("cipherSuites: cipher suite %q is not permitted by the listener", name)
+	}
+
+	if err := ValidateECDHCurves(tls.ECDHCurves); err != nil {
+		return nil, fmt.Errorf("ecdhCurves: %s", err)
+	}
+	permitted = policy.ECDHCurves
+	if len(permitted) == 0 {
+		permitted = DefaultECDHCurves
+	}
+	if name, ok := subset(tls.ECDHCurves, permitted, func(c string) []string { return []string{c} }); !ok {
+		return nil, fmt.Errorf("ecdhCurves: curve %q is not permitted by the listener", name)
+	}
+
+	return &TLSPolicy{
+		MinimumProtocolVersion: minVersion,
+		MaximumProtocolVersion: maxVersion,
+		CipherSuites:           tls.CipherSuites,
+		ECDHCurves:             tls.ECDHCurves,
+	}, nil
+}
+
+// subset returns true if every name in values, as expanded by names,
+// is also in permitted. Otherwise the first name not permitted is
+// returned.
+func subset(values, permitted []string, names func(string) []string) (string, bool) {
+	ok := make(map[string]bool)
+	for _, p := range permitted {
+		for _, name := range names(p) {
+			ok[name] = true
+		}
+	}
+	for _, v := range values {
+		for _, name := range names(v) {
+			if !ok[name] {
+				return name, false
+			}
+		}
+	}
+	return "", true
+}
+
+// certificateWarning returns a warning if the tls certificate of
+// secret has expired, or is not yet valid, at now, and the time after
+// now at which the warning changes, or the zero time if it does not.
+// Certificates which cannot be parsed are left for Envoy to reject.
+func certificateWarning(secret *Secret, now time.Time) (string, time.Time) {
+	block, _ := pem.Decode(secret.Cert())
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", time.Time{}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", time.Time{}
+	}
+	name := secret.Namespace() + "/" + secret.Name()
+	switch {
+	case now.After(cert.NotAfter):
+		return fmt.Sprintf("TLS certificate %q expired at %s", name, cert.NotAfter.UTC().Format(time.RFC3339)), time.Time{}
+	case now.Before(cert.NotBefore):
+		return fmt.Sprintf("TLS certificate %q is not valid before %s", name, cert.NotBefore.UTC().Format(time.RFC3339)), cert.NotBefore
+	default:
+		// the certificate has expired once now is after NotAfter.
+		return "", cert.NotAfter.Add(time.Second)
+	}
+}
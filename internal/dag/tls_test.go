@@ -0,0 +1,264 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/google/go-cmp/cmp"
+	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
+	"github.com/heptio/contour/internal/certgen"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProtoVersion(t *testing.T) {
+	tests := map[string]struct {
+		version string
+		want    envoy_api_v2_auth.TlsParameters_TlsProtocol
+		wantErr string
+	}{
+		"not set": {
+			version: "",
+			want:    0,
+		},
+		"1.2": {
+			version: "1.2",
+			want:    envoy_api_v2_auth.TlsParameters_TLSv1_2,
+		},
+		"1.3": {
+			version: "1.3",
+			want:    envoy_api_v2_auth.TlsParameters_TLSv1_3,
+		},
+		"1.0": {
+			version: "1.0",
+			wantErr: `invalid TLS protocol version "1.0"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ProtoVersion(tc.version)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestValidateCipherSuites(t *testing.T) {
+	if err := ValidateCipherSuites(DefaultCipherSuites); err != nil {
+		t.Fatalf("default cipher suites: %v", err)
+	}
+	if err := ValidateECDHCurves(DefaultECDHCurves); err != nil {
+		t.Fatalf("default curves: %v", err)
+	}
+}
+
+func TestTLSPolicy(t *testing.T) {
+	tests := map[string]struct {
+		tls     *projcontour.TLS
+		policy  TLSPolicy
+		want    *TLSPolicy
+		wantErr string
+	}{
+		"not set": {
+			tls: &projcontour.TLS{},
+			want: &TLSPolicy{
+				MinimumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+			},
+		},
+		"tightened": {
+			tls: &projcontour.TLS{
+				MinimumProtocolVersion: "1.2",
+				MaximumProtocolVersion: "1.2",
+				CipherSuites:           []string{"ECDHE-ECDSA-CHACHA20-POLY1305"},
+				ECDHCurves:             []string{"X25519"},
+			},
+			want: &TLSPolicy{
+				MinimumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_2,
+				MaximumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_2,
+				CipherSuites:           []string{"ECDHE-ECDSA-CHACHA20-POLY1305"},
+				ECDHCurves:             []string{"X25519"},
+			},
+		},
+		"invalid maximum protocol version": {
+			tls: &projcontour.TLS{
+				MaximumProtocolVersion: "2.0",
+			},
+			wantErr: `maximumProtocolVersion: invalid TLS protocol version "2.0"`,
+		},
+		"maximum protocol version exceeds listener": {
+			tls: &projcontour.TLS{
+				MaximumProtocolVersion: "1.3",
+			},
+			policy: TLSPolicy{
+				MaximumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_2,
+			},
+			wantErr: `maximumProtocolVersion "1.3" exceeds the maximum protocol version of the listener`,
+		},
+		"minimum protocol version exceeds maximum": {
+			tls: &projcontour.TLS{
+				MinimumProtocolVersion: "1.3",
+				MaximumProtocolVersion: "1.2",
+			},
+			wantErr: `minimumProtocolVersion "1.3" exceeds the maximum protocol version`,
+		},
+		"maximum protocol version below listener minimum": {
+			tls: &projcontour.TLS{
+				MaximumProtocolVersion: "1.2",
+			},
+			policy: TLSPolicy{
+				MinimumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_3,
+			},
+			wantErr: `maximumProtocolVersion "1.2" is less than the minimum protocol version of the listener`,
+		},
+		"unknown cipher suite": {
+			tls: &projcontour.TLS{
+				CipherSuites: []string{"RC4-SHA"},
+			},
+			wantErr: `cipherSuites: unknown cipher suite "RC4-SHA"`,
+		},
+		"cipher suite not in default policy": {
+			tls: &projcontour.TLS{
+				CipherSuites: []string{"AES128-SHA"},
+			},
+			wantErr: `cipherSuites: cipher suite "AES128-SHA" is not permitted by the listener`,
+		},
+		"cipher suite not in listener policy": {
+			tls: &projcontour.TLS{
+				CipherSuites: []string{"[ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]"},
+			},
+			policy: TLSPolicy{
+				CipherSuites: []string{"ECDHE-RSA-AES128-GCM-SHA256"},
+			},
+			wantErr: `cipherSuites: cipher suite "ECDHE-RSA-CHACHA20-POLY1305" is not permitted by the listener`,
+		},
+		"curve not in listener policy": {
+			tls: &projcontour.TLS{
+				ECDHCurves: []string{"P-384"},
+			},
+			wantErr: `ecdhCurves: curve "P-384" is not permitted by the listener`,
+		},
+		"curve in listener policy": {
+			tls: &projcontour.TLS{
+				ECDHCurves: []string{"P-384"},
+			},
+			policy: TLSPolicy{
+				ECDHCurves: []string{"P-256", "P-384"},
+			},
+			want: &TLSPolicy{
+				MinimumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+				ECDHCurves:             []string{"P-384"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tlsPolicy(tc.tls, tc.policy)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.wantErr, gotErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestCertificateWarning(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	cert, key, err := certgen.NewCA("example.com", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &Secret{
+		Object: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata(string(cert), string(key)),
+		},
+	}
+	block, _ := pem.Decode(cert)
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := parsed.NotBefore
+
+	tests := map[string]struct {
+		secret     *Secret
+		now        time.Time
+		want       string
+		wantChange time.Time
+	}{
+		"valid": {
+			secret:     secret,
+			now:        now,
+			want:       "",
+			wantChange: now.Add(time.Hour + time.Second),
+		},
+		"expired": {
+			secret: secret,
+			now:    now.Add(2 * time.Hour),
+			want:   `TLS certificate "default/secret" expired at ` + now.Add(time.Hour).UTC().Format(time.RFC3339),
+		},
+		"not yet valid": {
+			secret:     secret,
+			now:        notBefore.Add(-time.Hour),
+			want:       `TLS certificate "default/secret" is not valid before ` + notBefore.UTC().Format(time.RFC3339),
+			wantChange: notBefore,
+		},
+		"not a certificate": {
+			secret: &Secret{
+				Object: &v1.Secret{
+					Data: secretdata("certificate", "key"),
+				},
+			},
+			now:  now,
+			want: "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, change := certificateWarning(tc.secret, tc.now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+			if !change.Equal(tc.wantChange) {
+				t.Fatalf("expected change at %v, got %v", tc.wantChange, change)
+			}
+		})
+	}
+}
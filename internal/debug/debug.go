@@ -16,10 +16,12 @@
 package debug
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/pprof"
 
 	"github.com/heptio/contour/internal/dag"
+	cgrpc "github.com/heptio/contour/internal/grpc"
 	"github.com/heptio/contour/internal/httpsvc"
 )
 
@@ -28,6 +30,10 @@ type Service struct {
 	httpsvc.Service
 
 	Builder *dag.Builder
+
+	// XDSStatus records the configuration ACKed
+	// and NACKed by each connected Envoy.
+	XDSStatus *cgrpc.StatusCache
 }
 
 // Start fulfills the g.Start contract.
@@ -35,6 +41,7 @@ type Service struct {
 func (svc *Service) Start(stop <-chan struct{}) error {
 	registerProfile(&svc.ServeMux)
 	registerDotWriter(&svc.ServeMux, svc.Builder)
+	registerXDSStatus(&svc.ServeMux, svc.XDSStatus)
 	return svc.Service.Start(stop)
 }
 
@@ -58,3 +65,14 @@ func registerDotWriter(mux *http.ServeMux, builder *dag.Builder) {
 		dw.writeDot(w)
 	})
 }
+
+func registerXDSStatus(mux *http.ServeMux, status *cgrpc.StatusCache) {
+	mux.HandleFunc("/debug/xds", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(status.Nodes()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -57,6 +57,8 @@ func (c *ctx) writeVertex(v dag.Vertex) {
 		fmt.Fprintf(c.w, `"%p" [shape=record, label="{prefix|%s}"]`+"\n", v, v.Prefix)
 	case *dag.RegexRoute:
 		fmt.Fprintf(c.w, `"%p" [shape=record, label="{regex|%s}"]`+"\n", v, v.Regex)
+	case *dag.ExactRoute:
+		fmt.Fprintf(c.w, `"%p" [shape=record, label="{exact|%s}"]`+"\n", v, v.Path)
 	case *dag.TCPProxy:
 		fmt.Fprintf(c.w, `"%p" [shape=record, label="{tcpproxy}"]`+"\n", v)
 	case *dag.Cluster:
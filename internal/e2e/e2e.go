@@ -106,12 +106,16 @@ func setup(t *testing.T, opts ...func(*contour.EventHandler)) (cache.ResourceEve
 	discard := logrus.New()
 	discard.Out = new(discardWriter)
 	// Resource types in xDS v2.
-	srv := cgrpc.NewAPI(discard, map[string]cgrpc.Resource{
-		ch.ClusterCache.TypeURL():  &ch.ClusterCache,
-		ch.RouteCache.TypeURL():    &ch.RouteCache,
-		ch.ListenerCache.TypeURL(): &ch.ListenerCache,
-		ch.SecretCache.TypeURL():   &ch.SecretCache,
-		et.TypeURL():               et,
+	srv := cgrpc.NewAPI(discard, cgrpc.APIConfig{
+		Resources: map[string]cgrpc.Resource{
+			ch.ClusterCache.TypeURL():  &ch.ClusterCache,
+			ch.RouteCache.TypeURL():    &ch.RouteCache,
+			ch.ListenerCache.TypeURL(): &ch.ListenerCache,
+			ch.SecretCache.TypeURL():   &ch.SecretCache,
+			et.TypeURL():               et,
+		},
+		Status:  new(cgrpc.StatusCache),
+		Metrics: ch.Metrics,
 	})
 
 	var g workgroup.Group
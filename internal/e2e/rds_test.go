@@ -22,6 +22,8 @@ import (
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/golang/protobuf/ptypes"
 	ingressroutev1 "github.com/heptio/contour/apis/contour/v1beta1"
 	projcontour "github.com/heptio/contour/apis/projectcontour/v1alpha1"
 	"github.com/heptio/contour/internal/contour"
@@ -2884,6 +2886,265 @@ func assertRDS(t *testing.T, cc *grpc.ClientConn, versioninfo string, ingress_ht
 	}, streamRDS(t, cc))
 }
 
+// A client may complete a TLS handshake for one virtual host and send
+// the Host header of another. Virtual hosts which validate client
+// certificates must not be reachable this way.
+func TestRDSHTTPProxyClientValidationMismatchedSNI(t *testing.T) {
+	rh, cc, done := setup(t)
+	defer done()
+
+	rh.OnAdd(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-tls",
+			Namespace: "default",
+		},
+		Type: "kubernetes.io/tls",
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte("certificate"),
+			v1.TLSPrivateKeyKey: []byte("key"),
+		},
+	})
+	rh.OnAdd(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			envoy.CACertificateKey: []byte("ca"),
+		},
+	})
+	rh.OnAdd(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backend",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	})
+	rh.OnAdd(&projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mtls",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "secure.example.com",
+				TLS: &projcontour.TLS{
+					SecretName: "example-tls",
+					ClientValidation: &projcontour.DownstreamValidation{
+						CACertificate: "ca",
+					},
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "backend",
+					Port: 80,
+				}},
+			}},
+		},
+	})
+	rh.OnAdd(&projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "www.example.com",
+				TLS: &projcontour.TLS{
+					SecretName: "example-tls",
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "backend",
+					Port: 80,
+				}},
+			}},
+		},
+	})
+
+	tests := map[string]struct {
+		sni, host string
+		want      string
+	}{
+		"matching sni and host": {
+			sni:  "www.example.com",
+			host: "www.example.com",
+			want: "www.example.com",
+		},
+		"client validation, matching sni and host": {
+			sni:  "secure.example.com",
+			host: "secure.example.com",
+			want: "secure.example.com",
+		},
+		"client validation host on another sni": {
+			sni:  "www.example.com",
+			host: "secure.example.com",
+			want: "",
+		},
+		"host on the client validation sni": {
+			sni:  "secure.example.com",
+			host: "www.example.com",
+			want: "",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := routeRequest(t, cc, tc.sni, tc.host)
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// Virtual hosts protected by an authorization service must not be
+// reachable through the filter chain of another virtual host, which
+// does not authorize requests.
+func TestRDSHTTPProxyAuthorizationMismatchedSNI(t *testing.T) {
+	rh, cc, done := setup(t)
+	defer done()
+
+	rh.OnAdd(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-tls",
+			Namespace: "default",
+		},
+		Type: "kubernetes.io/tls",
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte("certificate"),
+			v1.TLSPrivateKeyKey: []byte("key"),
+		},
+	})
+	rh.OnAdd(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "backend",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	})
+	rh.OnAdd(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "auth",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"contour.heptio.com/upstream-protocol.h2c": "9000",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     9000,
+			}},
+		},
+	})
+	rh.OnAdd(&projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "authorized",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "secure.example.com",
+				TLS: &projcontour.TLS{
+					SecretName: "example-tls",
+				},
+				Authorization: &projcontour.AuthorizationServer{
+					Name: "auth",
+					Port: 9000,
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "backend",
+					Port: 80,
+				}},
+			}},
+		},
+	})
+	rh.OnAdd(&projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "www.example.com",
+				TLS: &projcontour.TLS{
+					SecretName: "example-tls",
+				},
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "backend",
+					Port: 80,
+				}},
+			}},
+		},
+	})
+
+	if got := routeRequest(t, cc, "secure.example.com", "secure.example.com"); got != "secure.example.com" {
+		t.Fatalf("expected: %q, got: %q", "secure.example.com", got)
+	}
+	if got := routeRequest(t, cc, "www.example.com", "secure.example.com"); got != "" {
+		t.Fatalf("expected: %q, got: %q", "", got)
+	}
+}
+
+// routeRequest returns the name of the virtual host which Envoy selects
+// for a request to the HTTPS listener with the supplied SNI and Host
+// header, or "" if the request matches no virtual host.
+func routeRequest(t *testing.T, cc *grpc.ClientConn, sni, host string) string {
+	t.Helper()
+	var routeConfig string
+	for _, r := range streamLDS(t, cc).Resources {
+		var l v2.Listener
+		check(t, ptypes.UnmarshalAny(r, &l))
+		if l.Name != contour.ENVOY_HTTPS_LISTENER {
+			continue
+		}
+		for _, fc := range l.FilterChains {
+			for _, name := range fc.FilterChainMatch.GetServerNames() {
+				if name != sni {
+					continue
+				}
+				var hcm http.HttpConnectionManager
+				check(t, ptypes.UnmarshalAny(fc.Filters[0].GetTypedConfig(), &hcm))
+				routeConfig = hcm.GetRds().GetRouteConfigName()
+			}
+		}
+	}
+	if routeConfig == "" {
+		t.Fatalf("no filter chain matches server name %q", sni)
+	}
+
+	for _, r := range streamRDS(t, cc, routeConfig).Resources {
+		var rc v2.RouteConfiguration
+		check(t, ptypes.UnmarshalAny(r, &rc))
+		for _, vh := range rc.VirtualHosts {
+			for _, domain := range vh.Domains {
+				if domain == host {
+					return vh.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
 func streamRDS(t *testing.T, cc *grpc.ClientConn, rn ...string) *v2.DiscoveryResponse {
 	t.Helper()
 	rds := v2.NewRouteDiscoveryServiceClient(cc)
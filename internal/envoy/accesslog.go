@@ -14,20 +14,160 @@
 package envoy
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	accesslogv2 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	_struct "github.com/golang/protobuf/ptypes/struct"
 )
 
-// FileAccessLog returns a new file based access log filter.
+// FileAccessLog returns a new file based access log filter
+// which writes Envoy's default text format.
 func FileAccessLog(path string) []*accesslog.AccessLog {
+	return fileAccessLog(&accesslogv2.FileAccessLog{
+		Path: path,
+	})
+}
+
+// FileAccessLogText returns a new file based access log filter
+// which writes each entry using the supplied format string.
+// A newline is appended to format if it does not end in one.
+func FileAccessLogText(path, format string) []*accesslog.AccessLog {
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	return fileAccessLog(&accesslogv2.FileAccessLog{
+		Path: path,
+		AccessLogFormat: &accesslogv2.FileAccessLog_Format{
+			Format: format,
+		},
+	})
+}
+
+// FileAccessLogJSON returns a new file based access log filter
+// which writes each entry as a JSON object. Each key of fields
+// is a field of the object, and its value the format string of
+// that field.
+func FileAccessLogJSON(path string, fields map[string]string) []*accesslog.AccessLog {
+	jsonFormat := &_struct.Struct{
+		Fields: make(map[string]*_struct.Value),
+	}
+	for k, v := range fields {
+		jsonFormat.Fields[k] = &_struct.Value{
+			Kind: &_struct.Value_StringValue{
+				StringValue: v,
+			},
+		}
+	}
+	return fileAccessLog(&accesslogv2.FileAccessLog{
+		Path: path,
+		AccessLogFormat: &accesslogv2.FileAccessLog_JsonFormat{
+			JsonFormat: jsonFormat,
+		},
+	})
+}
+
+// HTTPGRPCAccessLog returns a new access log filter which sends
+// each entry to the gRPC Access Log Service served by cluster.
+// logName identifies the log to the service.
+func HTTPGRPCAccessLog(cluster, logName string) []*accesslog.AccessLog {
 	return []*accesslog.AccessLog{{
-		Name: wellknown.FileAccessLog,
+		Name: wellknown.HTTPGRPCAccessLog,
 		ConfigType: &accesslog.AccessLog_TypedConfig{
-			TypedConfig: toAny(&accesslogv2.FileAccessLog{
-				Path: path,
-				// TODO(dfc) FileAccessLog_Format elided.
+			TypedConfig: toAny(&accesslogv2.HttpGrpcAccessLogConfig{
+				CommonConfig: &accesslogv2.CommonGrpcAccessLogConfig{
+					LogName: logName,
+					GrpcService: &envoy_api_v2_core.GrpcService{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: cluster,
+							},
+						},
+					},
+				},
 			}),
 		},
 	}}
 }
+
+func fileAccessLog(config *accesslogv2.FileAccessLog) []*accesslog.AccessLog {
+	return []*accesslog.AccessLog{{
+		Name: wellknown.FileAccessLog,
+		ConfigType: &accesslog.AccessLog_TypedConfig{
+			TypedConfig: toAny(config),
+		},
+	}}
+}
+
+// commandOperator matches an Envoy access log command operator,
+// its optional argument, and its optional maximum length.
+var commandOperator = regexp.MustCompile(`%([A-Z_0-9]+)(\([^)]*\))?(:[0-9]+)?%`)
+
+// commandOperators holds the access log command operators
+// supported by Envoy and whether each requires an argument.
+var commandOperators = map[string]bool{
+	"BYTES_RECEIVED":                                false,
+	"BYTES_SENT":                                    false,
+	"DOWNSTREAM_DIRECT_REMOTE_ADDRESS":              false,
+	"DOWNSTREAM_DIRECT_REMOTE_ADDRESS_WITHOUT_PORT": false,
+	"DOWNSTREAM_LOCAL_ADDRESS":                      false,
+	"DOWNSTREAM_LOCAL_ADDRESS_WITHOUT_PORT":         false,
+	"DOWNSTREAM_LOCAL_SUBJECT":                      false,
+	"DOWNSTREAM_LOCAL_URI_SAN":                      false,
+	"DOWNSTREAM_PEER_CERT_V_END":                    false,
+	"DOWNSTREAM_PEER_CERT_V_START":                  false,
+	"DOWNSTREAM_PEER_FINGERPRINT_256":               false,
+	"DOWNSTREAM_PEER_ISSUER":                        false,
+	"DOWNSTREAM_PEER_SERIAL":                        false,
+	"DOWNSTREAM_PEER_SUBJECT":                       false,
+	"DOWNSTREAM_PEER_URI_SAN":                       false,
+	"DOWNSTREAM_REMOTE_ADDRESS":                     false,
+	"DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT":        false,
+	"DOWNSTREAM_TLS_CIPHER":                         false,
+	"DOWNSTREAM_TLS_SESSION_ID":                     false,
+	"DOWNSTREAM_TLS_VERSION":                        false,
+	"DURATION":                                      false,
+	"DYNAMIC_METADATA":                              true,
+	"FILTER_STATE":                                  true,
+	"HOSTNAME":                                      false,
+	"PROTOCOL":                                      false,
+	"REQ":                                           true,
+	"REQUESTED_SERVER_NAME":                         false,
+	"REQUEST_DURATION":                              false,
+	"RESP":                                          true,
+	"RESPONSE_CODE":                                 false,
+	"RESPONSE_CODE_DETAILS":                         false,
+	"RESPONSE_DURATION":                             false,
+	"RESPONSE_FLAGS":                                false,
+	"RESPONSE_TX_DURATION":                          false,
+	"ROUTE_NAME":                                    false,
+	"START_TIME":                                    false,
+	"TRAILER":                                       true,
+	"UPSTREAM_CLUSTER":                              false,
+	"UPSTREAM_HOST":                                 false,
+	"UPSTREAM_LOCAL_ADDRESS":                        false,
+	"UPSTREAM_TRANSPORT_FAILURE_REASON":             false,
+}
+
+// ValidateAccessLogFormat returns an error if format contains
+// a command operator which Envoy does not support.
+func ValidateAccessLogFormat(format string) error {
+	for _, match := range commandOperator.FindAllStringSubmatch(format, -1) {
+		operator, arg := match[1], match[2]
+		needsArg, ok := commandOperators[operator]
+		if !ok {
+			return fmt.Errorf("unknown command operator %q", match[0])
+		}
+		if needsArg && arg == "" {
+			return fmt.Errorf("command operator %q requires an argument", match[0])
+		}
+		if !needsArg && arg != "" && operator != "START_TIME" {
+			return fmt.Errorf("command operator %q does not take an argument", match[0])
+		}
+	}
+	return nil
+}
@@ -16,9 +16,11 @@ package envoy
 import (
 	"testing"
 
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
 	envoy_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	_struct "github.com/golang/protobuf/ptypes/struct"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -48,3 +50,130 @@ func TestFileAccessLog(t *testing.T) {
 		})
 	}
 }
+
+func TestFileAccessLogText(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		want   string
+	}{
+		"newline appended": {
+			format: "%START_TIME% %RESPONSE_CODE%",
+			want:   "%START_TIME% %RESPONSE_CODE%\n",
+		},
+		"newline present": {
+			format: "%START_TIME% %RESPONSE_CODE%\n",
+			want:   "%START_TIME% %RESPONSE_CODE%\n",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := FileAccessLogText("/dev/stdout", tc.format)
+			want := []*envoy_accesslog.AccessLog{{
+				Name: wellknown.FileAccessLog,
+				ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+					TypedConfig: toAny(&accesslog_v2.FileAccessLog{
+						Path: "/dev/stdout",
+						AccessLogFormat: &accesslog_v2.FileAccessLog_Format{
+							Format: tc.want,
+						},
+					}),
+				},
+			}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestFileAccessLogJSON(t *testing.T) {
+	got := FileAccessLogJSON("/dev/stdout", map[string]string{
+		"status": "%RESPONSE_CODE%",
+	})
+	want := []*envoy_accesslog.AccessLog{{
+		Name: wellknown.FileAccessLog,
+		ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+			TypedConfig: toAny(&accesslog_v2.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &accesslog_v2.FileAccessLog_JsonFormat{
+					JsonFormat: &_struct.Struct{
+						Fields: map[string]*_struct.Value{
+							"status": {
+								Kind: &_struct.Value_StringValue{
+									StringValue: "%RESPONSE_CODE%",
+								},
+							},
+						},
+					},
+				},
+			}),
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestHTTPGRPCAccessLog(t *testing.T) {
+	got := HTTPGRPCAccessLog("contour", "ingress")
+	want := []*envoy_accesslog.AccessLog{{
+		Name: wellknown.HTTPGRPCAccessLog,
+		ConfigType: &envoy_accesslog.AccessLog_TypedConfig{
+			TypedConfig: toAny(&accesslog_v2.HttpGrpcAccessLogConfig{
+				CommonConfig: &accesslog_v2.CommonGrpcAccessLogConfig{
+					LogName: "ingress",
+					GrpcService: &envoy_api_v2_core.GrpcService{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: "contour",
+							},
+						},
+					},
+				},
+			}),
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestValidateAccessLogFormat(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		want   string
+	}{
+		"plain text": {
+			format: "hello",
+		},
+		"operators": {
+			format: "[%START_TIME%] \"%REQ(:METHOD)% %REQ(X-ENVOY-ORIGINAL-PATH?:PATH):256%\" %RESPONSE_CODE%",
+		},
+		"start time with format": {
+			format: "%START_TIME(%s.%3f)%",
+		},
+		"unknown operator": {
+			format: "%RESPONSE_STATUS%",
+			want:   `unknown command operator "%RESPONSE_STATUS%"`,
+		},
+		"missing argument": {
+			format: "%REQ%",
+			want:   `command operator "%REQ%" requires an argument`,
+		},
+		"unexpected argument": {
+			format: "%DURATION(ms)%",
+			want:   `command operator "%DURATION(ms)%" does not take an argument`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			if err := ValidateAccessLogFormat(tc.format); err != nil {
+				got = err.Error()
+			}
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
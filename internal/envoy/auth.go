@@ -16,33 +16,28 @@ package envoy
 import (
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/heptio/contour/internal/dag"
+	"github.com/heptio/contour/internal/protobuf"
 )
 
-var (
-	// This is the list of default ciphers used by contour 1.9.1. A handful are
-	// commented out, as they're arguably less secure. They're also unnecessary
-	// - most of the clients that might need to use the commented ciphers are
-	// unable to connect without TLS 1.0, which contour never enables.
-	//
-	// This list is ignored if the client and server negotiate TLS 1.3.
-	//
-	// The commented ciphers are left in place to simplify updating this list for future
-	// versions of envoy.
-	ciphers = []string{
-		"[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305]",
-		"[ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]",
-		"ECDHE-ECDSA-AES128-SHA",
-		"ECDHE-RSA-AES128-SHA",
-		//"AES128-GCM-SHA256",
-		//"AES128-SHA",
-		"ECDHE-ECDSA-AES256-GCM-SHA384",
-		"ECDHE-RSA-AES256-GCM-SHA384",
-		"ECDHE-ECDSA-AES256-SHA",
-		"ECDHE-RSA-AES256-SHA",
-		//"AES256-GCM-SHA384",
-		//"AES256-SHA",
+// TLSParameters returns the TLS parameters negotiated with clients.
+// If max is not set, TLS 1.3 is the maximum version. If cipherSuites
+// is empty, dag.DefaultCipherSuites is used, and if ecdhCurves is
+// empty, Envoy's default curves are used.
+func TLSParameters(min, max envoy_api_v2_auth.TlsParameters_TlsProtocol, cipherSuites, ecdhCurves []string) *envoy_api_v2_auth.TlsParameters {
+	if max == 0 {
+		max = envoy_api_v2_auth.TlsParameters_TLSv1_3
 	}
-)
+	if len(cipherSuites) == 0 {
+		cipherSuites = dag.DefaultCipherSuites
+	}
+	return &envoy_api_v2_auth.TlsParameters{
+		TlsMinimumProtocolVersion: min,
+		TlsMaximumProtocolVersion: max,
+		CipherSuites:              cipherSuites,
+		EcdhCurves:                ecdhCurves,
+	}
+}
 
 // UpstreamTLSContext creates an envoy_api_v2_auth.UpstreamTlsContext. By default
 // UpstreamTLSContext returns a HTTP/1.1 TLS enabled context. A list of
@@ -91,15 +86,14 @@ func validationContext(ca []byte, subjectName string) *envoy_api_v2_auth.CommonT
 	}
 }
 
-// DownstreamTLSContext creates a new DownstreamTlsContext.
-func DownstreamTLSContext(secretName string, tlsMinProtoVersion envoy_api_v2_auth.TlsParameters_TlsProtocol, alpnProtos ...string) *envoy_api_v2_auth.DownstreamTlsContext {
-	return &envoy_api_v2_auth.DownstreamTlsContext{
+// DownstreamTLSContext creates a new DownstreamTlsContext which negotiates
+// tlsParams. If clientValidation is not nil, clients must present a
+// certificate signed by one of its CA certificates, which are served
+// over SDS.
+func DownstreamTLSContext(secretName string, tlsParams *envoy_api_v2_auth.TlsParameters, clientValidation *dag.DownstreamValidation, alpnProtos ...string) *envoy_api_v2_auth.DownstreamTlsContext {
+	context := &envoy_api_v2_auth.DownstreamTlsContext{
 		CommonTlsContext: &envoy_api_v2_auth.CommonTlsContext{
-			TlsParams: &envoy_api_v2_auth.TlsParameters{
-				TlsMinimumProtocolVersion: tlsMinProtoVersion,
-				TlsMaximumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_3,
-				CipherSuites:              ciphers,
-			},
+			TlsParams: tlsParams,
 			TlsCertificateSdsSecretConfigs: []*envoy_api_v2_auth.SdsSecretConfig{{
 				Name:      secretName,
 				SdsConfig: ConfigSource("contour"),
@@ -107,4 +101,16 @@ func DownstreamTLSContext(secretName string, tlsMinProtoVersion envoy_api_v2_aut
 			AlpnProtocols: alpnProtos,
 		},
 	}
+
+	if clientValidation != nil {
+		context.CommonTlsContext.ValidationContextType = &envoy_api_v2_auth.CommonTlsContext_ValidationContextSdsSecretConfig{
+			ValidationContextSdsSecretConfig: &envoy_api_v2_auth.SdsSecretConfig{
+				Name:      CASecretname(clientValidation.CACertificate),
+				SdsConfig: ConfigSource("contour"),
+			},
+		}
+		context.RequireClientCertificate = protobuf.Bool(true)
+	}
+
+	return context
 }
@@ -19,6 +19,7 @@ import (
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/dag"
 )
 
 func TestUpstreamTLSContext(t *testing.T) {
@@ -82,3 +83,42 @@ func TestUpstreamTLSContext(t *testing.T) {
 		})
 	}
 }
+
+func TestTLSParameters(t *testing.T) {
+	tests := map[string]struct {
+		min, max     envoy_api_v2_auth.TlsParameters_TlsProtocol
+		cipherSuites []string
+		ecdhCurves   []string
+		want         *envoy_api_v2_auth.TlsParameters
+	}{
+		"defaults": {
+			min: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+			want: &envoy_api_v2_auth.TlsParameters{
+				TlsMinimumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_1,
+				TlsMaximumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_3,
+				CipherSuites:              dag.DefaultCipherSuites,
+			},
+		},
+		"restricted": {
+			min:          envoy_api_v2_auth.TlsParameters_TLSv1_2,
+			max:          envoy_api_v2_auth.TlsParameters_TLSv1_2,
+			cipherSuites: []string{"ECDHE-ECDSA-AES256-GCM-SHA384"},
+			ecdhCurves:   []string{"P-256"},
+			want: &envoy_api_v2_auth.TlsParameters{
+				TlsMinimumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_2,
+				TlsMaximumProtocolVersion: envoy_api_v2_auth.TlsParameters_TLSv1_2,
+				CipherSuites:              []string{"ECDHE-ECDSA-AES256-GCM-SHA384"},
+				EcdhCurves:                []string{"P-256"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := TLSParameters(tc.min, tc.max, tc.cipherSuites, tc.ecdhCurves)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
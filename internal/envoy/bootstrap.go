@@ -91,9 +91,48 @@ func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 		b.StaticResources.Clusters[0].TlsContext = upstreamFileTLSContext(c.GrpcCABundle, c.GrpcClientCert, c.GrpcClientKey)
 	}
 
+	if t := c.Tracing; t != nil {
+		b.StaticResources.Clusters = append(b.StaticResources.Clusters,
+			serviceCluster("tracing", t.ServiceName, t.ServiceNamespace, t.servicePort()))
+		b.Tracing = ZipkinTracing("tracing", t.collectorEndpoint())
+	}
+
+	if s := c.RateLimitService; s != nil {
+		b.StaticResources.Clusters = append(b.StaticResources.Clusters, grpcServiceCluster(s))
+	}
+
+	if s := c.AccessLogService; s != nil {
+		b.StaticResources.Clusters = append(b.StaticResources.Clusters, grpcServiceCluster(s))
+	}
+
 	return b
 }
 
+// grpcServiceCluster returns a cluster for the gRPC service s.
+func grpcServiceCluster(s *GRPCServiceConfig) *api.Cluster {
+	c := serviceCluster(s.ClusterName, s.ServiceName, s.ServiceNamespace, s.ServicePort)
+	c.Http2ProtocolOptions = new(envoy_api_v2_core.Http2ProtocolOptions) // enables http2
+	return c
+}
+
+// serviceCluster returns a cluster named name for
+// the port of a Service running in Kubernetes.
+func serviceCluster(name, serviceName, serviceNamespace string, servicePort int) *api.Cluster {
+	return &api.Cluster{
+		Name:                 name,
+		AltStatName:          strings.Join([]string{serviceNamespace, serviceName, strconv.Itoa(servicePort)}, "_"),
+		ConnectTimeout:       protobuf.Duration(250 * time.Millisecond),
+		ClusterDiscoveryType: ClusterDiscoveryType(api.Cluster_STRICT_DNS),
+		LbPolicy:             api.Cluster_ROUND_ROBIN,
+		LoadAssignment: &api.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: Endpoints(
+				SocketAddress(serviceName+"."+serviceNamespace, servicePort),
+			),
+		},
+	}
+}
+
 func upstreamFileTLSContext(cafile, certfile, keyfile string) *envoy_api_v2_auth.UpstreamTlsContext {
 	context := &envoy_api_v2_auth.UpstreamTlsContext{
 		CommonTlsContext: &envoy_api_v2_auth.CommonTlsContext{
@@ -160,6 +199,59 @@ type BootstrapConfig struct {
 
 	// GrpcClientKey is the filename that contains a client key for secure gRPC with TLS.
 	GrpcClientKey string
+
+	// Tracing, if set, configures Envoy to send spans to a
+	// Zipkin compatible trace collector.
+	Tracing *TracingConfig
+
+	// RateLimitService, if set, defines the cluster of the
+	// external rate limit service.
+	RateLimitService *GRPCServiceConfig
+
+	// AccessLogService, if set, defines the cluster of the
+	// gRPC access log service.
+	AccessLogService *GRPCServiceConfig
+}
+
+// TracingConfig holds the location of a Zipkin compatible
+// trace collector running as a Kubernetes Service.
+type TracingConfig struct {
+	// ServiceName is the name of the collector's Service.
+	ServiceName string
+
+	// ServiceNamespace is the namespace of the collector's Service.
+	ServiceNamespace string
+
+	// ServicePort is the port of the collector's Service.
+	// Defaults to 9411.
+	ServicePort int
+
+	// CollectorEndpoint is the API endpoint of the collector
+	// to which spans are sent. Defaults to /api/v2/spans.
+	CollectorEndpoint string
+}
+
+// GRPCServiceConfig holds the location of a gRPC service,
+// such as a rate limit or access log service, running as a
+// Kubernetes Service.
+type GRPCServiceConfig struct {
+	// ClusterName is the name of the cluster through
+	// which Envoy reaches the service.
+	ClusterName string
+
+	// ServiceName is the name of the service's Service.
+	ServiceName string
+
+	// ServiceNamespace is the namespace of the service's Service.
+	ServiceNamespace string
+
+	// ServicePort is the gRPC port of the service's Service.
+	ServicePort int
+}
+
+func (t *TracingConfig) servicePort() int { return intOrDefault(t.ServicePort, 9411) }
+func (t *TracingConfig) collectorEndpoint() string {
+	return stringOrDefault(t.CollectorEndpoint, "/api/v2/spans")
 }
 
 func (c *BootstrapConfig) xdsAddress() string   { return stringOrDefault(c.XDSAddress, "127.0.0.1") }
@@ -75,7 +75,7 @@ func cluster(cluster *dag.Cluster) *v2.Cluster {
 	c := &v2.Cluster{
 		Name:           Clustername(cluster),
 		AltStatName:    altStatName(service),
-		ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+		ConnectTimeout: protobuf.Duration(connectTimeout(cluster)),
 		LbPolicy:       lbPolicy(cluster.LoadBalancerStrategy),
 		CommonLbConfig: ClusterCommonLBConfig(),
 		HealthChecks:   edshealthcheck(cluster),
@@ -153,6 +153,15 @@ func lbPolicy(strategy string) v2.Cluster_LbPolicy {
 	}
 }
 
+// connectTimeout returns the timeout for new connections to the
+// upstream hosts of c, or 250ms if c does not set one.
+func connectTimeout(c *dag.Cluster) time.Duration {
+	if c.ConnectTimeout > 0 {
+		return c.ConnectTimeout
+	}
+	return 250 * time.Millisecond
+}
+
 func edshealthcheck(c *dag.Cluster) []*envoy_api_v2_core.HealthCheck {
 	if c.HealthCheckPolicy == nil {
 		return nil
@@ -185,6 +194,9 @@ func Clustername(cluster *dag.Cluster) string {
 		buf += uv.CACertificate.Object.ObjectMeta.Name
 		buf += uv.SubjectName
 	}
+	if cluster.ConnectTimeout > 0 {
+		buf += cluster.ConnectTimeout.String()
+	}
 
 	hash := sha1.Sum([]byte(buf))
 	ns := service.Namespace
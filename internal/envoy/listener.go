@@ -21,12 +21,20 @@ import (
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	buffer "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/buffer/v2"
+	extauthz "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	healthcheck "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/health_check/v2"
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rate_limit/v2"
 	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	ratelimitconfig "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/protobuf"
 )
@@ -65,62 +73,260 @@ func Listener(name, address string, port int, lf []*envoy_api_v2_listener.Listen
 
 // HTTPConnectionManager creates a new HTTP Connection Manager filter
 // for the supplied route and access log.
-func HTTPConnectionManager(routename, accessLogPath string) *envoy_api_v2_listener.Filter {
+// Any filters supplied are inserted into the HTTP filter chain before the router.
+func HTTPConnectionManager(routename string, accesslogger []*accesslog.AccessLog, filters ...*http.HttpFilter) *envoy_api_v2_listener.Filter {
+	return HTTPConnectionManagerWithOptions(routename, accesslogger, nil, filters...)
+}
+
+// HTTPConnectionManagerOptions holds the optional settings
+// of an HTTP Connection Manager filter.
+type HTTPConnectionManagerOptions struct {
+	// Filters are the HTTP filters which precede those supplied
+	// to HTTPConnectionManagerWithOptions and the router.
+	// If nil, defaults to DefaultHTTPFilters.
+	Filters []*http.HttpFilter
+
+	// Tracing, if set, traces requests using these settings.
+	Tracing *http.HttpConnectionManager_Tracing
+
+	// IdleTimeout is the time after which a connection with
+	// no active requests is closed. Defaults to 60 seconds.
+	IdleTimeout time.Duration
+
+	// StreamIdleTimeout is the time after which a request with
+	// no activity is reset. If not set, Envoy's default is used.
+	StreamIdleTimeout time.Duration
+
+	// RequestTimeout is the time allowed to receive an entire
+	// request. If not set, Envoy's default is used.
+	RequestTimeout time.Duration
+
+	// DrainTimeout is the time between Envoy telling HTTP/2
+	// clients a connection is draining and closing it.
+	// If not set, Envoy's default is used.
+	DrainTimeout time.Duration
+
+	// ForwardClientCertificate, if set, replaces the
+	// x-forwarded-client-cert header of each request with the
+	// selected details of the client certificate. If not set,
+	// the header is removed.
+	ForwardClientCertificate *dag.ClientCertificateDetails
+}
+
+// HTTPConnectionManagerWithOptions creates a new HTTP Connection Manager
+// filter like HTTPConnectionManager, applying the supplied options.
+// If opts is nil, the defaults are used.
+func HTTPConnectionManagerWithOptions(routename string, accesslogger []*accesslog.AccessLog, opts *HTTPConnectionManagerOptions, filters ...*http.HttpFilter) *envoy_api_v2_listener.Filter {
+	if opts == nil {
+		opts = new(HTTPConnectionManagerOptions)
+	}
+	idleTimeout := opts.IdleTimeout
+	if idleTimeout == 0 {
+		// Sets the idle timeout for HTTP connections to 60 seconds.
+		// This is chosen as a rough default to stop idle connections wasting resources,
+		// without stopping slow connections from being terminated too quickly.
+		idleTimeout = 60 * time.Second
+	}
+
+	var httpFilters []*http.HttpFilter
+	if opts.Filters == nil {
+		httpFilters = DefaultHTTPFilters()
+	} else {
+		httpFilters = append(httpFilters, opts.Filters...)
+	}
+	httpFilters = append(httpFilters, filters...)
+	httpFilters = append(httpFilters, &http.HttpFilter{
+		Name: wellknown.Router,
+	})
+
+	hcm := &http.HttpConnectionManager{
+		StatPrefix: routename,
+		RouteSpecifier: &http.HttpConnectionManager_Rds{
+			Rds: &http.Rds{
+				RouteConfigName: routename,
+				ConfigSource: &envoy_api_v2_core.ConfigSource{
+					ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
+						ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
+							ApiType: envoy_api_v2_core.ApiConfigSource_GRPC,
+							GrpcServices: []*envoy_api_v2_core.GrpcService{{
+								TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+									EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+										ClusterName: "contour",
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+		HttpFilters: httpFilters,
+		HttpProtocolOptions: &envoy_api_v2_core.Http1ProtocolOptions{
+			// Enable support for HTTP/1.0 requests that carry
+			// a Host: header. See #537.
+			AcceptHttp_10: true,
+		},
+		AccessLog:         accesslogger,
+		Tracing:           opts.Tracing,
+		UseRemoteAddress:  protobuf.Bool(true),
+		NormalizePath:     protobuf.Bool(true),
+		IdleTimeout:       protobuf.Duration(idleTimeout),
+		StreamIdleTimeout: optionalDuration(opts.StreamIdleTimeout),
+		RequestTimeout:    optionalDuration(opts.RequestTimeout),
+		DrainTimeout:      optionalDuration(opts.DrainTimeout),
+	}
+	if fcc := opts.ForwardClientCertificate; fcc != nil {
+		hcm.ForwardClientCertDetails = http.HttpConnectionManager_SANITIZE_SET
+		hcm.SetCurrentClientCertDetails = &http.HttpConnectionManager_SetCurrentClientCertDetails{
+			Subject: protobuf.Bool(fcc.Subject),
+			Cert:    fcc.Cert,
+			Chain:   fcc.Chain,
+			Dns:     fcc.DNS,
+			Uri:     fcc.URI,
+		}
+	}
+
 	return &envoy_api_v2_listener.Filter{
 		Name: wellknown.HTTPConnectionManager,
 		ConfigType: &envoy_api_v2_listener.Filter_TypedConfig{
-			TypedConfig: toAny(&http.HttpConnectionManager{
-				StatPrefix: routename,
-				RouteSpecifier: &http.HttpConnectionManager_Rds{
-					Rds: &http.Rds{
-						RouteConfigName: routename,
-						ConfigSource: &envoy_api_v2_core.ConfigSource{
-							ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
-								ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
-									ApiType: envoy_api_v2_core.ApiConfigSource_GRPC,
-									GrpcServices: []*envoy_api_v2_core.GrpcService{{
-										TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
-											EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
-												ClusterName: "contour",
-											},
-										},
-									}},
-								},
-							},
-						},
+			TypedConfig: toAny(hcm),
+		},
+	}
+}
+
+// RateLimitFilter creates a new HTTP rate limit filter which sends the
+// descriptors for each request to the gRPC rate limit service named by
+// cluster. If failOpen is true, requests are allowed when the rate limit
+// service cannot be reached.
+func RateLimitFilter(cluster, domain string, timeout time.Duration, failOpen bool) *http.HttpFilter {
+	rl := &ratelimit.RateLimit{
+		Domain:          domain,
+		FailureModeDeny: !failOpen,
+		RateLimitService: &ratelimitconfig.RateLimitServiceConfig{
+			GrpcService: &envoy_api_v2_core.GrpcService{
+				TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+						ClusterName: cluster,
 					},
 				},
-				HttpFilters: []*http.HttpFilter{{
-					Name: wellknown.Gzip,
-				}, {
-					Name: wellknown.GRPCWeb,
-				}, {
-					Name: wellknown.Router,
-				}},
-				HttpProtocolOptions: &envoy_api_v2_core.Http1ProtocolOptions{
-					// Enable support for HTTP/1.0 requests that carry
-					// a Host: header. See #537.
-					AcceptHttp_10: true,
+			},
+		},
+	}
+	if timeout > 0 {
+		rl.Timeout = protobuf.Duration(timeout)
+	}
+	return &http.HttpFilter{
+		Name: wellknown.HTTPRateLimit,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(rl),
+		},
+	}
+}
+
+// ExtAuthzFilter creates a new HTTP external authorization filter which
+// checks each request with the gRPC authorization service named by
+// cluster. If failOpen is true, requests are allowed when the
+// authorization service cannot be reached.
+func ExtAuthzFilter(cluster string, failOpen bool, timeout time.Duration) *http.HttpFilter {
+	grpc := &envoy_api_v2_core.GrpcService{
+		TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+			EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+				ClusterName: cluster,
+			},
+		},
+	}
+	if timeout > 0 {
+		grpc.Timeout = protobuf.Duration(timeout)
+	}
+	return &http.HttpFilter{
+		Name: wellknown.HTTPExternalAuthorization,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&extauthz.ExtAuthz{
+				Services: &extauthz.ExtAuthz_GrpcService{
+					GrpcService: grpc,
 				},
-				AccessLog:        FileAccessLog(accessLogPath),
-				UseRemoteAddress: protobuf.Bool(true),
-				NormalizePath:    protobuf.Bool(true),
-				// Sets the idle timeout for HTTP connections to 60 seconds.
-				// This is chosen as a rough default to stop idle connections wasting resources,
-				// without stopping slow connections from being terminated too quickly.
-				IdleTimeout: protobuf.Duration(60 * time.Second),
+				FailureModeAllow: failOpen,
+			}),
+		},
+	}
+}
+
+// DefaultHTTPFilters returns the HTTP filters of an HTTP
+// connection manager whose filters are not configured.
+func DefaultHTTPFilters() []*http.HttpFilter {
+	return []*http.HttpFilter{
+		GzipFilter(),
+		GRPCWebFilter(),
+	}
+}
+
+// GzipFilter returns a new HTTP filter which compresses responses.
+func GzipFilter() *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: wellknown.Gzip,
+	}
+}
+
+// GRPCWebFilter returns a new HTTP filter which translates
+// gRPC-Web requests to gRPC.
+func GRPCWebFilter() *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: wellknown.GRPCWeb,
+	}
+}
+
+// CORSFilter returns a new HTTP filter which applies the CORS
+// policies of the virtual hosts and routes.
+func CORSFilter() *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: wellknown.CORS,
+	}
+}
+
+// HealthCheckFilter returns a new HTTP filter which answers requests
+// for path with Envoy's own health, rather than routing them.
+func HealthCheckFilter(path string) *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: wellknown.HealthCheck,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&healthcheck.HealthCheck{
+				PassThroughMode: protobuf.Bool(false),
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: ":path",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{
+						ExactMatch: path,
+					},
+				}},
+			}),
+		},
+	}
+}
+
+// BufferFilter returns a new HTTP filter which buffers request bodies
+// of up to maxRequestBytes before they are routed. Larger requests are
+// rejected.
+func BufferFilter(maxRequestBytes uint32) *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: wellknown.Buffer,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&buffer.Buffer{
+				MaxRequestBytes: protobuf.UInt32(maxRequestBytes),
 			}),
 		},
 	}
 }
 
-// TCPProxy creates a new TCPProxy filter.
-func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLogPath string) *envoy_api_v2_listener.Filter {
-	// Set the idle timeout in seconds for connections through a TCP Proxy type filter.
-	// The value of two and a half hours for reasons documented at
-	// https://github.com/heptio/contour/issues/1074
-	// Set to 9001 because now it's OVER NINE THOUSAND.
-	idleTimeout := protobuf.Duration(9001 * time.Second)
+// TCPProxy creates a new TCPProxy filter. Connections idle for
+// longer than idle are closed, if idle is zero a default is used.
+func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.AccessLog, idle time.Duration) *envoy_api_v2_listener.Filter {
+	if idle == 0 {
+		// Set the idle timeout in seconds for connections through a TCP Proxy type filter.
+		// The value of two and a half hours for reasons documented at
+		// https://github.com/heptio/contour/issues/1074
+		// Set to 9001 because now it's OVER NINE THOUSAND.
+		idle = 9001 * time.Second
+	}
+	idleTimeout := protobuf.Duration(idle)
 
 	switch len(proxy.Clusters) {
 	case 1:
@@ -132,7 +338,7 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLogPath string) *env
 					ClusterSpecifier: &tcp.TcpProxy_Cluster{
 						Cluster: Clustername(proxy.Clusters[0]),
 					},
-					AccessLog:   FileAccessLog(accessLogPath),
+					AccessLog:   accesslogger,
 					IdleTimeout: idleTimeout,
 				}),
 			},
@@ -160,7 +366,7 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accessLogPath string) *env
 							Clusters: clusters,
 						},
 					},
-					AccessLog:   FileAccessLog(accessLogPath),
+					AccessLog:   accesslogger,
 					IdleTimeout: idleTimeout,
 				}),
 			},
@@ -234,7 +440,8 @@ func FilterChains(filters ...*envoy_api_v2_listener.Filter) []*envoy_api_v2_list
 }
 
 // FilterChainTLS returns a TLS enabled envoy_api_v2_listener.FilterChain,
-func FilterChainTLS(domain string, secret *dag.Secret, filters []*envoy_api_v2_listener.Filter, tlsMinProtoVersion envoy_api_v2_auth.TlsParameters_TlsProtocol, alpnProtos ...string) *envoy_api_v2_listener.FilterChain {
+// which validates client certificates if clientValidation is not nil.
+func FilterChainTLS(domain string, secret *dag.Secret, filters []*envoy_api_v2_listener.Filter, tlsParams *envoy_api_v2_auth.TlsParameters, clientValidation *dag.DownstreamValidation, alpnProtos ...string) *envoy_api_v2_listener.FilterChain {
 	fc := &envoy_api_v2_listener.FilterChain{
 		Filters: filters,
 		FilterChainMatch: &envoy_api_v2_listener.FilterChainMatch{
@@ -243,16 +450,38 @@ func FilterChainTLS(domain string, secret *dag.Secret, filters []*envoy_api_v2_l
 	}
 	// attach certificate data to this listener if provided.
 	if secret != nil {
-		fc.TlsContext = DownstreamTLSContext(Secretname(secret), tlsMinProtoVersion, alpnProtos...)
+		fc.TlsContext = DownstreamTLSContext(Secretname(secret), tlsParams, clientValidation, alpnProtos...)
 	}
 	return fc
 }
 
+// FilterChainTLSFallback returns a TLS enabled envoy_api_v2_listener.FilterChain
+// which presents the fallback certificate to clients whose TLS connections
+// match no server name, including those which do not send SNI.
+func FilterChainTLSFallback(fallback *dag.Secret, filters []*envoy_api_v2_listener.Filter, tlsParams *envoy_api_v2_auth.TlsParameters, alpnProtos ...string) *envoy_api_v2_listener.FilterChain {
+	return &envoy_api_v2_listener.FilterChain{
+		Filters: filters,
+		FilterChainMatch: &envoy_api_v2_listener.FilterChainMatch{
+			TransportProtocol: "tls",
+		},
+		TlsContext: DownstreamTLSContext(Secretname(fallback), tlsParams, nil, alpnProtos...),
+	}
+}
+
 // ListenerFilters returns a []*envoy_api_v2_listener.ListenerFilter for the supplied listener filters.
 func ListenerFilters(filters ...*envoy_api_v2_listener.ListenerFilter) []*envoy_api_v2_listener.ListenerFilter {
 	return filters
 }
 
+// optionalDuration returns d as a protobuf duration,
+// or nil if d is zero so Envoy's default applies.
+func optionalDuration(d time.Duration) *duration.Duration {
+	if d == 0 {
+		return nil
+	}
+	return protobuf.Duration(d)
+}
+
 func toAny(pb proto.Message) *any.Any {
 	a, err := ptypes.MarshalAny(pb)
 	if err != nil {
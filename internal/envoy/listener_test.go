@@ -21,13 +21,20 @@ import (
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	buffer "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/buffer/v2"
+	extauthz "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	healthcheck "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/health_check/v2"
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rate_limit/v2"
 	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
 	envoy_config_v2_tcpproxy "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	ratelimitconfig "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v2"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/google/go-cmp/cmp"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/protobuf"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -44,13 +51,13 @@ func TestListener(t *testing.T) {
 			address: "0.0.0.0",
 			port:    9000,
 			f: []*envoy_api_v2_listener.Filter{
-				HTTPConnectionManager("http", "/dev/null"),
+				HTTPConnectionManager("http", FileAccessLog("/dev/null")),
 			},
 			want: &v2.Listener{
 				Name:    "http",
 				Address: SocketAddress("0.0.0.0", 9000),
 				FilterChains: FilterChains(
-					HTTPConnectionManager("http", "/dev/null"),
+					HTTPConnectionManager("http", FileAccessLog("/dev/null")),
 				),
 			},
 		},
@@ -62,7 +69,7 @@ func TestListener(t *testing.T) {
 				ProxyProtocol(),
 			},
 			f: []*envoy_api_v2_listener.Filter{
-				HTTPConnectionManager("http-proxy", "/dev/null"),
+				HTTPConnectionManager("http-proxy", FileAccessLog("/dev/null")),
 			},
 			want: &v2.Listener{
 				Name:    "http-proxy",
@@ -71,7 +78,7 @@ func TestListener(t *testing.T) {
 					ProxyProtocol(),
 				),
 				FilterChains: FilterChains(
-					HTTPConnectionManager("http-proxy", "/dev/null"),
+					HTTPConnectionManager("http-proxy", FileAccessLog("/dev/null")),
 				),
 			},
 		},
@@ -162,7 +169,7 @@ func TestSocketAddress(t *testing.T) {
 func TestDownstreamTLSContext(t *testing.T) {
 	const secretName = "default/tls-cert"
 
-	got := DownstreamTLSContext(secretName, envoy_api_v2_auth.TlsParameters_TLSv1_1, "h2", "http/1.1")
+	got := DownstreamTLSContext(secretName, TLSParameters(envoy_api_v2_auth.TlsParameters_TLSv1_1, 0, nil, nil), nil, "h2", "http/1.1")
 	want := &envoy_api_v2_auth.DownstreamTlsContext{
 		CommonTlsContext: &envoy_api_v2_auth.CommonTlsContext{
 			TlsParams: &envoy_api_v2_auth.TlsParameters{
@@ -204,10 +211,42 @@ func TestDownstreamTLSContext(t *testing.T) {
 	}
 }
 
+func TestDownstreamTLSContextClientValidation(t *testing.T) {
+	clientValidation := &dag.DownstreamValidation{
+		CACertificate: &dag.Secret{
+			Object: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ca",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					CACertificateKey: []byte("ca"),
+				},
+			},
+		},
+	}
+
+	got := DownstreamTLSContext("default/tls-cert", TLSParameters(envoy_api_v2_auth.TlsParameters_TLSv1_1, 0, nil, nil), clientValidation, "h2", "http/1.1")
+	want := &envoy_api_v2_auth.CommonTlsContext_ValidationContextSdsSecretConfig{
+		ValidationContextSdsSecretConfig: &envoy_api_v2_auth.SdsSecretConfig{
+			Name:      "default/ca/ca/1c42c72cf9",
+			SdsConfig: ConfigSource("contour"),
+		},
+	}
+	if diff := cmp.Diff(want, got.CommonTlsContext.ValidationContextType); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(protobuf.Bool(true), got.RequireClientCertificate); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
 func TestHTTPConnectionManager(t *testing.T) {
 	tests := map[string]struct {
 		routename string
 		accesslog string
+		opts      *HTTPConnectionManagerOptions
+		filters   []*http.HttpFilter
 		want      *envoy_api_v2_listener.Filter
 	}{
 		"default": {
@@ -257,10 +296,124 @@ func TestHTTPConnectionManager(t *testing.T) {
 				},
 			},
 		},
+		"options": {
+			routename: "default/kuard",
+			accesslog: "/dev/stdout",
+			opts: &HTTPConnectionManagerOptions{
+				Tracing:           HTTPConnectionManagerTracing(10, nil),
+				IdleTimeout:       time.Hour,
+				StreamIdleTimeout: 10 * time.Minute,
+				RequestTimeout:    30 * time.Second,
+				DrainTimeout:      time.Minute,
+				ForwardClientCertificate: &dag.ClientCertificateDetails{
+					Subject: true,
+					URI:     true,
+				},
+			},
+			want: &envoy_api_v2_listener.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_api_v2_listener.Filter_TypedConfig{
+					TypedConfig: toAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_api_v2_core.ConfigSource{
+									ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
+											ApiType: envoy_api_v2_core.ApiConfigSource_GRPC,
+											GrpcServices: []*envoy_api_v2_core.GrpcService{{
+												TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: wellknown.Gzip,
+						}, {
+							Name: wellknown.GRPCWeb,
+						}, {
+							Name: wellknown.Router,
+						}},
+						HttpProtocolOptions: &envoy_api_v2_core.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						AccessLog:                FileAccessLog("/dev/stdout"),
+						Tracing:                  HTTPConnectionManagerTracing(10, nil),
+						UseRemoteAddress:         protobuf.Bool(true),
+						NormalizePath:            protobuf.Bool(true),
+						IdleTimeout:              protobuf.Duration(time.Hour),
+						StreamIdleTimeout:        protobuf.Duration(10 * time.Minute),
+						RequestTimeout:           protobuf.Duration(30 * time.Second),
+						DrainTimeout:             protobuf.Duration(time.Minute),
+						ForwardClientCertDetails: http.HttpConnectionManager_SANITIZE_SET,
+						SetCurrentClientCertDetails: &http.HttpConnectionManager_SetCurrentClientCertDetails{
+							Subject: protobuf.Bool(true),
+							Uri:     true,
+						},
+					}),
+				},
+			},
+		},
+		"rate limit filter": {
+			routename: "default/kuard",
+			accesslog: "/dev/stdout",
+			filters:   []*http.HttpFilter{RateLimitFilter("ratelimit", "contour", 0, false)},
+			want: &envoy_api_v2_listener.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_api_v2_listener.Filter_TypedConfig{
+					TypedConfig: toAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_api_v2_core.ConfigSource{
+									ConfigSourceSpecifier: &envoy_api_v2_core.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_api_v2_core.ApiConfigSource{
+											ApiType: envoy_api_v2_core.ApiConfigSource_GRPC,
+											GrpcServices: []*envoy_api_v2_core.GrpcService{{
+												TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{
+							{Name: wellknown.Gzip},
+							{Name: wellknown.GRPCWeb},
+							RateLimitFilter("ratelimit", "contour", 0, false),
+							{Name: wellknown.Router},
+						},
+						HttpProtocolOptions: &envoy_api_v2_core.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						AccessLog:        FileAccessLog("/dev/stdout"),
+						UseRemoteAddress: protobuf.Bool(true),
+						NormalizePath:    protobuf.Bool(true),
+						IdleTimeout:      protobuf.Duration(60 * time.Second),
+					}),
+				},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := HTTPConnectionManager(tc.routename, tc.accesslog)
+			got := HTTPConnectionManagerWithOptions(tc.routename, FileAccessLog(tc.accesslog), tc.opts, tc.filters...)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
@@ -268,6 +421,92 @@ func TestHTTPConnectionManager(t *testing.T) {
 	}
 }
 
+func TestRateLimitFilter(t *testing.T) {
+	got := RateLimitFilter("ratelimit", "contour", 100*time.Millisecond, true)
+	want := &http.HttpFilter{
+		Name: wellknown.HTTPRateLimit,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&ratelimit.RateLimit{
+				Domain:  "contour",
+				Timeout: protobuf.Duration(100 * time.Millisecond),
+				RateLimitService: &ratelimitconfig.RateLimitServiceConfig{
+					GrpcService: &envoy_api_v2_core.GrpcService{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: "ratelimit",
+							},
+						},
+					},
+				},
+			}),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestHealthCheckFilter(t *testing.T) {
+	got := HealthCheckFilter("/healthz")
+	want := &http.HttpFilter{
+		Name: wellknown.HealthCheck,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&healthcheck.HealthCheck{
+				PassThroughMode: protobuf.Bool(false),
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: ":path",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{
+						ExactMatch: "/healthz",
+					},
+				}},
+			}),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestBufferFilter(t *testing.T) {
+	got := BufferFilter(1 << 20)
+	want := &http.HttpFilter{
+		Name: wellknown.Buffer,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&buffer.Buffer{
+				MaxRequestBytes: protobuf.UInt32(1 << 20),
+			}),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestExtAuthzFilter(t *testing.T) {
+	got := ExtAuthzFilter("auth/auth/9000/da39a3ee5e", true, 500*time.Millisecond)
+	want := &http.HttpFilter{
+		Name: wellknown.HTTPExternalAuthorization,
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: toAny(&extauthz.ExtAuthz{
+				Services: &extauthz.ExtAuthz_GrpcService{
+					GrpcService: &envoy_api_v2_core.GrpcService{
+						TargetSpecifier: &envoy_api_v2_core.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_api_v2_core.GrpcService_EnvoyGrpc{
+								ClusterName: "auth/auth/9000/da39a3ee5e",
+							},
+						},
+						Timeout: protobuf.Duration(500 * time.Millisecond),
+					},
+				},
+				FailureModeAllow: true,
+			}),
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
 func TestTCPProxy(t *testing.T) {
 	const (
 		statPrefix    = "ingress_https"
@@ -300,6 +539,7 @@ func TestTCPProxy(t *testing.T) {
 
 	tests := map[string]struct {
 		proxy *dag.TCPProxy
+		idle  time.Duration
 		want  *envoy_api_v2_listener.Filter
 	}{
 		"single cluster": {
@@ -320,6 +560,25 @@ func TestTCPProxy(t *testing.T) {
 				},
 			},
 		},
+		"idle timeout": {
+			proxy: &dag.TCPProxy{
+				Clusters: []*dag.Cluster{c1},
+			},
+			idle: time.Hour,
+			want: &envoy_api_v2_listener.Filter{
+				Name: wellknown.TCPProxy,
+				ConfigType: &envoy_api_v2_listener.Filter_TypedConfig{
+					TypedConfig: toAny(&envoy_config_v2_tcpproxy.TcpProxy{
+						StatPrefix: statPrefix,
+						ClusterSpecifier: &envoy_config_v2_tcpproxy.TcpProxy_Cluster{
+							Cluster: Clustername(c1),
+						},
+						AccessLog:   FileAccessLog(accessLogPath),
+						IdleTimeout: protobuf.Duration(time.Hour),
+					}),
+				},
+			},
+		},
 		"multiple cluster": {
 			proxy: &dag.TCPProxy{
 				Clusters: []*dag.Cluster{c2, c1},
@@ -350,7 +609,7 @@ func TestTCPProxy(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := TCPProxy(statPrefix, tc.proxy, accessLogPath)
+			got := TCPProxy(statPrefix, tc.proxy, FileAccessLog(accessLogPath), tc.idle)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
 				t.Fatal(diff)
 			}
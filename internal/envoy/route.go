@@ -13,10 +13,17 @@
 package envoy
 
 import (
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	extauthz "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	envoy_type_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/protobuf"
@@ -45,6 +52,7 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 		Timeout:       timeout(r),
 		PrefixRewrite: r.PrefixRewrite,
 		HashPolicy:    hashPolicy(r),
+		RateLimits:    RateLimits(r.RateLimitPolicy),
 	}
 
 	if r.Websocket {
@@ -55,8 +63,19 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 		)
 	}
 
-	switch len(r.Clusters) {
-	case 1:
+	switch {
+	case r.HostRewrite != "":
+		ra.HostRewriteSpecifier = &envoy_api_v2_route.RouteAction_HostRewrite{
+			HostRewrite: r.HostRewrite,
+		}
+	case r.AutoHostRewrite:
+		ra.HostRewriteSpecifier = &envoy_api_v2_route.RouteAction_AutoHostRewrite{
+			AutoHostRewrite: protobuf.Bool(true),
+		}
+	}
+
+	switch {
+	case len(r.Clusters) == 1 && !hasHeadersPolicy(r.Clusters[0]):
 		ra.ClusterSpecifier = &envoy_api_v2_route.RouteAction_Cluster{
 			Cluster: Clustername(r.Clusters[0]),
 		}
@@ -70,6 +89,12 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 	}
 }
 
+// hasHeadersPolicy returns true if the cluster manipulates headers,
+// which Envoy only supports on weighted clusters.
+func hasHeadersPolicy(c *dag.Cluster) bool {
+	return c.RequestHeadersPolicy != nil || c.ResponseHeadersPolicy != nil
+}
+
 // hashPolicy returns a slice of hash policies iff at least one of the route's
 // clusters supplied uses the `Cookie` load balancing stategy.
 func hashPolicy(r *dag.Route) []*envoy_api_v2_route.RouteAction_HashPolicy {
@@ -127,6 +152,92 @@ func retryPolicy(r *dag.Route) *envoy_api_v2_route.RetryPolicy {
 	return rp
 }
 
+// RateLimits returns the rate limit actions for the supplied policy,
+// one rate limit for each of the policy's descriptors.
+func RateLimits(rlp *dag.RateLimitPolicy) []*envoy_api_v2_route.RateLimit {
+	if rlp == nil {
+		return nil
+	}
+
+	var rateLimits []*envoy_api_v2_route.RateLimit
+	for _, d := range rlp.Descriptors {
+		rl := &envoy_api_v2_route.RateLimit{}
+		for _, entry := range d.Entries {
+			switch {
+			case entry.GenericKey != nil:
+				rl.Actions = append(rl.Actions, &envoy_api_v2_route.RateLimit_Action{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_GenericKey_{
+						GenericKey: &envoy_api_v2_route.RateLimit_Action_GenericKey{
+							DescriptorValue: entry.GenericKey.Value,
+						},
+					},
+				})
+			case entry.RequestHeader != nil:
+				rl.Actions = append(rl.Actions, &envoy_api_v2_route.RateLimit_Action{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RequestHeaders_{
+						RequestHeaders: &envoy_api_v2_route.RateLimit_Action_RequestHeaders{
+							HeaderName:    entry.RequestHeader.HeaderName,
+							DescriptorKey: entry.RequestHeader.Key,
+						},
+					},
+				})
+			case entry.RemoteAddress != nil:
+				rl.Actions = append(rl.Actions, &envoy_api_v2_route.RateLimit_Action{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RemoteAddress_{
+						RemoteAddress: &envoy_api_v2_route.RateLimit_Action_RemoteAddress{},
+					},
+				})
+			}
+		}
+		rateLimits = append(rateLimits, rl)
+	}
+	return rateLimits
+}
+
+// CORSPolicy returns the CORS policy of a virtual host for the
+// supplied policy. The origin "*" matches any origin.
+func CORSPolicy(cp *dag.CORSPolicy) *envoy_api_v2_route.CorsPolicy {
+	if cp == nil {
+		return nil
+	}
+
+	policy := &envoy_api_v2_route.CorsPolicy{
+		AllowMethods:     strings.Join(cp.AllowMethods, ","),
+		AllowHeaders:     strings.Join(cp.AllowHeaders, ","),
+		ExposeHeaders:    strings.Join(cp.ExposeHeaders, ","),
+		AllowCredentials: protobuf.Bool(cp.AllowCredentials),
+	}
+	if cp.MaxAge > 0 {
+		policy.MaxAge = strconv.Itoa(int(cp.MaxAge.Seconds()))
+	}
+	for _, origin := range cp.AllowOrigin {
+		m := &envoy_type_matcher.StringMatcher{
+			MatchPattern: &envoy_type_matcher.StringMatcher_Exact{
+				Exact: origin,
+			},
+		}
+		if origin == "*" {
+			m.MatchPattern = &envoy_type_matcher.StringMatcher_SafeRegex{
+				SafeRegex: safeRegex(".*"),
+			}
+		}
+		policy.AllowOriginStringMatch = append(policy.AllowOriginStringMatch, m)
+	}
+	return policy
+}
+
+// DisableExtAuthz returns the per filter configuration which
+// disables external authorization for a route.
+func DisableExtAuthz() map[string]*any.Any {
+	return map[string]*any.Any{
+		wellknown.HTTPExternalAuthorization: toAny(&extauthz.ExtAuthzPerRoute{
+			Override: &extauthz.ExtAuthzPerRoute_Disabled{
+				Disabled: true,
+			},
+		}),
+	}
+}
+
 // UpgradeHTTPS returns a route Action that redirects the request to HTTPS.
 func UpgradeHTTPS() *envoy_api_v2_route.Route_Redirect {
 	return &envoy_api_v2_route.Route_Redirect{
@@ -145,6 +256,39 @@ func RouteHeaders() []*envoy_api_v2_core.HeaderValueOption {
 	)
 }
 
+// HeadersToAdd returns the headers set by the supplied policy,
+// sorted by name. Each header replaces any existing value.
+func HeadersToAdd(policy *dag.HeadersPolicy) []*envoy_api_v2_core.HeaderValueOption {
+	if policy == nil || len(policy.Set) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range policy.Set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers []*envoy_api_v2_core.HeaderValueOption
+	for _, name := range names {
+		headers = append(headers, &envoy_api_v2_core.HeaderValueOption{
+			Header: &envoy_api_v2_core.HeaderValue{
+				Key:   name,
+				Value: policy.Set[name],
+			},
+			Append: protobuf.Bool(false),
+		})
+	}
+	return headers
+}
+
+// HeadersToRemove returns the names of the headers removed by the supplied policy.
+func HeadersToRemove(policy *dag.HeadersPolicy) []string {
+	if policy == nil {
+		return nil
+	}
+	return policy.Remove
+}
+
 // weightedClusters returns a route.WeightedCluster for multiple services.
 func weightedClusters(clusters []*dag.Cluster) *envoy_api_v2_route.WeightedCluster {
 	var wc envoy_api_v2_route.WeightedCluster
@@ -152,8 +296,12 @@ func weightedClusters(clusters []*dag.Cluster) *envoy_api_v2_route.WeightedClust
 	for _, cluster := range clusters {
 		total += cluster.Weight
 		wc.Clusters = append(wc.Clusters, &envoy_api_v2_route.WeightedCluster_ClusterWeight{
-			Name:   Clustername(cluster),
-			Weight: protobuf.UInt32(cluster.Weight),
+			Name:                    Clustername(cluster),
+			Weight:                  protobuf.UInt32(cluster.Weight),
+			RequestHeadersToAdd:     HeadersToAdd(cluster.RequestHeadersPolicy),
+			RequestHeadersToRemove:  HeadersToRemove(cluster.RequestHeadersPolicy),
+			ResponseHeadersToAdd:    HeadersToAdd(cluster.ResponseHeadersPolicy),
+			ResponseHeadersToRemove: HeadersToRemove(cluster.ResponseHeadersPolicy),
 		})
 	}
 	// Check if no weights were defined, if not default to even distribution
@@ -169,28 +317,91 @@ func weightedClusters(clusters []*dag.Cluster) *envoy_api_v2_route.WeightedClust
 	return &wc
 }
 
-// RouteRegex returns a regex matcher.
-func RouteRegex(regex string) *envoy_api_v2_route.RouteMatch {
+// RouteRegex returns a regex matcher. The regex is
+// compiled by Envoy with std::regex.
+func RouteRegex(regex string, headers ...dag.HeaderCondition) *envoy_api_v2_route.RouteMatch {
 	return &envoy_api_v2_route.RouteMatch{
 		PathSpecifier: &envoy_api_v2_route.RouteMatch_Regex{
 			Regex: regex,
 		},
+		Headers: headerMatcher(headers),
+	}
+}
+
+// RouteSafeRegex returns a regex matcher. The regex is compiled
+// by Envoy with the RE2 engine, as it is validated by the DAG.
+func RouteSafeRegex(regex string, headers ...dag.HeaderCondition) *envoy_api_v2_route.RouteMatch {
+	return &envoy_api_v2_route.RouteMatch{
+		PathSpecifier: &envoy_api_v2_route.RouteMatch_SafeRegex{
+			SafeRegex: safeRegex(regex),
+		},
+		Headers: headerMatcher(headers),
+	}
+}
+
+// safeRegex returns a matcher for regex using the RE2 engine.
+func safeRegex(regex string) *envoy_type_matcher.RegexMatcher {
+	return &envoy_type_matcher.RegexMatcher{
+		EngineType: &envoy_type_matcher.RegexMatcher_GoogleRe2{
+			GoogleRe2: &envoy_type_matcher.RegexMatcher_GoogleRE2{},
+		},
+		Regex: regex,
+	}
+}
+
+// RouteExact returns a matcher for the exact path.
+func RouteExact(path string, headers ...dag.HeaderCondition) *envoy_api_v2_route.RouteMatch {
+	return &envoy_api_v2_route.RouteMatch{
+		PathSpecifier: &envoy_api_v2_route.RouteMatch_Path{
+			Path: path,
+		},
+		Headers: headerMatcher(headers),
 	}
 }
 
 // RoutePrefix returns a prefix matcher.
-func RoutePrefix(prefix string) *envoy_api_v2_route.RouteMatch {
+func RoutePrefix(prefix string, headers ...dag.HeaderCondition) *envoy_api_v2_route.RouteMatch {
 	return &envoy_api_v2_route.RouteMatch{
 		PathSpecifier: &envoy_api_v2_route.RouteMatch_Prefix{
 			Prefix: prefix,
 		},
+		Headers: headerMatcher(headers),
+	}
+}
+
+// headerMatcher returns a []*envoy_api_v2_route.HeaderMatcher for the supplied
+// header conditions. All of the returned matchers must match for the route to
+// be selected.
+func headerMatcher(headers []dag.HeaderCondition) []*envoy_api_v2_route.HeaderMatcher {
+	var envoyHeaders []*envoy_api_v2_route.HeaderMatcher
+	for _, h := range headers {
+		header := &envoy_api_v2_route.HeaderMatcher{
+			Name: h.Name,
+		}
+		switch h.MatchType {
+		case dag.HeaderMatchTypeContains:
+			// envoy has no contains matcher, so express contains
+			// as an RE2 regex that matches anywhere. QuoteMeta
+			// escapes the value using RE2 syntax.
+			header.HeaderMatchSpecifier = &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+				SafeRegexMatch: safeRegex(".*" + regexp.QuoteMeta(h.Value) + ".*"),
+			}
+		default:
+			header.HeaderMatchSpecifier = &envoy_api_v2_route.HeaderMatcher_ExactMatch{
+				ExactMatch: h.Value,
+			}
+		}
+		envoyHeaders = append(envoyHeaders, header)
 	}
+	return envoyHeaders
 }
 
 // VirtualHost creates a new route.VirtualHost.
 func VirtualHost(hostname string, routes ...*envoy_api_v2_route.Route) *envoy_api_v2_route.VirtualHost {
 	domains := []string{hostname}
-	if hostname != "*" {
+	if !strings.HasPrefix(hostname, "*") {
+		// Envoy matches wildcard domains by suffix, so a
+		// port suffix can only be added to non wildcard domains.
 		domains = append(domains, hostname+":*")
 	}
 	return &envoy_api_v2_route.VirtualHost{
@@ -14,10 +14,13 @@
 package envoy
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoy_type_matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher"
 	"github.com/google/go-cmp/cmp"
 	"github.com/heptio/contour/internal/dag"
 	"github.com/heptio/contour/internal/protobuf"
@@ -110,6 +113,64 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"host rewrite": {
+			route: &dag.Route{
+				Clusters:    []*dag.Cluster{c1},
+				HostRewrite: "www.example.com",
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					HostRewriteSpecifier: &envoy_api_v2_route.RouteAction_HostRewrite{
+						HostRewrite: "www.example.com",
+					},
+				},
+			},
+		},
+		"auto host rewrite": {
+			route: &dag.Route{
+				Clusters:        []*dag.Cluster{c1},
+				AutoHostRewrite: true,
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					HostRewriteSpecifier: &envoy_api_v2_route.RouteAction_AutoHostRewrite{
+						AutoHostRewrite: protobuf.Bool(true),
+					},
+				},
+			},
+		},
+		"rate limit policy": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{c1},
+				RateLimitPolicy: &dag.RateLimitPolicy{
+					Descriptors: []*dag.RateLimitDescriptor{{
+						Entries: []dag.RateLimitDescriptorEntry{{
+							RemoteAddress: &dag.RemoteAddressDescriptorEntry{},
+						}},
+					}},
+				},
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RateLimits: []*envoy_api_v2_route.RateLimit{{
+						Actions: []*envoy_api_v2_route.RateLimit_Action{{
+							ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RemoteAddress_{
+								RemoteAddress: &envoy_api_v2_route.RateLimit_Action_RemoteAddress{},
+							},
+						}},
+					}},
+				},
+			},
+		},
 		"websocket": {
 			route: &dag.Route{
 				Websocket: true,
@@ -468,6 +529,40 @@ func TestWeightedClusters(t *testing.T) {
 				TotalWeight: protobuf.UInt32(100),
 			},
 		},
+		"single service with headers policies": {
+			clusters: []*dag.Cluster{{
+				Upstream: &dag.Service{
+					Name:      "kuard",
+					Namespace: "default",
+					ServicePort: &v1.ServicePort{
+						Port: 8080,
+					},
+				},
+				RequestHeadersPolicy: &dag.HeadersPolicy{
+					Set: map[string]string{
+						"X-Service": "kuard",
+					},
+				},
+				ResponseHeadersPolicy: &dag.HeadersPolicy{
+					Remove: []string{"Server"},
+				},
+			}},
+			want: &envoy_api_v2_route.WeightedCluster{
+				Clusters: []*envoy_api_v2_route.WeightedCluster_ClusterWeight{{
+					Name:   "default/kuard/8080/da39a3ee5e",
+					Weight: protobuf.UInt32(1),
+					RequestHeadersToAdd: []*envoy_api_v2_core.HeaderValueOption{{
+						Header: &envoy_api_v2_core.HeaderValue{
+							Key:   "X-Service",
+							Value: "kuard",
+						},
+						Append: protobuf.Bool(false),
+					}},
+					ResponseHeadersToRemove: []string{"Server"},
+				}},
+				TotalWeight: protobuf.UInt32(1),
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -502,6 +597,14 @@ func TestVirtualHost(t *testing.T) {
 				Domains: []string{"www.example.com", "www.example.com:*"},
 			},
 		},
+		"wildcard hostname without port suffix": {
+			hostname: "*.example.com",
+			port:     9999,
+			want: &envoy_api_v2_route.VirtualHost{
+				Name:    "*.example.com",
+				Domains: []string{"*.example.com"},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -513,6 +616,220 @@ func TestVirtualHost(t *testing.T) {
 	}
 }
 
+func TestVirtualHostDomainsMatchHost(t *testing.T) {
+	tests := map[string]struct {
+		hostname string
+		host     string
+		want     bool
+	}{
+		"exact host": {
+			hostname: "www.example.com",
+			host:     "www.example.com",
+			want:     true,
+		},
+		"exact host with port": {
+			hostname: "www.example.com",
+			host:     "www.example.com:8080",
+			want:     true,
+		},
+		"wildcard host": {
+			hostname: "*.example.com",
+			host:     "www.example.com",
+			want:     true,
+		},
+		"wildcard host with port": {
+			hostname: "*.example.com",
+			host:     "www.example.com:8080",
+			want:     false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := false
+			for _, domain := range VirtualHost(tc.hostname).Domains {
+				got = got || domainMatches(domain, tc.host)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+// domainMatches reports whether Envoy matches host against domain,
+// which may have a single wildcard as a prefix or suffix.
+func domainMatches(domain, host string) bool {
+	switch {
+	case domain == "*":
+		return true
+	case strings.HasPrefix(domain, "*"):
+		return strings.HasSuffix(host, domain[1:]) && len(host) > len(domain)-1
+	case strings.HasSuffix(domain, "*"):
+		return strings.HasPrefix(host, domain[:len(domain)-1]) && len(host) > len(domain)-1
+	default:
+		return domain == host
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.RateLimitPolicy
+		want   []*envoy_api_v2_route.RateLimit
+	}{
+		"nil policy": {
+			policy: nil,
+			want:   nil,
+		},
+		"one descriptor per rate limit": {
+			policy: &dag.RateLimitPolicy{
+				Descriptors: []*dag.RateLimitDescriptor{{
+					Entries: []dag.RateLimitDescriptorEntry{{
+						GenericKey: &dag.GenericKeyDescriptorEntry{Value: "api"},
+					}, {
+						RequestHeader: &dag.RequestHeaderDescriptorEntry{
+							HeaderName: "X-Tenant",
+							Key:        "tenant",
+						},
+					}},
+				}, {
+					Entries: []dag.RateLimitDescriptorEntry{{
+						RemoteAddress: &dag.RemoteAddressDescriptorEntry{},
+					}},
+				}},
+			},
+			want: []*envoy_api_v2_route.RateLimit{{
+				Actions: []*envoy_api_v2_route.RateLimit_Action{{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_GenericKey_{
+						GenericKey: &envoy_api_v2_route.RateLimit_Action_GenericKey{
+							DescriptorValue: "api",
+						},
+					},
+				}, {
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RequestHeaders_{
+						RequestHeaders: &envoy_api_v2_route.RateLimit_Action_RequestHeaders{
+							HeaderName:    "X-Tenant",
+							DescriptorKey: "tenant",
+						},
+					},
+				}},
+			}, {
+				Actions: []*envoy_api_v2_route.RateLimit_Action{{
+					ActionSpecifier: &envoy_api_v2_route.RateLimit_Action_RemoteAddress_{
+						RemoteAddress: &envoy_api_v2_route.RateLimit_Action_RemoteAddress{},
+					},
+				}},
+			}},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RateLimits(tc.policy)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestCORSPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.CORSPolicy
+		want   *envoy_api_v2_route.CorsPolicy
+	}{
+		"nil policy": {
+			policy: nil,
+			want:   nil,
+		},
+		"all fields": {
+			policy: &dag.CORSPolicy{
+				AllowOrigin:      []string{"https://example.com", "*"},
+				AllowMethods:     []string{"GET", "POST"},
+				AllowHeaders:     []string{"Authorization", "Content-Type"},
+				ExposeHeaders:    []string{"X-Request-Id"},
+				MaxAge:           10 * time.Minute,
+				AllowCredentials: true,
+			},
+			want: &envoy_api_v2_route.CorsPolicy{
+				AllowOriginStringMatch: []*envoy_type_matcher.StringMatcher{{
+					MatchPattern: &envoy_type_matcher.StringMatcher_Exact{
+						Exact: "https://example.com",
+					},
+				}, {
+					MatchPattern: &envoy_type_matcher.StringMatcher_SafeRegex{
+						SafeRegex: &envoy_type_matcher.RegexMatcher{
+							EngineType: &envoy_type_matcher.RegexMatcher_GoogleRe2{
+								GoogleRe2: &envoy_type_matcher.RegexMatcher_GoogleRE2{},
+							},
+							Regex: ".*",
+						},
+					},
+				}},
+				AllowMethods:     "GET,POST",
+				AllowHeaders:     "Authorization,Content-Type",
+				ExposeHeaders:    "X-Request-Id",
+				MaxAge:           "600",
+				AllowCredentials: protobuf.Bool(true),
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := CORSPolicy(tc.policy)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestHeadersToAdd(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.HeadersPolicy
+		want   []*envoy_api_v2_core.HeaderValueOption
+	}{
+		"nil policy": {
+			policy: nil,
+			want:   nil,
+		},
+		"remove only": {
+			policy: &dag.HeadersPolicy{
+				Remove: []string{"X-Secret"},
+			},
+			want: nil,
+		},
+		"sorted by name": {
+			policy: &dag.HeadersPolicy{
+				Set: map[string]string{
+					"X-B": "2",
+					"X-A": "1",
+				},
+			},
+			want: []*envoy_api_v2_core.HeaderValueOption{{
+				Header: &envoy_api_v2_core.HeaderValue{
+					Key:   "X-A",
+					Value: "1",
+				},
+				Append: protobuf.Bool(false),
+			}, {
+				Header: &envoy_api_v2_core.HeaderValue{
+					Key:   "X-B",
+					Value: "2",
+				},
+				Append: protobuf.Bool(false),
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := HeadersToAdd(tc.policy)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestUpgradeHTTPS(t *testing.T) {
 	got := UpgradeHTTPS()
 	want := &envoy_api_v2_route.Route_Redirect{
@@ -527,3 +844,112 @@ func TestUpgradeHTTPS(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestRoutePrefixHeaders(t *testing.T) {
+	tests := map[string]struct {
+		prefix  string
+		headers []dag.HeaderCondition
+		want    *envoy_api_v2_route.RouteMatch
+	}{
+		"no headers": {
+			prefix: "/",
+			want: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: &envoy_api_v2_route.RouteMatch_Prefix{
+					Prefix: "/",
+				},
+			},
+		},
+		"exact and contains headers": {
+			prefix: "/api",
+			headers: []dag.HeaderCondition{{
+				Name:      "user-agent",
+				Value:     "Chrome (v1.0)",
+				MatchType: dag.HeaderMatchTypeContains,
+			}, {
+				Name:      "x-canary",
+				Value:     "true",
+				MatchType: dag.HeaderMatchTypeExact,
+			}},
+			want: &envoy_api_v2_route.RouteMatch{
+				PathSpecifier: &envoy_api_v2_route.RouteMatch_Prefix{
+					Prefix: "/api",
+				},
+				Headers: []*envoy_api_v2_route.HeaderMatcher{{
+					Name: "user-agent",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: &envoy_type_matcher.RegexMatcher{
+							EngineType: &envoy_type_matcher.RegexMatcher_GoogleRe2{
+								GoogleRe2: &envoy_type_matcher.RegexMatcher_GoogleRE2{},
+							},
+							Regex: `.*Chrome \(v1\.0\).*`,
+						},
+					},
+				}, {
+					Name: "x-canary",
+					HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{
+						ExactMatch: "true",
+					},
+				}},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RoutePrefix(tc.prefix, tc.headers...)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestRouteExact(t *testing.T) {
+	got := RouteExact("/healthz", dag.HeaderCondition{
+		Name:      "x-canary",
+		Value:     "true",
+		MatchType: dag.HeaderMatchTypeExact,
+	})
+	want := &envoy_api_v2_route.RouteMatch{
+		PathSpecifier: &envoy_api_v2_route.RouteMatch_Path{
+			Path: "/healthz",
+		},
+		Headers: []*envoy_api_v2_route.HeaderMatcher{{
+			Name: "x-canary",
+			HeaderMatchSpecifier: &envoy_api_v2_route.HeaderMatcher_ExactMatch{
+				ExactMatch: "true",
+			},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestRouteRegex(t *testing.T) {
+	got := RouteRegex("/api/v[0-9]+/.*")
+	want := &envoy_api_v2_route.RouteMatch{
+		PathSpecifier: &envoy_api_v2_route.RouteMatch_Regex{
+			Regex: "/api/v[0-9]+/.*",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestRouteSafeRegex(t *testing.T) {
+	got := RouteSafeRegex("/api/v[0-9]+/.*")
+	want := &envoy_api_v2_route.RouteMatch{
+		PathSpecifier: &envoy_api_v2_route.RouteMatch_SafeRegex{
+			SafeRegex: &envoy_type_matcher.RegexMatcher{
+				EngineType: &envoy_type_matcher.RegexMatcher_GoogleRe2{
+					GoogleRe2: &envoy_type_matcher.RegexMatcher_GoogleRE2{},
+				},
+				Regex: "/api/v[0-9]+/.*",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
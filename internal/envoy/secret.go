@@ -10,6 +10,8 @@ import (
 )
 
 // Secretname returns the name of the SDS secret for this secret.
+// The name only changes with the secret's certificate, a change to
+// its intermediate certificates is sent to Envoy under the same name.
 func Secretname(s *dag.Secret) string {
 	hash := sha1.Sum(s.Cert())
 	ns := s.Namespace()
@@ -18,6 +20,8 @@ func Secretname(s *dag.Secret) string {
 }
 
 // Secret creates new envoy_api_v2_auth.Secret from secret.
+// The secret's intermediate certificates follow its
+// certificate in the certificate chain.
 func Secret(s *dag.Secret) *envoy_api_v2_auth.Secret {
 	return &envoy_api_v2_auth.Secret{
 		Name: Secretname(s),
@@ -30,10 +34,51 @@ func Secret(s *dag.Secret) *envoy_api_v2_auth.Secret {
 				},
 				CertificateChain: &envoy_api_v2_core.DataSource{
 					Specifier: &envoy_api_v2_core.DataSource_InlineBytes{
-						InlineBytes: s.Cert(),
+						InlineBytes: certificateChain(s.Cert(), s.Chain()),
 					},
 				},
 			},
 		},
 	}
 }
+
+// CASecretname returns the name of the SDS secret
+// for the CA certificates of this secret.
+func CASecretname(s *dag.Secret) string {
+	hash := sha1.Sum(s.Object.Data[CACertificateKey])
+	ns := s.Namespace()
+	name := s.Name()
+	return hashname(60, ns, name, "ca", fmt.Sprintf("%x", hash[:5]))
+}
+
+// CASecret creates a new envoy_api_v2_auth.Secret which
+// validates client certificates with the CA certificates
+// of secret.
+func CASecret(s *dag.Secret) *envoy_api_v2_auth.Secret {
+	return &envoy_api_v2_auth.Secret{
+		Name: CASecretname(s),
+		Type: &envoy_api_v2_auth.Secret_ValidationContext{
+			ValidationContext: &envoy_api_v2_auth.CertificateValidationContext{
+				TrustedCa: &envoy_api_v2_core.DataSource{
+					Specifier: &envoy_api_v2_core.DataSource_InlineBytes{
+						InlineBytes: s.Object.Data[CACertificateKey],
+					},
+				},
+			},
+		},
+	}
+}
+
+// certificateChain returns the PEM encoded certificate cert
+// followed by the PEM encoded intermediate certificates chain.
+func certificateChain(cert, chain []byte) []byte {
+	if len(chain) == 0 {
+		return cert
+	}
+	buf := make([]byte, 0, len(cert)+len(chain)+1)
+	buf = append(buf, cert...)
+	if len(cert) > 0 && cert[len(cert)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	return append(buf, chain...)
+}
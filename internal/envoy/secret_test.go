@@ -47,6 +47,38 @@ func TestSecret(t *testing.T) {
 				},
 			},
 		},
+		"secret with chain": {
+			secret: &dag.Secret{
+				Object: &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						v1.TLSCertKey:           []byte("cert"),
+						v1.TLSPrivateKeyKey:     []byte("key"),
+						dag.CertificateChainKey: []byte("intermediate\n"),
+					},
+				},
+			},
+			want: &envoy_api_v2_auth.Secret{
+				Name: "default/simple/cd1b506996",
+				Type: &envoy_api_v2_auth.Secret_TlsCertificate{
+					TlsCertificate: &envoy_api_v2_auth.TlsCertificate{
+						PrivateKey: &envoy_api_v2_core.DataSource{
+							Specifier: &envoy_api_v2_core.DataSource_InlineBytes{
+								InlineBytes: []byte("key"),
+							},
+						},
+						CertificateChain: &envoy_api_v2_core.DataSource{
+							Specifier: &envoy_api_v2_core.DataSource_InlineBytes{
+								InlineBytes: []byte("cert\nintermediate\n"),
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -105,3 +137,33 @@ func TestSecretname(t *testing.T) {
 		})
 	}
 }
+
+func TestCASecret(t *testing.T) {
+	s := &dag.Secret{
+		Object: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ca",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				CACertificateKey: []byte("ca"),
+			},
+		},
+	}
+	got := CASecret(s)
+	want := &envoy_api_v2_auth.Secret{
+		Name: "default/ca/ca/1c42c72cf9",
+		Type: &envoy_api_v2_auth.Secret_ValidationContext{
+			ValidationContext: &envoy_api_v2_auth.CertificateValidationContext{
+				TrustedCa: &envoy_api_v2_core.DataSource{
+					Specifier: &envoy_api_v2_core.DataSource_InlineBytes{
+						InlineBytes: []byte("ca"),
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
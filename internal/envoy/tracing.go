@@ -0,0 +1,65 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"math"
+
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	trace "github.com/envoyproxy/go-control-plane/envoy/config/trace/v2"
+	_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+)
+
+// ZipkinTracing returns a tracing provider which sends spans, as
+// Zipkin v2 JSON, to the endpoint of the collector behind cluster.
+func ZipkinTracing(cluster, endpoint string) *trace.Tracing {
+	return &trace.Tracing{
+		Http: &trace.Tracing_Http{
+			Name: wellknown.Zipkin,
+			ConfigType: &trace.Tracing_Http_TypedConfig{
+				TypedConfig: toAny(&trace.ZipkinConfig{
+					CollectorCluster:         cluster,
+					CollectorEndpoint:        endpoint,
+					CollectorEndpointVersion: trace.ZipkinConfig_HTTP_JSON,
+				}),
+			},
+		},
+	}
+}
+
+// HTTPConnectionManagerTracing returns the tracing settings of an HTTP
+// connection manager which traces sampling percent of its requests, tagging
+// their spans with the values of the supplied request headers.
+func HTTPConnectionManagerTracing(sampling float64, requestHeadersForTags []string) *http.HttpConnectionManager_Tracing {
+	return &http.HttpConnectionManager_Tracing{
+		RandomSampling:        &_type.Percent{Value: sampling},
+		RequestHeadersForTags: requestHeadersForTags,
+	}
+}
+
+// RouteTracing returns the tracing settings of a route which traces
+// sampling percent of its requests, overriding those of the HTTP
+// connection manager.
+func RouteTracing(sampling float64) *envoy_api_v2_route.Tracing {
+	return &envoy_api_v2_route.Tracing{
+		RandomSampling: &_type.FractionalPercent{
+			// sampling is a percentage, expressed here in millionths
+			// to keep up to four decimal places of precision.
+			Numerator:   uint32(math.Round(sampling * 10000)),
+			Denominator: _type.FractionalPercent_MILLION,
+		},
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"testing"
+
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	http "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	_type "github.com/envoyproxy/go-control-plane/envoy/type"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHTTPConnectionManagerTracing(t *testing.T) {
+	got := HTTPConnectionManagerTracing(12.5, []string{"x-request-id"})
+	want := &http.HttpConnectionManager_Tracing{
+		RandomSampling:        &_type.Percent{Value: 12.5},
+		RequestHeadersForTags: []string{"x-request-id"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestRouteTracing(t *testing.T) {
+	tests := map[string]struct {
+		sampling float64
+		want     uint32
+	}{
+		"none": {
+			sampling: 0,
+			want:     0,
+		},
+		"all": {
+			sampling: 100,
+			want:     1000000,
+		},
+		"fraction": {
+			sampling: 0.0125,
+			want:     125,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RouteTracing(tc.sampling)
+			want := &envoy_api_v2_route.Tracing{
+				RandomSampling: &_type.FractionalPercent{
+					Numerator:   tc.want,
+					Denominator: _type.FractionalPercent_MILLION,
+				},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
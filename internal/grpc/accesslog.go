@@ -0,0 +1,192 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_data_accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	envoy_service_accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// AccessLogEntry is an access log entry received from an Envoy.
+type AccessLogEntry struct {
+	// Node is the ID of the Envoy which sent the entry.
+	Node string
+
+	// LogName is the log name configured on the Envoy access log.
+	LogName string
+
+	// HTTP is set if the entry was logged by an HTTP connection manager.
+	HTTP *envoy_data_accesslog_v2.HTTPAccessLogEntry
+
+	// TCP is set if the entry was logged by a TCP proxy.
+	TCP *envoy_data_accesslog_v2.TCPAccessLogEntry
+}
+
+// AccessLogSink writes the access log entries received by Contour.
+type AccessLogSink interface {
+	Write(*AccessLogEntry) error
+}
+
+// RegisterAccessLogService registers an Envoy gRPC Access Log
+// Service on g which writes each entry it receives to sink.
+func RegisterAccessLogService(g *grpc.Server, log logrus.FieldLogger, sink AccessLogSink) {
+	envoy_service_accesslog_v2.RegisterAccessLogServiceServer(g, &accessLogService{
+		FieldLogger: log,
+		sink:        sink,
+	})
+}
+
+// accessLogService implements the Envoy gRPC Access Log Service.
+type accessLogService struct {
+	logrus.FieldLogger
+	connections counter
+	sink        AccessLogSink
+}
+
+// StreamAccessLogs receives a stream of access log entries from an Envoy.
+func (als *accessLogService) StreamAccessLogs(st envoy_service_accesslog_v2.AccessLogService_StreamAccessLogsServer) (err error) {
+	log := als.WithField("connection", als.connections.next())
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("access log stream terminated")
+		} else {
+			log.Info("access log stream terminated")
+		}
+	}()
+
+	// node and logName identify the Envoy and its access log,
+	// they are only sent in the first message of the stream.
+	var node, logName string
+	for {
+		msg, err := st.Recv()
+		if err == io.EOF {
+			return st.SendAndClose(new(envoy_service_accesslog_v2.StreamAccessLogsResponse))
+		}
+		if err != nil {
+			return err
+		}
+		if id := msg.Identifier; id != nil {
+			node, logName = id.GetNode().GetId(), id.LogName
+		}
+
+		var entries []*AccessLogEntry
+		switch logs := msg.LogEntries.(type) {
+		case *envoy_service_accesslog_v2.StreamAccessLogsMessage_HttpLogs:
+			for _, e := range logs.HttpLogs.GetLogEntry() {
+				entries = append(entries, &AccessLogEntry{Node: node, LogName: logName, HTTP: e})
+			}
+		case *envoy_service_accesslog_v2.StreamAccessLogsMessage_TcpLogs:
+			for _, e := range logs.TcpLogs.GetLogEntry() {
+				entries = append(entries, &AccessLogEntry{Node: node, LogName: logName, TCP: e})
+			}
+		}
+		for _, e := range entries {
+			if err := als.sink.Write(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// LogAccessLogSink writes each access log entry to a logrus.FieldLogger.
+type LogAccessLogSink struct {
+	logrus.FieldLogger
+}
+
+// Write logs e at info level with its properties as fields.
+func (s *LogAccessLogSink) Write(e *AccessLogEntry) error {
+	log := s.WithField("node_id", e.Node).WithField("log_name", e.LogName)
+	switch {
+	case e.HTTP != nil:
+		common := e.HTTP.GetCommonProperties()
+		req, resp := e.HTTP.GetRequest(), e.HTTP.GetResponse()
+		log = withCommonProperties(log, common).
+			WithField("method", req.GetRequestMethod().String()).
+			WithField("authority", req.GetAuthority()).
+			WithField("path", req.GetPath()).
+			WithField("response_code", resp.GetResponseCode().GetValue())
+	case e.TCP != nil:
+		conn := e.TCP.GetConnectionProperties()
+		log = withCommonProperties(log, e.TCP.GetCommonProperties()).
+			WithField("received_bytes", conn.GetReceivedBytes()).
+			WithField("sent_bytes", conn.GetSentBytes())
+	}
+	log.Info("access log")
+	return nil
+}
+
+func withCommonProperties(log logrus.FieldLogger, common *envoy_data_accesslog_v2.AccessLogCommon) logrus.FieldLogger {
+	return log.WithField("downstream_remote_address", socketAddress(common.GetDownstreamRemoteAddress())).
+		WithField("upstream_cluster", common.GetUpstreamCluster())
+}
+
+// socketAddress returns addr formatted as address:port.
+func socketAddress(addr *envoy_api_v2_core.Address) string {
+	sa := addr.GetSocketAddress()
+	if sa == nil {
+		return ""
+	}
+	return net.JoinHostPort(sa.GetAddress(), strconv.FormatUint(uint64(sa.GetPortValue()), 10))
+}
+
+// JSONAccessLogSink writes each access log entry to W as a line of JSON.
+type JSONAccessLogSink struct {
+	mu sync.Mutex
+	W  io.Writer
+}
+
+// Write writes e to s.W. The entry is written using the field
+// names of Envoy's access log protobufs.
+func (s *JSONAccessLogSink) Write(e *AccessLogEntry) error {
+	line := struct {
+		Node    string          `json:"node_id"`
+		LogName string          `json:"log_name"`
+		HTTP    json.RawMessage `json:"http,omitempty"`
+		TCP     json.RawMessage `json:"tcp,omitempty"`
+	}{
+		Node:    e.Node,
+		LogName: e.LogName,
+	}
+	var err error
+	switch {
+	case e.HTTP != nil:
+		line.HTTP, err = marshalJSON(e.HTTP)
+	case e.TCP != nil:
+		line.TCP, err = marshalJSON(e.TCP)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.W).Encode(line)
+}
+
+func marshalJSON(pb proto.Message) (json.RawMessage, error) {
+	m := jsonpb.Marshaler{OrigName: true}
+	s, err := m.MarshalToString(pb)
+	return json.RawMessage(s), err
+}
@@ -0,0 +1,111 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_data_accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	envoy_service_accesslog_v2 "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heptio/contour/internal/protobuf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+func TestAccessLogServiceStream(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	var buf bytes.Buffer
+	als := &accessLogService{
+		FieldLogger: log,
+		sink:        &JSONAccessLogSink{W: &buf},
+	}
+
+	msgs := []*envoy_service_accesslog_v2.StreamAccessLogsMessage{{
+		// the first message identifies the Envoy.
+		Identifier: &envoy_service_accesslog_v2.StreamAccessLogsMessage_Identifier{
+			Node:    &envoy_api_v2_core.Node{Id: "envoy"},
+			LogName: "contour",
+		},
+		LogEntries: &envoy_service_accesslog_v2.StreamAccessLogsMessage_HttpLogs{
+			HttpLogs: &envoy_service_accesslog_v2.StreamAccessLogsMessage_HTTPAccessLogEntries{
+				LogEntry: []*envoy_data_accesslog_v2.HTTPAccessLogEntry{{
+					Request: &envoy_data_accesslog_v2.HTTPRequestProperties{
+						Authority: "www.example.com",
+						Path:      "/",
+					},
+					Response: &envoy_data_accesslog_v2.HTTPResponseProperties{
+						ResponseCode: protobuf.UInt32(200),
+					},
+				}},
+			},
+		},
+	}, {
+		LogEntries: &envoy_service_accesslog_v2.StreamAccessLogsMessage_TcpLogs{
+			TcpLogs: &envoy_service_accesslog_v2.StreamAccessLogsMessage_TCPAccessLogEntries{
+				LogEntry: []*envoy_data_accesslog_v2.TCPAccessLogEntry{{
+					ConnectionProperties: &envoy_data_accesslog_v2.ConnectionProperties{
+						ReceivedBytes: 10,
+						SentBytes:     20,
+					},
+				}},
+			},
+		},
+	}}
+	st := &mockAccessLogStream{
+		recv: func() (*envoy_service_accesslog_v2.StreamAccessLogsMessage, error) {
+			if len(msgs) == 0 {
+				return nil, io.EOF
+			}
+			msg := msgs[0]
+			msgs = msgs[1:]
+			return msg, nil
+		},
+	}
+
+	if err := als.StreamAccessLogs(st); err != nil {
+		t.Fatal(err)
+	}
+	if !st.closed {
+		t.Fatal("expected stream to be closed")
+	}
+
+	want := `{"node_id":"envoy","log_name":"contour","http":{"request":{"authority":"www.example.com","path":"/"},"response":{"response_code":200}}}
+{"node_id":"envoy","log_name":"contour","tcp":{"connection_properties":{"received_bytes":"10","sent_bytes":"20"}}}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+type mockAccessLogStream struct {
+	grpc.ServerStream
+	recv   func() (*envoy_service_accesslog_v2.StreamAccessLogsMessage, error)
+	closed bool
+}
+
+func (m *mockAccessLogStream) Context() context.Context { return context.Background() }
+func (m *mockAccessLogStream) Recv() (*envoy_service_accesslog_v2.StreamAccessLogsMessage, error) {
+	return m.recv()
+}
+func (m *mockAccessLogStream) SendAndClose(*envoy_service_accesslog_v2.StreamAccessLogsResponse) error {
+	m.closed = true
+	return nil
+}
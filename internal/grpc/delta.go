@@ -0,0 +1,437 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+type deltaStream interface {
+	Context() context.Context
+	Send(*envoy_api_v2.DeltaDiscoveryResponse) error
+	Recv() (*envoy_api_v2.DeltaDiscoveryRequest, error)
+}
+
+// deltaState records the resources, and their versions, held by
+// the Envoy on the other end of a delta xDS stream.
+type deltaState struct {
+	// wildcard is true if the Envoy subscribed to every
+	// resource of this type rather than a set of names.
+	wildcard bool
+
+	// subscribed is the set of resource names the Envoy has
+	// subscribed to. Ignored if wildcard is true.
+	subscribed map[string]bool
+
+	// versions holds the version of each resource last sent to,
+	// or reported by, the Envoy.
+	versions map[string]string
+
+	// rejected holds the version of each resource the Envoy
+	// rejected, or rejectedRemoval if it rejected the removal of
+	// the resource.
+	rejected map[string]string
+
+	// pending holds, by nonce, each response sent to the Envoy
+	// which it has not yet ACKed or NACKed.
+	pending map[string]*deltaResponse
+}
+
+// deltaResponse records a response sent on a delta xDS stream
+// which is awaiting its ACK or NACK.
+type deltaResponse struct {
+	// seq orders the responses in the order they were sent.
+	seq uint64
+
+	// version is the system version of the response.
+	version string
+
+	// prev holds the version of each resource the response
+	// changed before it was sent, or the empty string if the
+	// response added the resource.
+	prev map[string]string
+}
+
+// rejectedRemoval is recorded in deltaState.rejected for a resource
+// whose removal the Envoy rejected. Resource versions are never empty.
+const rejectedRemoval = ""
+
+// update applies the subscription changes in req to the deltaState.
+func (ds *deltaState) update(req *envoy_api_v2.DeltaDiscoveryRequest) {
+	for _, name := range req.ResourceNamesSubscribe {
+		ds.subscribed[name] = true
+	}
+	for _, name := range req.ResourceNamesUnsubscribe {
+		delete(ds.subscribed, name)
+		delete(ds.versions, name)
+		delete(ds.rejected, name)
+		for _, resp := range ds.pending {
+			delete(resp.prev, name)
+		}
+	}
+}
+
+// reject records that the Envoy rejected resp, keeping the version
+// of each resource it held before resp. If a later response changed
+// a resource again, that version is what the Envoy held when the later
+// response was sent, so the rejection no longer affects the resource.
+func (ds *deltaState) reject(resp *deltaResponse) {
+	for name, version := range resp.prev {
+		if later := ds.next(resp.seq, name); later != nil {
+			later.prev[name] = version
+			continue
+		}
+		if sent, ok := ds.versions[name]; ok {
+			ds.rejected[name] = sent
+		} else {
+			ds.rejected[name] = rejectedRemoval
+		}
+		if version == "" {
+			delete(ds.versions, name)
+		} else {
+			ds.versions[name] = version
+		}
+	}
+}
+
+// next returns the earliest pending response sent after seq
+// which changed the named resource, or nil if there is none.
+func (ds *deltaState) next(seq uint64, name string) *deltaResponse {
+	var next *deltaResponse
+	for _, resp := range ds.pending {
+		if _, ok := resp.prev[name]; !ok || resp.seq <= seq {
+			continue
+		}
+		if next == nil || resp.seq < next.seq {
+			next = resp
+		}
+	}
+	return next
+}
+
+// names returns the sorted names of the subscribed resources.
+func (ds *deltaState) names() []string {
+	var names []string
+	for name := range ds.subscribed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diff returns the resources whose version differs from the version
+// held by the Envoy, and the names of the resources the Envoy holds
+// which are no longer present. diff records the new versions as sent,
+// and returns the versions they replace.
+//
+// A resource the Envoy rejected is not sent again until its version
+// changes. A removal the Envoy rejected is sent again with the next
+// response which sends or removes another resource.
+func (ds *deltaState) diff(typeURL string, values []proto.Message) ([]*envoy_api_v2.Resource, []string, map[string]string, error) {
+	var resources []*envoy_api_v2.Resource
+	prev := make(map[string]string)
+	present := make(map[string]bool)
+	for _, value := range values {
+		name := resourceName(value)
+		present[name] = true
+
+		buf := proto.NewBuffer(nil)
+		buf.SetDeterministic(true)
+		if err := buf.Marshal(value); err != nil {
+			return nil, nil, nil, err
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		version := hex.EncodeToString(sum[:])
+		if rejected, ok := ds.rejected[name]; ok {
+			if rejected == version {
+				// Envoy rejected this version of the resource.
+				continue
+			}
+			delete(ds.rejected, name)
+		}
+		if ds.versions[name] == version {
+			// Envoy already has this version of the resource.
+			continue
+		}
+		prev[name] = ds.versions[name]
+		ds.versions[name] = version
+		resources = append(resources, &envoy_api_v2.Resource{
+			Name:     name,
+			Version:  version,
+			Resource: &any.Any{TypeUrl: typeURL, Value: buf.Bytes()},
+		})
+	}
+
+	var gone, retry []string
+	for name := range ds.versions {
+		if present[name] {
+			continue
+		}
+		if rejected, ok := ds.rejected[name]; ok && rejected == rejectedRemoval {
+			retry = append(retry, name)
+			continue
+		}
+		gone = append(gone, name)
+	}
+	if len(resources) > 0 || len(gone) > 0 {
+		gone = append(gone, retry...)
+	}
+	for _, name := range gone {
+		prev[name] = ds.versions[name]
+		delete(ds.versions, name)
+		delete(ds.rejected, name)
+	}
+	for name := range ds.rejected {
+		if !present[name] && ds.versions[name] == "" {
+			// a rejected resource the Envoy never held
+			// which is no longer present.
+			delete(ds.rejected, name)
+		}
+	}
+	sort.Strings(gone)
+	return resources, gone, prev, nil
+}
+
+// delta processes a stream of DeltaDiscoveryRequests. Unlike stream,
+// which sends every resource on each change, delta sends only the
+// resources which were added or changed and the names of those that
+// were removed since the last response. If Envoy rejects a response
+// the resources in it are not sent again until they change, but the
+// removals in it are retried with the next response.
+func (xh *xdsHandler) delta(st deltaStream) (err error) {
+	// bump connection counter and set it as a field on the logger
+	connection := xh.connections.next()
+	log := xh.WithField("connection", connection)
+
+	// node and typeURL identify the Envoy and the resource type
+	// being streamed, they are learnt from the first request.
+	var node, typeURL string
+
+	// set up some nice function exit handling which notifies if the
+	// stream terminated on error or not.
+	defer func() {
+		xh.status.remove(node, typeURL, connection)
+		if typeURL != "" {
+			xh.metrics.XDSStreamClosed(typeURL, node)
+		}
+		if err != nil {
+			log.WithError(err).Error("delta stream terminated")
+		} else {
+			log.Info("delta stream terminated")
+		}
+	}()
+
+	if err := xh.waitReady(st.Context()); err != nil {
+		return err
+	}
+
+	// the first request on a delta stream establishes the type
+	// of resource being streamed and the initial subscription.
+	req, err := st.Recv()
+	if err != nil {
+		return err
+	}
+	r, ok := xh.resources[req.TypeUrl]
+	if !ok {
+		return fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl)
+	}
+	typeURL = r.TypeURL()
+	if req.Node != nil {
+		node = req.Node.Id
+	}
+	log = log.WithField("type_url", typeURL).WithField("node_id", node)
+	xh.metrics.XDSStreamOpened(typeURL, node)
+
+	ds := &deltaState{
+		// an initial request which names no resources
+		// subscribes to every resource of this type.
+		wildcard:   len(req.ResourceNamesSubscribe) == 0,
+		subscribed: make(map[string]bool),
+		versions:   make(map[string]string),
+		rejected:   make(map[string]string),
+		pending:    make(map[string]*deltaResponse),
+	}
+	for name, version := range req.InitialResourceVersions {
+		ds.versions[name] = version
+	}
+	ds.update(req)
+	requested := func() {
+		if !ds.wildcard {
+			xh.status.requested(node, typeURL, connection, ds.names())
+		}
+	}
+	requested()
+
+	ctx := st.Context()
+
+	// subsequent requests are received in the background so
+	// changes to the resource can be sent without waiting for
+	// Envoy to send another request.
+	reqs := make(chan *envoy_api_v2.DeltaDiscoveryRequest)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req, err := st.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case reqs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var nonces counter
+	send := func(last int, force, push bool) error {
+		var values []proto.Message
+		if ds.wildcard {
+			values = r.Contents()
+		} else {
+			values = r.Query(ds.names())
+		}
+		resources, removed, prev, err := ds.diff(r.TypeURL(), values)
+		if err != nil {
+			return err
+		}
+		if len(resources) == 0 && len(removed) == 0 && !force {
+			// nothing has changed from Envoy's point of view.
+			return nil
+		}
+		resp := &envoy_api_v2.DeltaDiscoveryResponse{
+			SystemVersionInfo: strconv.Itoa(last),
+			Resources:         resources,
+			TypeUrl:           r.TypeURL(),
+			RemovedResources:  removed,
+		}
+		seq := nonces.next()
+		resp.Nonce = strconv.FormatUint(seq, 10)
+		if err := st.Send(resp); err != nil {
+			return err
+		}
+		xh.metrics.XDSResponseSent(typeURL, node, proto.Size(resp), push)
+		ds.pending[resp.Nonce] = &deltaResponse{
+			seq:     seq,
+			version: resp.SystemVersionInfo,
+			prev:    prev,
+		}
+		xh.status.sent(node, typeURL, connection, resp.SystemVersionInfo, resp.Nonce)
+		log.WithField("count", len(resources)).WithField("removed", len(removed)).Info("delta response")
+		return nil
+	}
+
+	ch := make(chan int, 1)
+
+	// internally all registration values start at zero so sending
+	// a last that is less than zero will guarantee that the first
+	// registration fires immediately and Envoy receives the
+	// initial set of resources.
+	last := -1
+	registered := false
+
+	// Envoy waits for a response to its initial request, even
+	// if there are no resources to send.
+	initial := true
+	for {
+		if !registered {
+			// no hints are passed as the subscription may change while
+			// registered; diff discards notifications which do not
+			// change any subscribed resource.
+			r.Register(ch, last)
+			registered = true
+		}
+
+		select {
+		case last = <-ch:
+			registered = false
+			// notifications after the initial response are pushes
+			// caused by a DAG rebuild, unless they are endpoints.
+			push := !initial && typeURL != cache.EndpointType
+			if err := send(last, initial, push); err != nil {
+				return err
+			}
+			initial = false
+		case req := <-reqs:
+			if req.TypeUrl != "" && req.TypeUrl != r.TypeURL() {
+				return fmt.Errorf("unexpected typeURL %q on %q delta stream", req.TypeUrl, r.TypeURL())
+			}
+			log := log.WithField("response_nonce", req.ResponseNonce)
+			if resp, ok := ds.pending[req.ResponseNonce]; ok {
+				// Envoy replies to every response in the order
+				// they were sent, several may await a reply.
+				if err := req.ErrorDetail; err != nil {
+					log.WithField("code", err.Code).WithField("nacked_version", resp.version).Error(err.Message)
+					xh.status.nack(node, typeURL, connection, resp.version, err.Message)
+					xh.metrics.XDSNackReceived(typeURL, node)
+					// Envoy kept its previous versions of the
+					// resources in the rejected response.
+					ds.reject(resp)
+				} else {
+					xh.status.ack(node, typeURL, connection, resp.version)
+				}
+				delete(ds.pending, req.ResponseNonce)
+			}
+			if len(req.ResourceNamesSubscribe) == 0 && len(req.ResourceNamesUnsubscribe) == 0 {
+				// an ACK or NACK of a previous response.
+				continue
+			}
+			log.WithField("subscribe", req.ResourceNamesSubscribe).WithField("unsubscribe", req.ResourceNamesUnsubscribe).Info("delta subscription")
+			ds.update(req)
+			requested()
+			if initial {
+				// the initial response has not been sent yet,
+				// it will include this subscription.
+				continue
+			}
+			if err := send(last, false, false); err != nil {
+				return err
+			}
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// resourceName returns the name Envoy uses to identify the resource.
+func resourceName(m proto.Message) string {
+	switch m := m.(type) {
+	case *envoy_api_v2.Cluster:
+		return m.Name
+	case *envoy_api_v2.ClusterLoadAssignment:
+		return m.ClusterName
+	case *envoy_api_v2.Listener:
+		return m.Name
+	case *envoy_api_v2.RouteConfiguration:
+		return m.Name
+	case *envoy_api_v2_auth.Secret:
+		return m.Name
+	default:
+		return ""
+	}
+}
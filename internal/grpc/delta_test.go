@@ -0,0 +1,416 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/heptio/contour/internal/contour"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/status"
+)
+
+func TestXDSHandlerDeltaErrors(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	tests := map[string]struct {
+		xh     xdsHandler
+		stream deltaStream
+		want   error
+	}{
+		"recv returns error immediately": {
+			xh: xdsHandler{FieldLogger: log},
+			stream: &mockDeltaStream{
+				context: context.Background,
+				recv: func() (*v2.DeltaDiscoveryRequest, error) {
+					return nil, io.EOF
+				},
+			},
+			want: io.EOF,
+		},
+		"no registered typeURL": {
+			xh: xdsHandler{FieldLogger: log},
+			stream: &mockDeltaStream{
+				context: context.Background,
+				recv: func() (*v2.DeltaDiscoveryRequest, error) {
+					return &v2.DeltaDiscoveryRequest{
+						TypeUrl: "com.heptio.potato",
+					}, nil
+				},
+			},
+			want: fmt.Errorf("no resource registered for typeURL %q", "com.heptio.potato"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.xh.delta(tc.stream)
+			if !equalError(tc.want, got) {
+				t.Fatalf("expected: %v, got: %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestXDSHandlerDeltaWildcard(t *testing.T) {
+	r := &deltaResource{}
+	r.set(cluster("a", 1), cluster("b", 1))
+
+	dh := startDelta(t, r, &v2.DeltaDiscoveryRequest{TypeUrl: cache.ClusterType})
+	defer dh.stop(t)
+
+	// the initial response holds every resource.
+	dh.expect(t, []string{"a", "b"}, nil)
+
+	// only the changed resource, and the removed resource, are sent.
+	r.set(cluster("b", 2))
+	dh.expect(t, []string{"b"}, []string{"a"})
+
+	// a notification which changes nothing sends nothing, so the
+	// next response holds only the added resource.
+	r.set(cluster("b", 2))
+	r.set(cluster("b", 2), cluster("c", 1))
+	dh.expect(t, []string{"c"}, nil)
+}
+
+func TestXDSHandlerDeltaSubscribe(t *testing.T) {
+	r := &deltaResource{}
+	r.set(cluster("a", 1), cluster("b", 1), cluster("c", 1))
+
+	dh := startDelta(t, r, &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"a"},
+	})
+	defer dh.stop(t)
+
+	// only the subscribed resource is sent.
+	dh.expect(t, []string{"a"}, nil)
+
+	// subscribing to another resource sends only that resource.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"b"},
+	}
+	dh.expect(t, []string{"b"}, nil)
+
+	// changes to unsubscribed resources are not sent.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:                  cache.ClusterType,
+		ResourceNamesSubscribe:   []string{"c"},
+		ResourceNamesUnsubscribe: []string{"a"},
+	}
+	dh.expect(t, []string{"c"}, nil)
+	r.set(cluster("a", 2), cluster("b", 1), cluster("c", 1))
+	r.set(cluster("a", 2), cluster("b", 2), cluster("c", 1))
+	dh.expect(t, []string{"b"}, nil)
+}
+
+func TestXDSHandlerDeltaInitialVersions(t *testing.T) {
+	r := &deltaResource{}
+	r.set(cluster("a", 1))
+
+	var ds deltaState
+	ds.versions = make(map[string]string)
+	resources, _, _, err := ds.diff(cache.ClusterType, r.Contents())
+	check(t, err)
+
+	// an Envoy which reconnects holding the current version of a
+	// and a stale resource b receives only the removal of b.
+	dh := startDelta(t, r, &v2.DeltaDiscoveryRequest{
+		TypeUrl: cache.ClusterType,
+		InitialResourceVersions: map[string]string{
+			"a": resources[0].Version,
+			"b": "stale",
+		},
+	})
+	defer dh.stop(t)
+
+	dh.expect(t, nil, []string{"b"})
+}
+
+func TestXDSHandlerDeltaNack(t *testing.T) {
+	r := &deltaResource{}
+	r.set(cluster("a", 1), cluster("b", 1))
+
+	dh := startDelta(t, r, &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"a"},
+	})
+	defer dh.stop(t)
+
+	resp := dh.expect(t, []string{"a"}, nil)
+
+	// Envoy rejects a and keeps its previous version.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:       cache.ClusterType,
+		ResponseNonce: resp.Nonce,
+		ErrorDetail:   &status.Status{Message: "rejected"},
+	}
+
+	// the rejected version of a is not sent again.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"b"},
+	}
+	dh.expect(t, []string{"b"}, nil)
+
+	// until it changes.
+	r.set(cluster("a", 2), cluster("b", 1))
+	dh.expect(t, []string{"a"}, nil)
+}
+
+func TestXDSHandlerDeltaNackRemoval(t *testing.T) {
+	r := &deltaResource{}
+	r.set(cluster("a", 1), cluster("b", 1), cluster("c", 1))
+
+	dh := startDelta(t, r, &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"a", "b"},
+	})
+	defer dh.stop(t)
+
+	dh.expect(t, []string{"a", "b"}, nil)
+	r.set(cluster("a", 1), cluster("c", 1))
+	resp := dh.expect(t, nil, []string{"b"})
+
+	// Envoy rejects the removal of b and keeps it.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:       cache.ClusterType,
+		ResponseNonce: resp.Nonce,
+		ErrorDetail:   &status.Status{Message: "rejected"},
+	}
+
+	// the removal of b is not sent on its own.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"d"},
+	}
+
+	// but with the next response.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"c"},
+	}
+	dh.expect(t, []string{"c"}, []string{"b"})
+}
+
+func TestXDSHandlerDeltaNackOutstanding(t *testing.T) {
+	r := &deltaResource{}
+	r.set(cluster("a", 1), cluster("b", 1), cluster("c", 1))
+
+	dh := startDelta(t, r, &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"a", "b"},
+	})
+	defer dh.stop(t)
+
+	resp := dh.expect(t, []string{"a", "b"}, nil)
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:       cache.ClusterType,
+		ResponseNonce: resp.Nonce,
+	}
+
+	// two responses are sent before Envoy replies to either.
+	r.set(cluster("a", 2), cluster("b", 2), cluster("c", 1))
+	first := dh.expect(t, []string{"a", "b"}, nil)
+	r.set(cluster("a", 3), cluster("b", 2), cluster("c", 1))
+	second := dh.expect(t, []string{"a"}, nil)
+
+	// Envoy rejects the first response, keeping a and b at
+	// version 1, then accepts the second, updating a.
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:       cache.ClusterType,
+		ResponseNonce: first.Nonce,
+		ErrorDetail:   &status.Status{Message: "rejected"},
+	}
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:       cache.ClusterType,
+		ResponseNonce: second.Nonce,
+	}
+	dh.reqs <- &v2.DeltaDiscoveryRequest{
+		TypeUrl:                cache.ClusterType,
+		ResourceNamesSubscribe: []string{"c"},
+	}
+	dh.expect(t, []string{"c"}, nil)
+
+	// Envoy holds version 3 of a and version 1 of b.
+	r.set(cluster("a", 1), cluster("b", 1), cluster("c", 2))
+	dh.expect(t, []string{"a", "c"}, nil)
+
+	want := []NodeStatus{{
+		Streams: []ResourceStatus{{
+			TypeURL:           cache.ClusterType,
+			Connection:        1,
+			ResourceNames:     []string{"a", "b", "c"},
+			LastSentVersion:   "4",
+			LastSentNonce:     "5",
+			LastAckedVersion:  second.SystemVersionInfo,
+			LastNackedVersion: first.SystemVersionInfo,
+			LastNackMessage:   "rejected",
+		}},
+	}}
+	if diff := cmp.Diff(want, dh.status.Nodes(), cmpopts.IgnoreFields(ResourceStatus{}, "LastNackTime")); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+// deltaHarness drives xdsHandler.delta with a mock stream.
+type deltaHarness struct {
+	reqs   chan *v2.DeltaDiscoveryRequest
+	resps  chan *v2.DeltaDiscoveryResponse
+	done   chan error
+	cancel func()
+	status *StatusCache
+}
+
+func startDelta(t *testing.T, r Resource, initial *v2.DeltaDiscoveryRequest) *deltaHarness {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	sc := new(StatusCache)
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			r.TypeURL(): r,
+		},
+		status: sc,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dh := &deltaHarness{
+		reqs:   make(chan *v2.DeltaDiscoveryRequest, 1),
+		resps:  make(chan *v2.DeltaDiscoveryResponse, 1),
+		done:   make(chan error, 1),
+		cancel: cancel,
+		status: sc,
+	}
+	dh.reqs <- initial
+	st := &mockDeltaStream{
+		context: func() context.Context { return ctx },
+		recv: func() (*v2.DeltaDiscoveryRequest, error) {
+			select {
+			case req := <-dh.reqs:
+				return req, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+		send: func(resp *v2.DeltaDiscoveryResponse) error {
+			dh.resps <- resp
+			return nil
+		},
+	}
+	go func() {
+		dh.done <- xh.delta(st)
+	}()
+	return dh
+}
+
+// expect asserts the next response holds the named resources
+// and removes the named resources, and returns the response.
+func (dh *deltaHarness) expect(t *testing.T, names, removed []string) *v2.DeltaDiscoveryResponse {
+	t.Helper()
+	select {
+	case resp := <-dh.resps:
+		var got []string
+		for _, r := range resp.Resources {
+			got = append(got, r.Name)
+		}
+		if diff := cmp.Diff(names, got); diff != "" {
+			t.Fatal(diff)
+		}
+		if diff := cmp.Diff(removed, resp.RemovedResources); diff != "" {
+			t.Fatal(diff)
+		}
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for delta response")
+	}
+	return nil
+}
+
+func (dh *deltaHarness) stop(t *testing.T) {
+	t.Helper()
+	dh.cancel()
+	if err := <-dh.done; err != context.Canceled {
+		t.Fatalf("expected: %v, got: %v", context.Canceled, err)
+	}
+}
+
+// deltaResource is a Resource whose contents can be changed.
+type deltaResource struct {
+	contour.Cond
+	mu     sync.Mutex
+	values []proto.Message
+}
+
+// set replaces the contents of the deltaResource and notifies
+// any registered waiters.
+func (d *deltaResource) set(values ...proto.Message) {
+	d.mu.Lock()
+	d.values = values
+	d.mu.Unlock()
+	d.Notify()
+}
+
+func (d *deltaResource) Contents() []proto.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.values
+}
+
+func (d *deltaResource) Query(names []string) []proto.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var values []proto.Message
+	for _, n := range names {
+		for _, v := range d.values {
+			if resourceName(v) == n {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func (d *deltaResource) TypeURL() string { return cache.ClusterType }
+
+func cluster(name string, timeout time.Duration) *v2.Cluster {
+	return &v2.Cluster{
+		Name:           name,
+		ConnectTimeout: ptypes.DurationProto(timeout * time.Second),
+	}
+}
+
+type mockDeltaStream struct {
+	context func() context.Context
+	send    func(*v2.DeltaDiscoveryResponse) error
+	recv    func() (*v2.DeltaDiscoveryRequest, error)
+}
+
+func (m *mockDeltaStream) Context() context.Context                   { return m.context() }
+func (m *mockDeltaStream) Send(resp *v2.DeltaDiscoveryResponse) error { return m.send(resp) }
+func (m *mockDeltaStream) Recv() (*v2.DeltaDiscoveryRequest, error)   { return m.recv() }
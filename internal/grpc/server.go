@@ -22,16 +22,37 @@ import (
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	loadstats "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v2"
+	"github.com/heptio/contour/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
+// APIConfig holds the configuration of the xDS gRPC API.
+type APIConfig struct {
+	// Resources are the xDS resources served, keyed by type URL.
+	Resources map[string]Resource
+
+	// Status, if not nil, records the ACK/NACK status of each
+	// connected Envoy.
+	Status *StatusCache
+
+	// Metrics, if not nil, records the stream, response, and
+	// NACK counts of each connected Envoy.
+	Metrics *metrics.Metrics
+
+	// Ready, if not nil, holds back all responses until it is closed.
+	Ready <-chan struct{}
+}
+
 // NewAPI returns a *grpc.Server which responds to the Envoy v2 xDS gRPC API.
-func NewAPI(log logrus.FieldLogger, resources map[string]Resource, opts ...grpc.ServerOption) *grpc.Server {
+func NewAPI(log logrus.FieldLogger, config APIConfig, opts ...grpc.ServerOption) *grpc.Server {
 	g := grpc.NewServer(opts...)
 	s := &grpcServer{
 		xdsHandler{
 			FieldLogger: log,
-			resources:   resources,
+			resources:   config.Resources,
+			status:      config.Status,
+			metrics:     config.Metrics,
+			ready:       config.Ready,
 		},
 	}
 
@@ -56,16 +77,16 @@ func (s *grpcServer) FetchEndpoints(_ context.Context, req *v2.DiscoveryRequest)
 	return nil, status.Errorf(codes.Unimplemented, "FetchEndpoints unimplemented")
 }
 
-func (s *grpcServer) DeltaEndpoints(v2.EndpointDiscoveryService_DeltaEndpointsServer) error {
-	return status.Errorf(codes.Unimplemented, "DeltaEndpoints unimplemented")
+func (s *grpcServer) DeltaEndpoints(srv v2.EndpointDiscoveryService_DeltaEndpointsServer) error {
+	return s.delta(srv)
 }
 
 func (s *grpcServer) FetchListeners(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "FetchListeners unimplemented")
 }
 
-func (s *grpcServer) DeltaListeners(v2.ListenerDiscoveryService_DeltaListenersServer) error {
-	return status.Errorf(codes.Unimplemented, "DeltaListeners unimplemented")
+func (s *grpcServer) DeltaListeners(srv v2.ListenerDiscoveryService_DeltaListenersServer) error {
+	return s.delta(srv)
 }
 
 func (s *grpcServer) FetchRoutes(_ context.Context, req *v2.DiscoveryRequest) (*v2.DiscoveryResponse, error) {
@@ -76,8 +97,8 @@ func (s *grpcServer) FetchSecrets(_ context.Context, req *v2.DiscoveryRequest) (
 	return nil, status.Errorf(codes.Unimplemented, "FetchSecrets unimplemented")
 }
 
-func (s *grpcServer) DeltaSecrets(discovery.SecretDiscoveryService_DeltaSecretsServer) error {
-	return status.Errorf(codes.Unimplemented, "DeltaSecrets unimplemented")
+func (s *grpcServer) DeltaSecrets(srv discovery.SecretDiscoveryService_DeltaSecretsServer) error {
+	return s.delta(srv)
 }
 
 func (s *grpcServer) StreamClusters(srv v2.ClusterDiscoveryService_StreamClustersServer) error {
@@ -92,12 +113,12 @@ func (s *grpcServer) StreamLoadStats(srv loadstats.LoadReportingService_StreamLo
 	return status.Errorf(codes.Unimplemented, "StreamLoadStats unimplemented")
 }
 
-func (s *grpcServer) DeltaClusters(v2.ClusterDiscoveryService_DeltaClustersServer) error {
-	return status.Errorf(codes.Unimplemented, "IncrementalClusters unimplemented")
+func (s *grpcServer) DeltaClusters(srv v2.ClusterDiscoveryService_DeltaClustersServer) error {
+	return s.delta(srv)
 }
 
-func (s *grpcServer) DeltaRoutes(v2.RouteDiscoveryService_DeltaRoutesServer) error {
-	return status.Errorf(codes.Unimplemented, "IncrementalRoutes unimplemented")
+func (s *grpcServer) DeltaRoutes(srv v2.RouteDiscoveryService_DeltaRoutesServer) error {
+	return s.delta(srv)
 }
 
 func (s *grpcServer) StreamListeners(srv v2.ListenerDiscoveryService_StreamListenersServer) error {
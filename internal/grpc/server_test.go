@@ -199,12 +199,16 @@ func TestGRPC(t *testing.T) {
 				Metrics:      ch.Metrics,
 				FieldLogger:  log,
 			}
-			srv := NewAPI(log, map[string]Resource{
-				ch.ClusterCache.TypeURL():  &ch.ClusterCache,
-				ch.RouteCache.TypeURL():    &ch.RouteCache,
-				ch.ListenerCache.TypeURL(): &ch.ListenerCache,
-				ch.SecretCache.TypeURL():   &ch.SecretCache,
-				et.TypeURL():               et,
+			srv := NewAPI(log, APIConfig{
+				Resources: map[string]Resource{
+					ch.ClusterCache.TypeURL():  &ch.ClusterCache,
+					ch.RouteCache.TypeURL():    &ch.RouteCache,
+					ch.ListenerCache.TypeURL(): &ch.ListenerCache,
+					ch.SecretCache.TypeURL():   &ch.SecretCache,
+					et.TypeURL():               et,
+				},
+				Status:  new(StatusCache),
+				Metrics: ch.Metrics,
 			})
 			l, err := net.Listen("tcp", "127.0.0.1:0")
 			check(t, err)
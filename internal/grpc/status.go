@@ -0,0 +1,176 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ResourceStatus records the configuration of a single resource type
+// sent to, and acknowledged or rejected by, an Envoy on one stream.
+type ResourceStatus struct {
+	// TypeURL is the type of resource.
+	TypeURL string `json:"type_url"`
+
+	// Connection identifies the stream delivering this resource.
+	Connection uint64 `json:"connection"`
+
+	// ResourceNames are the names of the resources the Envoy
+	// last requested on the stream, none if it requested every
+	// resource of the type.
+	ResourceNames []string `json:"resource_names,omitempty"`
+
+	// LastSentVersion and LastSentNonce identify the
+	// most recent response sent to the Envoy.
+	LastSentVersion string `json:"last_sent_version,omitempty"`
+	LastSentNonce   string `json:"last_sent_nonce,omitempty"`
+
+	// LastAckedVersion is the most recent version the Envoy accepted.
+	LastAckedVersion string `json:"last_acked_version,omitempty"`
+
+	// LastNackedVersion is the most recent version the Envoy rejected,
+	// LastNackMessage and LastNackTime record why and when.
+	LastNackedVersion string     `json:"last_nacked_version,omitempty"`
+	LastNackMessage   string     `json:"last_nack_message,omitempty"`
+	LastNackTime      *time.Time `json:"last_nack_time,omitempty"`
+}
+
+// NodeStatus holds the status of each stream to an Envoy. An Envoy
+// may open several streams of one resource type, such as one for
+// each route configuration it requests.
+type NodeStatus struct {
+	// Node is the Envoy's node id.
+	Node string `json:"node"`
+
+	Streams []ResourceStatus `json:"streams"`
+}
+
+// StatusCache records, for each stream of each Envoy node, the versions
+// of configuration sent to, acknowledged by, and rejected by that node.
+// A nil *StatusCache records nothing.
+type StatusCache struct {
+	mu    sync.Mutex
+	nodes map[string]map[stream]*ResourceStatus // node id -> stream -> status
+}
+
+// stream identifies a stream of one resource type to an Envoy.
+type stream struct {
+	typeURL    string
+	connection uint64
+}
+
+// Nodes returns a copy of the status of every connected node, sorted
+// by node id, and each of its streams sorted by resource type and
+// connection.
+func (sc *StatusCache) Nodes() []NodeStatus {
+	if sc == nil {
+		return nil
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	var nodes []NodeStatus
+	for node, streams := range sc.nodes {
+		ns := NodeStatus{Node: node}
+		for _, rs := range streams {
+			ns.Streams = append(ns.Streams, *rs)
+		}
+		sort.Slice(ns.Streams, func(i, j int) bool {
+			a, b := ns.Streams[i], ns.Streams[j]
+			if a.TypeURL != b.TypeURL {
+				return a.TypeURL < b.TypeURL
+			}
+			return a.Connection < b.Connection
+		})
+		nodes = append(nodes, ns)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+	return nodes
+}
+
+// requested records the names of the resources node
+// requested on connection.
+func (sc *StatusCache) requested(node, typeURL string, connection uint64, names []string) {
+	sc.update(node, typeURL, connection, func(rs *ResourceStatus) {
+		rs.ResourceNames = append([]string(nil), names...)
+	})
+}
+
+// sent records that version was sent to node with nonce on connection.
+func (sc *StatusCache) sent(node, typeURL string, connection uint64, version, nonce string) {
+	sc.update(node, typeURL, connection, func(rs *ResourceStatus) {
+		rs.LastSentVersion = version
+		rs.LastSentNonce = nonce
+	})
+}
+
+// ack records that node accepted version on connection.
+func (sc *StatusCache) ack(node, typeURL string, connection uint64, version string) {
+	sc.update(node, typeURL, connection, func(rs *ResourceStatus) {
+		rs.LastAckedVersion = version
+	})
+}
+
+// nack records that node rejected version on connection with message.
+func (sc *StatusCache) nack(node, typeURL string, connection uint64, version, message string) {
+	sc.update(node, typeURL, connection, func(rs *ResourceStatus) {
+		rs.LastNackedVersion = version
+		rs.LastNackMessage = message
+		now := time.Now()
+		rs.LastNackTime = &now
+	})
+}
+
+// remove discards the status of the stream of typeURL to node on connection.
+func (sc *StatusCache) remove(node, typeURL string, connection uint64) {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	streams := sc.nodes[node]
+	delete(streams, stream{typeURL: typeURL, connection: connection})
+	if len(streams) == 0 {
+		delete(sc.nodes, node)
+	}
+}
+
+func (sc *StatusCache) update(node, typeURL string, connection uint64, fn func(*ResourceStatus)) {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.nodes == nil {
+		sc.nodes = make(map[string]map[stream]*ResourceStatus)
+	}
+	streams, ok := sc.nodes[node]
+	if !ok {
+		streams = make(map[stream]*ResourceStatus)
+		sc.nodes[node] = streams
+	}
+	key := stream{typeURL: typeURL, connection: connection}
+	rs, ok := streams[key]
+	if !ok {
+		rs = &ResourceStatus{
+			TypeURL:    typeURL,
+			Connection: connection,
+		}
+		streams[key] = rs
+	}
+	fn(rs)
+}
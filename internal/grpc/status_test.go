@@ -0,0 +1,142 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStatusCache(t *testing.T) {
+	tests := map[string]struct {
+		fn   func(*StatusCache)
+		want []NodeStatus
+	}{
+		"empty": {
+			fn:   func(*StatusCache) {},
+			want: nil,
+		},
+		"sent and acked": {
+			fn: func(sc *StatusCache) {
+				sc.sent("envoy", "clusters", 1, "7", "1")
+				sc.ack("envoy", "clusters", 1, "7")
+			},
+			want: []NodeStatus{{
+				Node: "envoy",
+				Streams: []ResourceStatus{{
+					TypeURL:          "clusters",
+					Connection:       1,
+					LastSentVersion:  "7",
+					LastSentNonce:    "1",
+					LastAckedVersion: "7",
+				}},
+			}},
+		},
+		"nacked": {
+			fn: func(sc *StatusCache) {
+				sc.sent("envoy", "listeners", 1, "3", "1")
+				sc.nack("envoy", "listeners", 1, "3", "bad listener")
+				sc.sent("envoy", "clusters", 2, "7", "1")
+			},
+			want: []NodeStatus{{
+				Node: "envoy",
+				Streams: []ResourceStatus{{
+					TypeURL:         "clusters",
+					Connection:      2,
+					LastSentVersion: "7",
+					LastSentNonce:   "1",
+				}, {
+					TypeURL:           "listeners",
+					Connection:        1,
+					LastSentVersion:   "3",
+					LastSentNonce:     "1",
+					LastNackedVersion: "3",
+					LastNackMessage:   "bad listener",
+				}},
+			}},
+		},
+		"concurrent streams of one type": {
+			fn: func(sc *StatusCache) {
+				sc.requested("envoy", "routes", 1, []string{"ingress_http"})
+				sc.requested("envoy", "routes", 2, []string{"ingress_https/example.com"})
+				sc.sent("envoy", "routes", 1, "7", "1")
+				sc.sent("envoy", "routes", 2, "7", "1")
+				sc.ack("envoy", "routes", 1, "7")
+				sc.nack("envoy", "routes", 2, "7", "bad route")
+			},
+			want: []NodeStatus{{
+				Node: "envoy",
+				Streams: []ResourceStatus{{
+					TypeURL:          "routes",
+					Connection:       1,
+					ResourceNames:    []string{"ingress_http"},
+					LastSentVersion:  "7",
+					LastSentNonce:    "1",
+					LastAckedVersion: "7",
+				}, {
+					TypeURL:           "routes",
+					Connection:        2,
+					ResourceNames:     []string{"ingress_https/example.com"},
+					LastSentVersion:   "7",
+					LastSentNonce:     "1",
+					LastNackedVersion: "7",
+					LastNackMessage:   "bad route",
+				}},
+			}},
+		},
+		"closing one stream keeps the others of its type": {
+			fn: func(sc *StatusCache) {
+				sc.requested("envoy", "clusters", 1, []string{"default/a/80"})
+				sc.requested("envoy", "clusters", 2, []string{"default/b/80"})
+				sc.sent("envoy", "clusters", 1, "7", "1")
+				sc.nack("envoy", "clusters", 1, "7", "bad cluster")
+				sc.sent("envoy", "clusters", 2, "8", "1")
+				sc.ack("envoy", "clusters", 2, "8")
+				sc.remove("envoy", "clusters", 2)
+			},
+			want: []NodeStatus{{
+				Node: "envoy",
+				Streams: []ResourceStatus{{
+					TypeURL:           "clusters",
+					Connection:        1,
+					ResourceNames:     []string{"default/a/80"},
+					LastSentVersion:   "7",
+					LastSentNonce:     "1",
+					LastNackedVersion: "7",
+					LastNackMessage:   "bad cluster",
+				}},
+			}},
+		},
+		"remove": {
+			fn: func(sc *StatusCache) {
+				sc.sent("envoy", "clusters", 1, "7", "1")
+				sc.remove("envoy", "clusters", 1)
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var sc StatusCache
+			tc.fn(&sc)
+			got := sc.Nodes()
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreFields(ResourceStatus{}, "LastNackTime")); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
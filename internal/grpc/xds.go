@@ -20,8 +20,10 @@ import (
 	"sync/atomic"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/heptio/contour/internal/metrics"
 	"github.com/sirupsen/logrus"
 )
 
@@ -46,6 +48,12 @@ type xdsHandler struct {
 	logrus.FieldLogger
 	connections counter
 	resources   map[string]Resource // registered resource types
+	status      *StatusCache        // per node ACK/NACK status
+	metrics     *metrics.Metrics    // per node stream metrics
+
+	// ready, if not nil, is closed once the resources hold
+	// the first complete DAG. Until then no responses are sent.
+	ready <-chan struct{}
 }
 
 type grpcStream interface {
@@ -57,11 +65,24 @@ type grpcStream interface {
 // stream processes a stream of DiscoveryRequests.
 func (xh *xdsHandler) stream(st grpcStream) (err error) {
 	// bump connection counter and set it as a field on the logger
-	log := xh.WithField("connection", xh.connections.next())
+	connection := xh.connections.next()
+	log := xh.WithField("connection", connection)
+
+	// node and typeURL identify the Envoy and the resource type
+	// being streamed, they are learnt from the first request.
+	var node, typeURL string
+
+	// opened records if this stream has been counted in
+	// the stream metrics for node and typeURL.
+	opened := false
 
 	// set up some nice function exit handling which notifies if the
 	// stream terminated on error or not.
 	defer func() {
+		xh.status.remove(node, typeURL, connection)
+		if opened {
+			xh.metrics.XDSStreamClosed(typeURL, node)
+		}
 		if err != nil {
 			log.WithError(err).Error("stream terminated")
 		} else {
@@ -69,13 +90,22 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 		}
 	}()
 
+	ctx := st.Context()
+	if err := xh.waitReady(ctx); err != nil {
+		return err
+	}
+
 	ch := make(chan int, 1)
 
+	// version and nonce record the most recent response sent
+	// on this stream. Envoy returns the nonce when it ACKs or
+	// NACKs that response.
+	var version, nonce string
+
 	// internally all registration values start at zero so sending
 	// a last that is less than zero will guarantee that each stream
 	// will generate a response immediately, then wait.
 	last := -1
-	ctx := st.Context()
 
 	// now stick in this loop until the client disconnects.
 	for {
@@ -89,14 +119,10 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 		// note: redeclare log in this scope so the next time around the loop all is forgotten.
 		log := log.WithField("version_info", req.VersionInfo).WithField("response_nonce", req.ResponseNonce)
 		if req.Node != nil {
-			log = log.WithField("node_id", req.Node.Id)
-		}
-
-		if err := req.ErrorDetail; err != nil {
-			// if Envoy rejected the last update log the details here.
-			// TODO(dfc) issue 1176: handle xDS ACK/NACK
-			log.WithField("code", err.Code).Error(err.Message)
+			// Envoy may only send its node on the first request.
+			node = req.Node.Id
 		}
+		log = log.WithField("node_id", node)
 
 		// from the request we derive the resource to stream which have
 		// been registered according to the typeURL.
@@ -104,7 +130,34 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 		if !ok {
 			return fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl)
 		}
+		typeURL = r.TypeURL()
 		log = log.WithField("resource_names", req.ResourceNames).WithField("type_url", req.TypeUrl)
+		if !opened {
+			xh.metrics.XDSStreamOpened(typeURL, node)
+			opened = true
+		}
+		xh.status.requested(node, typeURL, connection, req.ResourceNames)
+
+		switch {
+		case nonce == "":
+			// nothing has been sent on this stream, this is the
+			// initial request so there is nothing to ACK or NACK.
+		case req.ResponseNonce != nonce:
+			// this request refers to an earlier response, Envoy
+			// will send another request for the latest response.
+			log.Debug("ignoring request with stale nonce")
+			continue
+		case req.ErrorDetail != nil:
+			// Envoy rejected the last response. Envoy continues to use
+			// the last version it accepted so there is no point resending
+			// the rejected configuration until it changes.
+			err := req.ErrorDetail
+			log.WithField("code", err.Code).WithField("nacked_version", version).Error(err.Message)
+			xh.status.nack(node, typeURL, connection, version, err.Message)
+			xh.metrics.XDSNackReceived(typeURL, node)
+		default:
+			xh.status.ack(node, typeURL, connection, req.VersionInfo)
+		}
 		log.Info("stream_wait")
 
 		// now we wait for a notification, if this is the first request received on this
@@ -136,11 +189,21 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 				VersionInfo: strconv.Itoa(last),
 				Resources:   any,
 				TypeUrl:     r.TypeURL(),
-				Nonce:       strconv.Itoa(last),
+				// last increases with every response on this
+				// stream so it is also a unique nonce.
+				Nonce: strconv.Itoa(last),
 			}
 			if err := st.Send(resp); err != nil {
 				return err
 			}
+			// the first response on a stream answers the initial
+			// request, and endpoints change independently of the
+			// DAG, so only later non EDS responses are pushes
+			// caused by a DAG rebuild.
+			push := nonce != "" && typeURL != cache.EndpointType
+			xh.metrics.XDSResponseSent(typeURL, node, proto.Size(resp), push)
+			version, nonce = resp.VersionInfo, resp.Nonce
+			xh.status.sent(node, typeURL, connection, version, nonce)
 			log.WithField("count", len(resources)).Info("response")
 		case <-ctx.Done():
 			return ctx.Err()
@@ -148,6 +211,25 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 	}
 }
 
+// waitReady blocks until the xdsHandler is ready or ctx is done.
+func (xh *xdsHandler) waitReady(ctx context.Context) error {
+	if xh.ready == nil {
+		return nil
+	}
+	select {
+	case <-xh.ready:
+		return nil
+	default:
+		xh.Info("waiting for the first DAG before responding")
+	}
+	select {
+	case <-xh.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // toAny converts the contents of a resourcer's Values to the
 // respective slice of *any.Any.
 func toAny(typeURL string, values []proto.Message) ([]*any.Any, error) {
@@ -19,10 +19,17 @@ import (
 	"io"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/heptio/contour/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/status"
 )
 
 func TestXDSHandlerStream(t *testing.T) {
@@ -150,6 +157,255 @@ func TestXDSHandlerStream(t *testing.T) {
 	}
 }
 
+func TestXDSHandlerStreamACKNACK(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			"com.heptio.potato": &mockResource{
+				register: func(ch chan int, i int) {
+					ch <- i + 1
+				},
+				contents: func() []proto.Message {
+					return []proto.Message{new(v2.ClusterLoadAssignment)}
+				},
+				typeurl: func() string { return "com.heptio.potato" },
+			},
+		},
+		status: new(StatusCache),
+	}
+
+	var got []NodeStatus
+	reqs := []*v2.DiscoveryRequest{{
+		// initial request.
+		TypeUrl: "com.heptio.potato",
+		Node:    &envoy_api_v2_core.Node{Id: "envoy"},
+	}, {
+		// ACK of version 0.
+		TypeUrl:       "com.heptio.potato",
+		VersionInfo:   "0",
+		ResponseNonce: "0",
+	}, {
+		// NACK of version 1.
+		TypeUrl:       "com.heptio.potato",
+		VersionInfo:   "0",
+		ResponseNonce: "1",
+		ErrorDetail:   &status.Status{Message: "bad config"},
+	}, {
+		// stale nonce, ignored.
+		TypeUrl:       "com.heptio.potato",
+		VersionInfo:   "0",
+		ResponseNonce: "0",
+		ErrorDetail:   &status.Status{Message: "stale"},
+	}}
+	var sent int
+	st := &mockStream{
+		context: context.Background,
+		recv: func() (*v2.DiscoveryRequest, error) {
+			if len(reqs) == 0 {
+				got = xh.status.Nodes()
+				return nil, io.EOF
+			}
+			req := reqs[0]
+			reqs = reqs[1:]
+			return req, nil
+		},
+		send: func(resp *v2.DiscoveryResponse) error {
+			sent++
+			return nil
+		},
+	}
+
+	if err := xh.stream(st); err != io.EOF {
+		t.Fatalf("expected: %v, got: %v", io.EOF, err)
+	}
+	if sent != 3 {
+		t.Fatalf("expected 3 responses, got %d", sent)
+	}
+
+	want := []NodeStatus{{
+		Node: "envoy",
+		Streams: []ResourceStatus{{
+			TypeURL:           "com.heptio.potato",
+			Connection:        1,
+			LastSentVersion:   "2",
+			LastSentNonce:     "2",
+			LastAckedVersion:  "0",
+			LastNackedVersion: "1",
+			LastNackMessage:   "bad config",
+		}},
+	}}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ResourceStatus{}, "LastNackTime")); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// the status of the Envoy is discarded when the stream closes.
+	if nodes := xh.status.Nodes(); len(nodes) != 0 {
+		t.Fatalf("expected no nodes, got %v", nodes)
+	}
+}
+
+func TestXDSHandlerStreamMetrics(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	r := prometheus.NewRegistry()
+	m := metrics.NewMetrics(r)
+	m.SetDAGLastRebuilt(time.Now())
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			"com.heptio.potato": &mockResource{
+				register: func(ch chan int, i int) {
+					ch <- i + 1
+				},
+				contents: func() []proto.Message {
+					return []proto.Message{new(v2.ClusterLoadAssignment)}
+				},
+				typeurl: func() string { return "com.heptio.potato" },
+			},
+		},
+		metrics: m,
+	}
+
+	// gather returns the value of each xDS metric for the Envoy.
+	gather := func() map[string]float64 {
+		mfs, err := r.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make(map[string]float64)
+		for _, mf := range mfs {
+			for _, metric := range mf.Metric {
+				switch {
+				case metric.Gauge != nil:
+					values[mf.GetName()] = metric.Gauge.GetValue()
+				case metric.Counter != nil:
+					values[mf.GetName()] = metric.Counter.GetValue()
+				case metric.Summary != nil:
+					values[mf.GetName()] = float64(metric.Summary.GetSampleCount())
+				}
+			}
+		}
+		return values
+	}
+
+	var got map[string]float64
+	reqs := []*v2.DiscoveryRequest{{
+		// initial request.
+		TypeUrl: "com.heptio.potato",
+		Node:    &envoy_api_v2_core.Node{Id: "envoy"},
+	}, {
+		// NACK of version 0.
+		TypeUrl:       "com.heptio.potato",
+		VersionInfo:   "",
+		ResponseNonce: "0",
+		ErrorDetail:   &status.Status{Message: "bad config"},
+	}}
+	var size int
+	st := &mockStream{
+		context: context.Background,
+		recv: func() (*v2.DiscoveryRequest, error) {
+			if len(reqs) == 0 {
+				got = gather()
+				return nil, io.EOF
+			}
+			req := reqs[0]
+			reqs = reqs[1:]
+			return req, nil
+		},
+		send: func(resp *v2.DiscoveryResponse) error {
+			size += proto.Size(resp)
+			return nil
+		},
+	}
+
+	if err := xh.stream(st); err != io.EOF {
+		t.Fatalf("expected: %v, got: %v", io.EOF, err)
+	}
+
+	want := map[string]float64{
+		metrics.XDSStreamsGauge:         1,
+		metrics.XDSResponsesCounter:     2,
+		metrics.XDSResponseBytesCounter: float64(size),
+		metrics.XDSNacksCounter:         1,
+		// the initial response is not a push.
+		metrics.XDSResponseDelaySummary: 1,
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreMapEntries(func(k string, _ float64) bool {
+		_, ok := want[k]
+		return !ok
+	})); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// the per node metrics are discarded when the stream closes.
+	got = gather()
+	for _, name := range []string{
+		metrics.XDSStreamsGauge,
+		metrics.XDSResponsesCounter,
+		metrics.XDSResponseBytesCounter,
+		metrics.XDSNacksCounter,
+	} {
+		if _, ok := got[name]; ok {
+			t.Errorf("expected no %s metric, got %v", name, got[name])
+		}
+	}
+}
+
+func TestXDSHandlerStreamWaitsForReady(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	ready := make(chan struct{})
+	xh := xdsHandler{
+		FieldLogger: log,
+		resources: map[string]Resource{
+			"com.heptio.potato": &mockResource{
+				register: func(ch chan int, i int) {
+					ch <- i + 1
+				},
+				contents: func() []proto.Message {
+					return []proto.Message{new(v2.ClusterLoadAssignment)}
+				},
+				typeurl: func() string { return "com.heptio.potato" },
+			},
+		},
+		ready: ready,
+	}
+
+	sent := make(chan *v2.DiscoveryResponse, 1)
+	st := &mockStream{
+		context: context.Background,
+		recv: func() (*v2.DiscoveryRequest, error) {
+			return &v2.DiscoveryRequest{TypeUrl: "com.heptio.potato"}, nil
+		},
+		send: func(resp *v2.DiscoveryResponse) error {
+			sent <- resp
+			return io.EOF
+		},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- xh.stream(st)
+	}()
+
+	select {
+	case <-sent:
+		t.Fatal("response sent before ready")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(ready)
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("no response sent after ready")
+	}
+	if err := <-done; err != io.EOF {
+		t.Fatalf("expected: %v, got: %v", io.EOF, err)
+	}
+}
+
 type mockStream struct {
 	context func() context.Context
 	send    func(*v2.DiscoveryResponse) error
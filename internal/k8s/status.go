@@ -29,7 +29,8 @@ type CRDStatus struct {
 	Client clientset.Interface
 }
 
-// SetStatus sets the IngressRoute status field to an Valid or Invalid status
+// SetStatus sets the status field of an IngressRoute, HTTPProxy, or
+// TLSCertificateDelegation to an Valid or Invalid status
 func (irs *CRDStatus) SetStatus(status, desc string, existing interface{}) error {
 	switch exist := existing.(type) {
 	case *ingressroutev1.IngressRoute:
@@ -52,6 +53,24 @@ func (irs *CRDStatus) SetStatus(status, desc string, existing interface{}) error
 			}
 			return irs.setHTTPProxyStatus(exist, updated)
 		}
+	case *ingressroutev1.TLSCertificateDelegation:
+		if irs.updateNeeded(status, desc, exist.Status) {
+			updated := exist.DeepCopy()
+			updated.Status = projcontour.Status{
+				CurrentStatus: status,
+				Description:   desc,
+			}
+			return irs.setIngressRouteDelegationStatus(exist, updated)
+		}
+	case *projcontour.TLSCertificateDelegation:
+		if irs.updateNeeded(status, desc, exist.Status) {
+			updated := exist.DeepCopy()
+			updated.Status = projcontour.Status{
+				CurrentStatus: status,
+				Description:   desc,
+			}
+			return irs.setHTTPProxyDelegationStatus(exist, updated)
+		}
 	}
 	return nil
 }
@@ -64,19 +83,11 @@ func (irs *CRDStatus) updateNeeded(status, desc string, existing projcontour.Sta
 }
 
 func (irs *CRDStatus) setIngressRouteStatus(existing, updated *ingressroutev1.IngressRoute) error {
-	existingBytes, err := json.Marshal(existing)
-	if err != nil {
-		return err
-	}
 	// Need to set the resource version of the updated endpoints to the resource
 	// version of the current service. Otherwise, the resulting patch does not
 	// have a resource version, and the server complains.
 	updated.ResourceVersion = existing.ResourceVersion
-	updatedBytes, err := json.Marshal(updated)
-	if err != nil {
-		return err
-	}
-	patchBytes, err := jsonpatch.CreateMergePatch(existingBytes, updatedBytes)
+	patchBytes, err := createMergePatch(existing, updated)
 	if err != nil {
 		return err
 	}
@@ -86,23 +97,48 @@ func (irs *CRDStatus) setIngressRouteStatus(existing, updated *ingressroutev1.In
 }
 
 func (irs *CRDStatus) setHTTPProxyStatus(existing, updated *projcontour.HTTPProxy) error {
-	existingBytes, err := json.Marshal(existing)
+	updated.ResourceVersion = existing.ResourceVersion
+	patchBytes, err := createMergePatch(existing, updated)
 	if err != nil {
 		return err
 	}
-	// Need to set the resource version of the updated endpoints to the resource
-	// version of the current service. Otherwise, the resulting patch does not
-	// have a resource version, and the server complains.
+
+	_, err = irs.Client.ProjectcontourV1alpha1().HTTPProxies(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
+	return err
+}
+
+func (irs *CRDStatus) setIngressRouteDelegationStatus(existing, updated *ingressroutev1.TLSCertificateDelegation) error {
 	updated.ResourceVersion = existing.ResourceVersion
-	updatedBytes, err := json.Marshal(updated)
+	patchBytes, err := createMergePatch(existing, updated)
 	if err != nil {
 		return err
 	}
-	patchBytes, err := jsonpatch.CreateMergePatch(existingBytes, updatedBytes)
+
+	_, err = irs.Client.ContourV1beta1().TLSCertificateDelegations(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
+	return err
+}
+
+func (irs *CRDStatus) setHTTPProxyDelegationStatus(existing, updated *projcontour.TLSCertificateDelegation) error {
+	updated.ResourceVersion = existing.ResourceVersion
+	patchBytes, err := createMergePatch(existing, updated)
 	if err != nil {
 		return err
 	}
 
-	_, err = irs.Client.ProjectcontourV1alpha1().HTTPProxies(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
+	_, err = irs.Client.ProjectcontourV1alpha1().TLSCertificateDelegations(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
 	return err
 }
+
+// createMergePatch returns a JSON merge patch which transforms
+// existing into updated.
+func createMergePatch(existing, updated interface{}) ([]byte, error) {
+	existingBytes, err := json.Marshal(existing)
+	if err != nil {
+		return nil, err
+	}
+	updatedBytes, err := json.Marshal(updated)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.CreateMergePatch(existingBytes, updatedBytes)
+}
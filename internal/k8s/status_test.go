@@ -113,3 +113,35 @@ func TestSetStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestSetTLSCertificateDelegationStatus(t *testing.T) {
+	existing := &projcontour.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "delegation",
+			Namespace: "default",
+		},
+	}
+
+	var gotPatchBytes []byte
+	client := fake.NewSimpleClientset(existing)
+	client.PrependReactor("patch", "tlscertificatedelegations", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		switch patchAction := action.(type) {
+		default:
+			return true, nil, fmt.Errorf("got unexpected action of type: %T", action)
+		case k8stesting.PatchActionImpl:
+			gotPatchBytes = patchAction.GetPatch()
+			return true, existing, nil
+		}
+	})
+	irs := CRDStatus{
+		Client: client,
+	}
+	if err := irs.SetStatus("invalid", `secret "wildcard" not found or is malformed`, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"status":{"currentStatus":"invalid","description":"secret \"wildcard\" not found or is malformed"}}`
+	if want != string(gotPatchBytes) {
+		t.Fatalf("expected patch: %s, got: %s", want, string(gotPatchBytes))
+	}
+}
@@ -17,6 +17,7 @@ package metrics
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"k8s.io/client-go/kubernetes"
@@ -28,22 +29,45 @@ import (
 
 // Metrics provide Prometheus metrics for the app
 type Metrics struct {
-	ingressRouteTotalGauge      *prometheus.GaugeVec
-	ingressRouteRootTotalGauge  *prometheus.GaugeVec
-	ingressRouteInvalidGauge    *prometheus.GaugeVec
-	ingressRouteValidGauge      *prometheus.GaugeVec
-	ingressRouteOrphanedGauge   *prometheus.GaugeVec
+	ingressRouteGauges          routeGauges
 	ingressRouteDAGRebuildGauge *prometheus.GaugeVec
+	httpProxyGauges             routeGauges
+	objectStatusGauge           *prometheus.GaugeVec
 
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	ResourceEventHandlerSummary *prometheus.SummaryVec
 
+	xdsStreamsGauge         *prometheus.GaugeVec
+	xdsResponsesCounter     *prometheus.CounterVec
+	xdsResponseBytesCounter *prometheus.CounterVec
+	xdsNacksCounter         *prometheus.CounterVec
+	xdsResponseDelaySummary *prometheus.SummaryVec
+
 	// Keep a local cache of metrics for comparison on updates
-	metricCache *IngressRouteMetric
+	ingressRouteMetricCache *RouteMetric
+	httpProxyMetricCache    *RouteMetric
+	objectStatusMetricCache map[ObjectStatusMeta]int
+
+	// mu protects xdsStreams and dagLastRebuilt which are
+	// accessed by each xDS stream.
+	mu             sync.Mutex
+	xdsStreams     map[xdsStream]int
+	dagLastRebuilt time.Time
 }
 
-// IngressRouteMetric stores various metrics for IngressRoute objects
-type IngressRouteMetric struct {
+// xdsStream identifies the streams of one resource type to an Envoy.
+type xdsStream struct {
+	typeURL, node string
+}
+
+// routeGauges holds the gauges describing one kind of route object.
+type routeGauges struct {
+	total, root, invalid, valid, orphaned *prometheus.GaugeVec
+}
+
+// RouteMetric stores various metrics for route objects,
+// such as IngressRoutes or HTTPProxies.
+type RouteMetric struct {
 	Total    map[Meta]int
 	Valid    map[Meta]int
 	Invalid  map[Meta]int
@@ -56,6 +80,11 @@ type Meta struct {
 	VHost, Namespace string
 }
 
+// ObjectStatusMeta holds the group, kind, namespace, and status of a metric object
+type ObjectStatusMeta struct {
+	Group, Kind, Namespace, Status string
+}
+
 const (
 	IngressRouteTotalGauge      = "contour_ingressroute_total"
 	IngressRouteRootTotalGauge  = "contour_ingressroute_root_total"
@@ -64,50 +93,79 @@ const (
 	IngressRouteOrphanedGauge   = "contour_ingressroute_orphaned_total"
 	IngressRouteDAGRebuildGauge = "contour_ingressroute_dagrebuild_timestamp"
 
+	HTTPProxyTotalGauge     = "contour_httpproxy_total"
+	HTTPProxyRootTotalGauge = "contour_httpproxy_root_total"
+	HTTPProxyInvalidGauge   = "contour_httpproxy_invalid_total"
+	HTTPProxyValidGauge     = "contour_httpproxy_valid_total"
+	HTTPProxyOrphanedGauge  = "contour_httpproxy_orphaned_total"
+
+	ObjectStatusGauge = "contour_object_status_total"
+
+	XDSStreamsGauge         = "contour_xds_streams"
+	XDSResponsesCounter     = "contour_xds_responses_total"
+	XDSResponseBytesCounter = "contour_xds_response_bytes_total"
+	XDSNacksCounter         = "contour_xds_nacks_total"
+	XDSResponseDelaySummary = "contour_xds_response_delay_seconds"
+
 	cacheHandlerOnUpdateSummary = "contour_cachehandler_onupdate_duration_seconds"
 	resourceEventHandlerSummary = "contour_resourceeventhandler_duration_seconds"
 )
 
-// NewMetrics creates a new set of metrics and registers them with
-// the supplied registry.
-func NewMetrics(registry *prometheus.Registry) *Metrics {
-	m := Metrics{
-		metricCache: &IngressRouteMetric{},
-		ingressRouteTotalGauge: prometheus.NewGaugeVec(
+// newRouteGauges returns the set of gauges describing the
+// route objects of the kind supplied, named by the supplied names.
+func newRouteGauges(kind, total, root, invalid, valid, orphaned string) routeGauges {
+	return routeGauges{
+		total: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: IngressRouteTotalGauge,
-				Help: "Total number of IngressRoutes",
+				Name: total,
+				Help: "Total number of " + kind,
 			},
 			[]string{"namespace"},
 		),
-		ingressRouteRootTotalGauge: prometheus.NewGaugeVec(
+		root: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: IngressRouteRootTotalGauge,
-				Help: "Total number of root IngressRoutes",
+				Name: root,
+				Help: "Total number of root " + kind,
 			},
 			[]string{"namespace"},
 		),
-		ingressRouteInvalidGauge: prometheus.NewGaugeVec(
+		invalid: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: IngressRouteInvalidGauge,
-				Help: "Total number of invalid IngressRoutes",
+				Name: invalid,
+				Help: "Total number of invalid " + kind,
 			},
 			[]string{"namespace", "vhost"},
 		),
-		ingressRouteValidGauge: prometheus.NewGaugeVec(
+		valid: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: IngressRouteValidGauge,
-				Help: "Total number of valid IngressRoutes",
+				Name: valid,
+				Help: "Total number of valid " + kind,
 			},
 			[]string{"namespace", "vhost"},
 		),
-		ingressRouteOrphanedGauge: prometheus.NewGaugeVec(
+		orphaned: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: IngressRouteOrphanedGauge,
-				Help: "Total number of orphaned IngressRoutes",
+				Name: orphaned,
+				Help: "Total number of orphaned " + kind,
 			},
 			[]string{"namespace"},
 		),
+	}
+}
+
+// NewMetrics creates a new set of metrics and registers them with
+// the supplied registry.
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := Metrics{
+		ingressRouteMetricCache: &RouteMetric{},
+		httpProxyMetricCache:    &RouteMetric{},
+		ingressRouteGauges: newRouteGauges("IngressRoutes",
+			IngressRouteTotalGauge,
+			IngressRouteRootTotalGauge,
+			IngressRouteInvalidGauge,
+			IngressRouteValidGauge,
+			IngressRouteOrphanedGauge,
+		),
 		ingressRouteDAGRebuildGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: IngressRouteDAGRebuildGauge,
@@ -115,6 +173,20 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{},
 		),
+		httpProxyGauges: newRouteGauges("HTTPProxies",
+			HTTPProxyTotalGauge,
+			HTTPProxyRootTotalGauge,
+			HTTPProxyInvalidGauge,
+			HTTPProxyValidGauge,
+			HTTPProxyOrphanedGauge,
+		),
+		objectStatusGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: ObjectStatusGauge,
+				Help: "Total number of objects with a status, by group, kind and status",
+			},
+			[]string{"group", "kind", "namespace", "status"},
+		),
 		CacheHandlerOnUpdateSummary: prometheus.NewSummary(prometheus.SummaryOpts{
 			Name:       cacheHandlerOnUpdateSummary,
 			Help:       "Histogram for the runtime of xDS cache regeneration",
@@ -127,6 +199,42 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 		},
 			[]string{"op"},
 		),
+		xdsStreamsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: XDSStreamsGauge,
+				Help: "Number of connected xDS streams",
+			},
+			[]string{"type_url", "node_id"},
+		),
+		xdsResponsesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: XDSResponsesCounter,
+				Help: "Total number of xDS responses sent",
+			},
+			[]string{"type_url", "node_id"},
+		),
+		xdsResponseBytesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: XDSResponseBytesCounter,
+				Help: "Total size in bytes of the xDS responses sent",
+			},
+			[]string{"type_url", "node_id"},
+		),
+		xdsNacksCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: XDSNacksCounter,
+				Help: "Total number of xDS responses rejected by Envoy",
+			},
+			[]string{"type_url", "node_id"},
+		),
+		xdsResponseDelaySummary: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       XDSResponseDelaySummary,
+			Help:       "Histogram for the time between a DAG rebuild and the xDS responses it causes",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+			[]string{"type_url"},
+		),
+		xdsStreams: make(map[xdsStream]int),
 	}
 	m.register(registry)
 	return &m
@@ -135,65 +243,172 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 // register registers the Metrics with the supplied registry.
 func (m *Metrics) register(registry *prometheus.Registry) {
 	registry.MustRegister(
-		m.ingressRouteTotalGauge,
-		m.ingressRouteRootTotalGauge,
-		m.ingressRouteInvalidGauge,
-		m.ingressRouteValidGauge,
-		m.ingressRouteOrphanedGauge,
+		m.ingressRouteGauges.total,
+		m.ingressRouteGauges.root,
+		m.ingressRouteGauges.invalid,
+		m.ingressRouteGauges.valid,
+		m.ingressRouteGauges.orphaned,
 		m.ingressRouteDAGRebuildGauge,
+		m.httpProxyGauges.total,
+		m.httpProxyGauges.root,
+		m.httpProxyGauges.invalid,
+		m.httpProxyGauges.valid,
+		m.httpProxyGauges.orphaned,
+		m.objectStatusGauge,
 		m.CacheHandlerOnUpdateSummary,
 		m.ResourceEventHandlerSummary,
+		m.xdsStreamsGauge,
+		m.xdsResponsesCounter,
+		m.xdsResponseBytesCounter,
+		m.xdsNacksCounter,
+		m.xdsResponseDelaySummary,
 	)
 }
 
 // SetDAGLastRebuilt records the last time the DAG was rebuilt.
 func (m *Metrics) SetDAGLastRebuilt(ts time.Time) {
 	m.ingressRouteDAGRebuildGauge.WithLabelValues().Set(float64(ts.Unix()))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dagLastRebuilt = ts
+}
+
+// The XDS methods below are called from each xDS stream.
+// A nil *Metrics records nothing.
+
+// XDSStreamOpened records a new xDS stream of typeURL to node.
+func (m *Metrics) XDSStreamOpened(typeURL, node string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stream := xdsStream{typeURL: typeURL, node: node}
+	m.xdsStreams[stream]++
+	m.xdsStreamsGauge.WithLabelValues(typeURL, node).Set(float64(m.xdsStreams[stream]))
+}
+
+// XDSStreamClosed records that an xDS stream of typeURL to node has closed.
+// Once node has no streams of typeURL its metrics are removed so departed
+// Envoys are not reported forever.
+func (m *Metrics) XDSStreamClosed(typeURL, node string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stream := xdsStream{typeURL: typeURL, node: node}
+	m.xdsStreams[stream]--
+	if m.xdsStreams[stream] > 0 {
+		m.xdsStreamsGauge.WithLabelValues(typeURL, node).Set(float64(m.xdsStreams[stream]))
+		return
+	}
+	delete(m.xdsStreams, stream)
+	m.xdsStreamsGauge.DeleteLabelValues(typeURL, node)
+	m.xdsResponsesCounter.DeleteLabelValues(typeURL, node)
+	m.xdsResponseBytesCounter.DeleteLabelValues(typeURL, node)
+	m.xdsNacksCounter.DeleteLabelValues(typeURL, node)
+}
+
+// XDSResponseSent records that a response of size bytes was sent to node.
+// If push is true the response was caused by a change to the caches, and the
+// time since the DAG was last rebuilt is recorded.
+func (m *Metrics) XDSResponseSent(typeURL, node string, size int, push bool) {
+	if m == nil {
+		return
+	}
+	m.xdsResponsesCounter.WithLabelValues(typeURL, node).Inc()
+	m.xdsResponseBytesCounter.WithLabelValues(typeURL, node).Add(float64(size))
+	if !push {
+		return
+	}
+	m.mu.Lock()
+	last := m.dagLastRebuilt
+	m.mu.Unlock()
+	if !last.IsZero() {
+		m.xdsResponseDelaySummary.WithLabelValues(typeURL).Observe(time.Since(last).Seconds())
+	}
+}
+
+// XDSNackReceived records that node rejected a response of typeURL.
+func (m *Metrics) XDSNackReceived(typeURL, node string) {
+	if m == nil {
+		return
+	}
+	m.xdsNacksCounter.WithLabelValues(typeURL, node).Inc()
 }
 
 // SetIngressRouteMetric sets metric values for a set of IngressRoutes
-func (m *Metrics) SetIngressRouteMetric(metrics IngressRouteMetric) {
+func (m *Metrics) SetIngressRouteMetric(metrics RouteMetric) {
+	m.ingressRouteMetricCache = m.ingressRouteGauges.set(m.ingressRouteMetricCache, metrics)
+}
+
+// SetHTTPProxyMetric sets metric values for a set of HTTPProxies
+func (m *Metrics) SetHTTPProxyMetric(metrics RouteMetric) {
+	m.httpProxyMetricCache = m.httpProxyGauges.set(m.httpProxyMetricCache, metrics)
+}
+
+// SetObjectStatusMetric sets the number of objects of each kind,
+// in each namespace, with each status.
+func (m *Metrics) SetObjectStatusMetric(metrics map[ObjectStatusMeta]int) {
+	for meta, value := range metrics {
+		m.objectStatusGauge.WithLabelValues(meta.Group, meta.Kind, meta.Namespace, meta.Status).Set(float64(value))
+		delete(m.objectStatusMetricCache, meta)
+	}
+
+	// All metrics processed, now remove what's left as they are not needed
+	for meta := range m.objectStatusMetricCache {
+		m.objectStatusGauge.DeleteLabelValues(meta.Group, meta.Kind, meta.Namespace, meta.Status)
+	}
+
+	m.objectStatusMetricCache = metrics
+}
+
+// set sets the gauges to the supplied metrics, removing any values
+// present in the cache of previous metrics but not in metrics.
+// set returns the new cache of metrics.
+func (g *routeGauges) set(cache *RouteMetric, metrics RouteMetric) *RouteMetric {
 	// Process metrics
 	for meta, value := range metrics.Total {
-		m.ingressRouteTotalGauge.WithLabelValues(meta.Namespace).Set(float64(value))
-		delete(m.metricCache.Total, meta)
+		g.total.WithLabelValues(meta.Namespace).Set(float64(value))
+		delete(cache.Total, meta)
 	}
 	for meta, value := range metrics.Invalid {
-		m.ingressRouteInvalidGauge.WithLabelValues(meta.Namespace, meta.VHost).Set(float64(value))
-		delete(m.metricCache.Invalid, meta)
+		g.invalid.WithLabelValues(meta.Namespace, meta.VHost).Set(float64(value))
+		delete(cache.Invalid, meta)
 	}
 	for meta, value := range metrics.Orphaned {
-		m.ingressRouteOrphanedGauge.WithLabelValues(meta.Namespace).Set(float64(value))
-		delete(m.metricCache.Orphaned, meta)
+		g.orphaned.WithLabelValues(meta.Namespace).Set(float64(value))
+		delete(cache.Orphaned, meta)
 	}
 	for meta, value := range metrics.Valid {
-		m.ingressRouteValidGauge.WithLabelValues(meta.Namespace, meta.VHost).Set(float64(value))
-		delete(m.metricCache.Valid, meta)
+		g.valid.WithLabelValues(meta.Namespace, meta.VHost).Set(float64(value))
+		delete(cache.Valid, meta)
 	}
 	for meta, value := range metrics.Root {
-		m.ingressRouteRootTotalGauge.WithLabelValues(meta.Namespace).Set(float64(value))
-		delete(m.metricCache.Root, meta)
+		g.root.WithLabelValues(meta.Namespace).Set(float64(value))
+		delete(cache.Root, meta)
 	}
 
 	// All metrics processed, now remove what's left as they are not needed
-	for meta := range m.metricCache.Total {
-		m.ingressRouteTotalGauge.DeleteLabelValues(meta.Namespace)
+	for meta := range cache.Total {
+		g.total.DeleteLabelValues(meta.Namespace)
 	}
-	for meta := range m.metricCache.Invalid {
-		m.ingressRouteInvalidGauge.DeleteLabelValues(meta.Namespace, meta.VHost)
+	for meta := range cache.Invalid {
+		g.invalid.DeleteLabelValues(meta.Namespace, meta.VHost)
 	}
-	for meta := range m.metricCache.Orphaned {
-		m.ingressRouteOrphanedGauge.DeleteLabelValues(meta.Namespace)
+	for meta := range cache.Orphaned {
+		g.orphaned.DeleteLabelValues(meta.Namespace)
 	}
-	for meta := range m.metricCache.Valid {
-		m.ingressRouteValidGauge.DeleteLabelValues(meta.Namespace, meta.VHost)
+	for meta := range cache.Valid {
+		g.valid.DeleteLabelValues(meta.Namespace, meta.VHost)
 	}
-	for meta := range m.metricCache.Root {
-		m.ingressRouteRootTotalGauge.DeleteLabelValues(meta.Namespace)
+	for meta := range cache.Root {
+		g.root.DeleteLabelValues(meta.Namespace)
 	}
 
-	// copier.Copy(&m.metricCache, metrics)
-	m.metricCache = &IngressRouteMetric{
+	return &RouteMetric{
 		Total:    metrics.Total,
 		Invalid:  metrics.Invalid,
 		Valid:    metrics.Valid,
@@ -207,6 +422,9 @@ type Service struct {
 	httpsvc.Service
 	*prometheus.Registry
 	Client *kubernetes.Clientset
+
+	// Ready is closed once Contour is ready to serve xDS.
+	Ready <-chan struct{}
 }
 
 // Start fulfills the g.Start contract.
@@ -214,6 +432,7 @@ type Service struct {
 func (svc *Service) Start(stop <-chan struct{}) error {
 
 	registerHealthCheck(&svc.ServeMux, svc.Client)
+	registerReadinessCheck(&svc.ServeMux, svc.Ready)
 	registerMetrics(&svc.ServeMux, svc.Registry)
 
 	return svc.Service.Start(stop)
@@ -235,6 +454,18 @@ func registerHealthCheck(mux *http.ServeMux, client *kubernetes.Clientset) {
 	mux.HandleFunc("/healthz", healthCheckHandler)
 }
 
+func registerReadinessCheck(mux *http.ServeMux, ready <-chan struct{}) {
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-ready:
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "OK")
+		default:
+			http.Error(w, "Not ready: waiting for informers to sync", http.StatusServiceUnavailable)
+		}
+	})
+}
+
 func registerMetrics(mux *http.ServeMux, registry *prometheus.Registry) {
 	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
 }
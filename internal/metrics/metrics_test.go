@@ -14,6 +14,8 @@
 package metrics
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -80,7 +82,7 @@ func TestSetDAGLastRebuilt(t *testing.T) {
 
 func TestWriteIngressRouteMetric(t *testing.T) {
 	tests := map[string]struct {
-		irMetrics IngressRouteMetric
+		irMetrics RouteMetric
 		total     testMetric
 		valid     testMetric
 		invalid   testMetric
@@ -88,7 +90,7 @@ func TestWriteIngressRouteMetric(t *testing.T) {
 		root      testMetric
 	}{
 		"simple": {
-			irMetrics: IngressRouteMetric{
+			irMetrics: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 					{Namespace: "foons"}:  3,
@@ -341,8 +343,8 @@ func TestRemoveMetric(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		irMetrics        IngressRouteMetric
-		irMetricsUpdated IngressRouteMetric
+		irMetrics        RouteMetric
+		irMetricsUpdated RouteMetric
 		totalWant        []*io_prometheus_client.Metric
 		validWant        []*io_prometheus_client.Metric
 		invalidWant      []*io_prometheus_client.Metric
@@ -350,7 +352,7 @@ func TestRemoveMetric(t *testing.T) {
 		rootWant         []*io_prometheus_client.Metric
 	}{
 		"orphan is resolved": {
-			irMetrics: IngressRouteMetric{
+			irMetrics: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 					{Namespace: "foons"}:  3,
@@ -368,7 +370,7 @@ func TestRemoveMetric(t *testing.T) {
 					{Namespace: "testns"}: 4,
 				},
 			},
-			irMetricsUpdated: IngressRouteMetric{
+			irMetricsUpdated: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 					{Namespace: "foons"}:  3,
@@ -446,7 +448,7 @@ func TestRemoveMetric(t *testing.T) {
 			},
 		},
 		"root IngressRoute is deleted": {
-			irMetrics: IngressRouteMetric{
+			irMetrics: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 					{Namespace: "foons"}:  3,
@@ -464,7 +466,7 @@ func TestRemoveMetric(t *testing.T) {
 					{Namespace: "testns"}: 4,
 				},
 			},
-			irMetricsUpdated: IngressRouteMetric{
+			irMetricsUpdated: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 				},
@@ -532,7 +534,7 @@ func TestRemoveMetric(t *testing.T) {
 			},
 		},
 		"valid is deleted from namespace": {
-			irMetrics: IngressRouteMetric{
+			irMetrics: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 					{Namespace: "foons"}:  3,
@@ -550,7 +552,7 @@ func TestRemoveMetric(t *testing.T) {
 					{Namespace: "testns"}: 4,
 				},
 			},
-			irMetricsUpdated: IngressRouteMetric{
+			irMetricsUpdated: RouteMetric{
 				Total: map[Meta]int{
 					{Namespace: "testns"}: 6,
 				},
@@ -722,3 +724,205 @@ func TestRemoveMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteHTTPProxyMetric(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+	m.SetHTTPProxyMetric(RouteMetric{
+		Total: map[Meta]int{
+			{Namespace: "testns"}: 2,
+		},
+		Valid: map[Meta]int{
+			{Namespace: "testns", VHost: "foo.com"}: 1,
+		},
+		Orphaned: map[Meta]int{
+			{Namespace: "testns"}: 1,
+		},
+		Root: map[Meta]int{
+			{Namespace: "testns"}: 1,
+		},
+	})
+	// HTTPProxies must not be counted as IngressRoutes.
+	m.SetIngressRouteMetric(RouteMetric{})
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][]*io_prometheus_client.Metric)
+	for _, mf := range gathering {
+		got[mf.GetName()] = mf.Metric
+	}
+
+	want := map[string][]*io_prometheus_client.Metric{
+		HTTPProxyTotalGauge: {{
+			Label: []*io_prometheus_client.LabelPair{{
+				Name:  func() *string { i := "namespace"; return &i }(),
+				Value: func() *string { i := "testns"; return &i }(),
+			}},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := float64(2); return &i }(),
+			},
+		}},
+		HTTPProxyValidGauge: {{
+			Label: []*io_prometheus_client.LabelPair{{
+				Name:  func() *string { i := "namespace"; return &i }(),
+				Value: func() *string { i := "testns"; return &i }(),
+			}, {
+				Name:  func() *string { i := "vhost"; return &i }(),
+				Value: func() *string { i := "foo.com"; return &i }(),
+			}},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := float64(1); return &i }(),
+			},
+		}},
+		HTTPProxyOrphanedGauge: {{
+			Label: []*io_prometheus_client.LabelPair{{
+				Name:  func() *string { i := "namespace"; return &i }(),
+				Value: func() *string { i := "testns"; return &i }(),
+			}},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := float64(1); return &i }(),
+			},
+		}},
+		HTTPProxyRootTotalGauge: {{
+			Label: []*io_prometheus_client.LabelPair{{
+				Name:  func() *string { i := "namespace"; return &i }(),
+				Value: func() *string { i := "testns"; return &i }(),
+			}},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := float64(1); return &i }(),
+			},
+		}},
+	}
+
+	for name, w := range want {
+		if !reflect.DeepEqual(got[name], w) {
+			t.Fatalf("write metric %s failed, want: %v got: %v", name, w, got[name])
+		}
+	}
+	for _, name := range []string{HTTPProxyInvalidGauge, IngressRouteTotalGauge, IngressRouteValidGauge} {
+		if len(got[name]) > 0 {
+			t.Fatalf("expected no values for metric %s, got: %v", name, got[name])
+		}
+	}
+}
+
+func TestSetObjectStatusMetric(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+	m.SetObjectStatusMetric(map[ObjectStatusMeta]int{
+		{Group: "projectcontour.io", Kind: "HTTPProxy", Namespace: "testns", Status: "valid"}:     2,
+		{Group: "contour.heptio.com", Kind: "IngressRoute", Namespace: "testns", Status: "valid"}: 1,
+	})
+	// the IngressRoute has been removed.
+	m.SetObjectStatusMetric(map[ObjectStatusMeta]int{
+		{Group: "projectcontour.io", Kind: "HTTPProxy", Namespace: "testns", Status: "valid"}: 3,
+	})
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []*io_prometheus_client.Metric{}
+	for _, mf := range gathering {
+		if mf.GetName() == ObjectStatusGauge {
+			got = mf.Metric
+		}
+	}
+
+	want := []*io_prometheus_client.Metric{{
+		Label: []*io_prometheus_client.LabelPair{{
+			Name:  func() *string { i := "group"; return &i }(),
+			Value: func() *string { i := "projectcontour.io"; return &i }(),
+		}, {
+			Name:  func() *string { i := "kind"; return &i }(),
+			Value: func() *string { i := "HTTPProxy"; return &i }(),
+		}, {
+			Name:  func() *string { i := "namespace"; return &i }(),
+			Value: func() *string { i := "testns"; return &i }(),
+		}, {
+			Name:  func() *string { i := "status"; return &i }(),
+			Value: func() *string { i := "valid"; return &i }(),
+		}},
+		Gauge: &io_prometheus_client.Gauge{
+			Value: func() *float64 { i := float64(3); return &i }(),
+		},
+	}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("write object status metric failed, want: %v got: %v", want, got)
+	}
+}
+
+func TestXDSStreamMetric(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	streams := func() []*io_prometheus_client.Metric {
+		gathering, err := r.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, mf := range gathering {
+			if mf.GetName() == XDSStreamsGauge {
+				return mf.Metric
+			}
+		}
+		return nil
+	}
+
+	m.XDSStreamOpened("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+	m.XDSStreamOpened("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+	m.XDSStreamClosed("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+
+	want := []*io_prometheus_client.Metric{{
+		Label: []*io_prometheus_client.LabelPair{{
+			Name:  func() *string { i := "node_id"; return &i }(),
+			Value: func() *string { i := "envoy"; return &i }(),
+		}, {
+			Name:  func() *string { i := "type_url"; return &i }(),
+			Value: func() *string { i := "type.googleapis.com/envoy.api.v2.Cluster"; return &i }(),
+		}},
+		Gauge: &io_prometheus_client.Gauge{
+			Value: func() *float64 { i := float64(1); return &i }(),
+		},
+	}}
+	if got := streams(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("write xds stream metric failed, want: %v got: %v", want, got)
+	}
+
+	// the series is removed once the last stream closes.
+	m.XDSStreamClosed("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+	if got := streams(); len(got) != 0 {
+		t.Fatalf("expected no values for metric %s, got: %v", XDSStreamsGauge, got)
+	}
+
+	// a nil *Metrics records nothing.
+	var nilMetrics *Metrics
+	nilMetrics.XDSStreamOpened("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+	nilMetrics.XDSResponseSent("type.googleapis.com/envoy.api.v2.Cluster", "envoy", 10, true)
+	nilMetrics.XDSNackReceived("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+	nilMetrics.XDSStreamClosed("type.googleapis.com/envoy.api.v2.Cluster", "envoy")
+}
+
+func TestReadinessCheck(t *testing.T) {
+	var mux http.ServeMux
+	ready := make(chan struct{})
+	registerReadinessCheck(&mux, ready)
+
+	check := func(want int) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+		if rec.Code != want {
+			t.Fatalf("expected status %d, got %d", want, rec.Code)
+		}
+	}
+
+	check(http.StatusServiceUnavailable)
+	close(ready)
+	check(http.StatusOK)
+}